@@ -0,0 +1,168 @@
+// Package codec encodes and decodes employee resources in the media types
+// clients can ask for, and picks one from an Accept header.
+package codec
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Codec reads and writes one media type.
+type Codec interface {
+	// MediaType is the type without parameters, such as "application/xml".
+	MediaType() string
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+// ErrUnsupportedValue is returned by codecs that only handle some types,
+// such as CSV, when given anything else.
+var ErrUnsupportedValue = errors.New("codec: unsupported value")
+
+// Registry holds codecs in order of preference. The first one is used when
+// a request has no Accept header or accepts anything.
+type Registry struct {
+	codecs []Codec
+}
+
+func NewRegistry(codecs ...Codec) *Registry {
+	r := &Registry{}
+	for _, c := range codecs {
+		r.Register(c)
+	}
+	return r
+}
+
+// Default returns a registry of JSON, XML, CSV and MessagePack, preferring
+// them in that order.
+func Default() *Registry {
+	return NewRegistry(JSON{}, XML{}, CSV{}, MessagePack{})
+}
+
+// Register adds c, replacing any codec for the same media type.
+func (r *Registry) Register(c Codec) {
+	for i, existing := range r.codecs {
+		if existing.MediaType() == c.MediaType() {
+			r.codecs[i] = c
+			return
+		}
+	}
+	r.codecs = append(r.codecs, c)
+}
+
+// MediaTypes lists the registered media types in order of preference.
+func (r *Registry) MediaTypes() []string {
+	types := make([]string, len(r.codecs))
+	for i, c := range r.codecs {
+		types[i] = c.MediaType()
+	}
+	return types
+}
+
+// Lookup returns the codec for a Content-Type header value. Parameters
+// such as charset are ignored.
+func (r *Registry) Lookup(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	for _, c := range r.codecs {
+		if c.MediaType() == mediaType {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// mediaRange is one entry of an Accept header.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+	index        int
+}
+
+// specificity ranks an exact type above type/* above */*.
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return m.typ == "*" || (m.typ == typ && (m.subtype == "*" || m.subtype == subtype))
+}
+
+// Negotiate picks the codec for an Accept header (RFC 9110, section
+// 12.5.1). Each codec gets the quality of the most specific range that
+// matches it; the highest quality wins, then the range the client listed
+// first, then the registry's order. It reports false when nothing
+// registered is acceptable.
+func (r *Registry) Negotiate(accept string) (Codec, bool) {
+	if len(r.codecs) == 0 {
+		return nil, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return r.codecs[0], true
+	}
+	ranges := parseAccept(accept)
+
+	var (
+		best      Codec
+		bestRange mediaRange
+	)
+	for _, c := range r.codecs {
+		match, ok := bestMatch(ranges, c.MediaType())
+		if !ok || match.q <= 0 {
+			continue
+		}
+		if best == nil || match.q > bestRange.q || (match.q == bestRange.q && match.index < bestRange.index) {
+			best, bestRange = c, match
+		}
+	}
+	return best, best != nil
+}
+
+func bestMatch(ranges []mediaRange, mediaType string) (mediaRange, bool) {
+	var (
+		best  mediaRange
+		found bool
+	)
+	for _, m := range ranges {
+		if m.matches(mediaType) && (!found || m.specificity() > best.specificity()) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// parseAccept splits an Accept header into media ranges, skipping any that
+// do not parse. A missing or invalid q counts as 1.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || (typ == "*" && subtype != "*") {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q, index: i})
+	}
+	return ranges
+}
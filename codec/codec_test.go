@@ -0,0 +1,153 @@
+package codec
+
+import (
+	"bytes"
+	"ems/models"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// usd is amount in US dollars.
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: "application/json"},
+		{accept: "*/*", want: "application/json"},
+		{accept: "application/xml", want: "application/xml"},
+		{accept: "text/csv; charset=utf-8", want: "text/csv"},
+		{accept: "application/xml, application/json", want: "application/xml"},
+		{accept: "application/json;q=0.5, application/msgpack", want: "application/msgpack"},
+		{accept: "text/*", want: "text/csv"},
+		{accept: "application/*;q=0.9, text/csv;q=0.8", want: "application/json"},
+		{accept: "*/*;q=0.1, application/json;q=0", want: "application/xml"},
+		{accept: "text/html", want: ""},
+		{accept: "image/*, application/pdf", want: ""},
+	}
+
+	r := Default()
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			got := ""
+			if c, ok := r.Negotiate(tt.accept); ok {
+				got = c.MediaType()
+			}
+			if got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	want := models.Employee{ID: 7, Name: "Zoë O'Neil", Position: "R&D, Tools", Salary: models.MustParseMoney("60000.5", "EUR"), DepartmentID: 3, ManagerID: 2}
+
+	for _, c := range []Codec{JSON{}, XML{}, CSV{}, MessagePack{}} {
+		t.Run(c.MediaType(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := c.Encode(&buf, want); err != nil {
+				t.Fatalf("Encode() unexpected error: %v", err)
+			}
+			var got models.Employee
+			if err := c.Decode(&buf, &got); err != nil {
+				t.Fatalf("Decode() unexpected error: %v", err)
+			}
+			if got != want {
+				t.Errorf("Decode(Encode(e)) = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodePage(t *testing.T) {
+	page := models.EmployeePage{
+		Items: []models.Employee{{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000")}},
+		Total: 1, Page: 1, Size: 10, TotalPages: 1,
+		Links: models.PageLinks{Self: "/employees?page=1&size=10"},
+	}
+	tests := []struct {
+		codec Codec
+		want  string
+	}{
+		{
+			codec: XML{},
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<employees><items><employee><id>1</id><name>John Doe</name><position>Developer</position><salary><amount>60000</amount><currency>USD</currency></salary></employee></items>` +
+				`<total>1</total><page>1</page><size>10</size><total_pages>1</total_pages><links><self>/employees?page=1&amp;size=10</self></links></employees>`,
+		},
+		{
+			codec: CSV{},
+			want:  "id,name,position,salary,currency,department_id,manager_id\n1,John Doe,Developer,60000,USD,0,0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codec.MediaType(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.codec.Encode(&buf, page); err != nil {
+				t.Fatalf("Encode() unexpected error: %v", err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("Encode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	var decoded models.EmployeePage
+	var buf bytes.Buffer
+	MessagePack{}.Encode(&buf, page)
+	if err := (MessagePack{}).Decode(&buf, &decoded); err != nil || !reflect.DeepEqual(decoded, page) {
+		t.Errorf("MessagePack page round trip = %+v (error %v), want %+v", decoded, err, page)
+	}
+}
+
+func TestDecodePlainSalary(t *testing.T) {
+	plain, _ := msgpack.Marshal(map[string]any{"name": "John Doe", "salary": 60000.5})
+	tests := []struct {
+		codec Codec
+		body  string
+	}{
+		{JSON{}, `{"name":"John Doe","salary":60000.5}`},
+		{XML{}, `<employee><name>John Doe</name><salary>60000.5</salary></employee>`},
+		{CSV{}, "name,salary\nJohn Doe,60000.5\n"},
+		{MessagePack{}, string(plain)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.codec.MediaType(), func(t *testing.T) {
+			var got models.Employee
+			if err := tt.codec.Decode(strings.NewReader(tt.body), &got); err != nil {
+				t.Fatalf("Decode() unexpected error: %v", err)
+			}
+			if got.Salary != models.MustParseMoney("60000.5", "") {
+				t.Errorf("Decode() salary = %v, want 60000.5 in no currency", got.Salary)
+			}
+		})
+	}
+}
+
+func TestCSVDecodeErrors(t *testing.T) {
+	tests := map[string]string{
+		"no data row":    "name,position,salary\n",
+		"two data rows":  "name,position,salary\nA,B,1\nC,D,2\n",
+		"unknown column": "name,position,salary,ssn\nA,B,1,123\n",
+		"bad salary":     "name,position,salary\nA,B,lots\n",
+		"bad department": "name,position,department_id\nA,B,Sales\n",
+		"bad manager":    "name,position,manager_id\nA,B,Alice\n",
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			var e models.Employee
+			if err := (CSV{}).Decode(strings.NewReader(body), &e); err == nil {
+				t.Errorf("Decode(%q) succeeded, want an error", body)
+			}
+		})
+	}
+}
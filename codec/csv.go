@@ -0,0 +1,161 @@
+package codec
+
+import (
+	"ems/fieldset"
+	"ems/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSV is the text/csv codec. It only handles employees: one employee, a
+// slice of them or a page, written as a header row and one row each, and
+// the same for fieldset projections of them, whose nested members become
+// dotted columns. Page metadata is left out; the Link header still carries
+// the navigation.
+type CSV struct{}
+
+var csvHeader = []string{"id", "name", "position", "salary", "currency", "department_id", "manager_id"}
+
+func (CSV) MediaType() string { return "text/csv" }
+
+func (CSV) Encode(w io.Writer, v any) error {
+	var (
+		employees []models.Employee
+		objects   []fieldset.Object
+	)
+	switch v := v.(type) {
+	case models.Employee:
+		employees = []models.Employee{v}
+	case *models.Employee:
+		employees = []models.Employee{*v}
+	case []models.Employee:
+		employees = v
+	case models.EmployeePage:
+		employees = v.Items
+	case fieldset.Object:
+		objects = []fieldset.Object{v}
+	case []fieldset.Object:
+		objects = v
+	case models.Page[fieldset.Object]:
+		objects = v.Items
+	default:
+		return fmt.Errorf("%w %T", ErrUnsupportedValue, v)
+	}
+
+	writer := csv.NewWriter(w)
+	if objects == nil {
+		writer.Write(csvHeader)
+	}
+	for _, e := range employees {
+		writer.Write([]string{
+			strconv.Itoa(e.ID),
+			e.Name,
+			e.Position,
+			e.Salary.Amount(),
+			e.Salary.Currency(),
+			strconv.Itoa(e.DepartmentID),
+			strconv.Itoa(e.ManagerID),
+		})
+	}
+	// Projections all share the same members, so the first one gives the
+	// header. An empty list has none to give and comes out empty.
+	for i, object := range objects {
+		names, values := flatten("", object, nil, nil)
+		if i == 0 {
+			writer.Write(names)
+		}
+		writer.Write(values)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func flatten(prefix string, object fieldset.Object, names, values []string) ([]string, []string) {
+	for _, m := range object {
+		if nested, ok := m.Value.(fieldset.Object); ok {
+			names, values = flatten(prefix+m.Name+".", nested, names, values)
+			continue
+		}
+		if money, ok := m.Value.(models.Money); ok {
+			// As in whole employees, the currency gets a column of its own.
+			names = append(names, prefix+m.Name, prefix+"currency")
+			values = append(values, money.Amount(), money.Currency())
+			continue
+		}
+		names = append(names, prefix+m.Name)
+		switch value := m.Value.(type) {
+		case nil:
+			values = append(values, "")
+		case float64:
+			values = append(values, strconv.FormatFloat(value, 'f', -1, 64))
+		default:
+			values = append(values, fmt.Sprint(value))
+		}
+	}
+	return names, values
+}
+
+// Decode reads a single employee: a header row naming some of id, name,
+// position, salary, currency, department_id and manager_id, in any order,
+// and one data row. A salary without a currency is left in none, for the
+// store to put in the employee's own, and an empty department_id or
+// manager_id is none, like 0.
+func (CSV) Decode(r io.Reader, v any) error {
+	e, ok := v.(*models.Employee)
+	if !ok {
+		return fmt.Errorf("%w %T", ErrUnsupportedValue, v)
+	}
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) != 2 {
+		return errors.New("csv: want a header row and one employee row")
+	}
+	salary, currency := "", ""
+	for i, column := range records[0] {
+		value := records[1][i]
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "id":
+			e.ID, err = strconv.Atoi(value)
+		case "name":
+			e.Name = value
+		case "position":
+			e.Position = value
+		case "salary":
+			salary = value
+		case "currency":
+			currency = value
+		case "department_id":
+			e.DepartmentID, err = csvID(value)
+		case "manager_id":
+			e.ManagerID, err = csvID(value)
+		default:
+			return fmt.Errorf("csv: unknown column %q", column)
+		}
+		if err != nil {
+			return fmt.Errorf("csv: column %q: %w", column, err)
+		}
+	}
+	if salary == "" && currency == "" {
+		return nil
+	}
+	e.Salary, err = models.ParseMoney(salary, currency)
+	if err != nil {
+		return fmt.Errorf("csv: column %q: %w", "salary", err)
+	}
+	return nil
+}
+
+// csvID reads an id column, where an empty value means none.
+func csvID(value string) (int, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(strings.TrimSpace(value))
+}
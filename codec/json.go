@@ -0,0 +1,21 @@
+package codec
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSON is the application/json codec.
+type JSON struct{}
+
+func (JSON) MediaType() string { return "application/json" }
+
+func (JSON) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (JSON) Decode(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
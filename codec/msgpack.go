@@ -0,0 +1,26 @@
+package codec
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePack is the application/msgpack codec. It reads the json struct
+// tags, so its keys match the JSON representation.
+type MessagePack struct{}
+
+func (MessagePack) MediaType() string { return "application/msgpack" }
+
+func (MessagePack) Encode(w io.Writer, v any) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(v)
+}
+
+func (MessagePack) Decode(r io.Reader, v any) error {
+	decoder := msgpack.NewDecoder(r)
+	decoder.SetCustomStructTag("json")
+	decoder.DisallowUnknownFields(true)
+	return decoder.Decode(v)
+}
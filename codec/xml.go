@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"ems/fieldset"
+	"ems/models"
+	"encoding/xml"
+	"io"
+)
+
+// XML is the application/xml codec. A single employee, whole or
+// projected, is written as an <employee> element; other values name their
+// own root with an XMLName field.
+type XML struct{}
+
+func (XML) MediaType() string { return "application/xml" }
+
+func (XML) Encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	var err error
+	switch v := v.(type) {
+	case models.Employee, *models.Employee, fieldset.Object:
+		err = encoder.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "employee"}})
+	default:
+		err = encoder.Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func (XML) Decode(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
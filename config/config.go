@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// Config holds the settings the server reads from the environment at startup.
+type Config struct {
+	// Addr is the address the HTTP server listens on.
+	Addr string
+	// StoreType selects the employee backend: "memory", "journal" or "sqlite".
+	StoreType string
+	// SQLitePath is the database file used when StoreType is "sqlite".
+	SQLitePath string
+	// JournalDir holds the log and snapshot when StoreType is "journal".
+	JournalDir string
+	// SnapshotEvery is how many journal records trigger a snapshot.
+	SnapshotEvery int
+	// CursorSecret signs list cursors. When empty a random key is used and
+	// cursors stop working after a restart.
+	CursorSecret string
+	// StrictIfMatch rejects PUT, PATCH and DELETE requests that have no
+	// If-Match header.
+	StrictIfMatch bool
+	// RatesFile is the CSV file exchange rates are loaded from and saved to.
+	// When empty rates are kept in memory only.
+	RatesFile string
+	// RatesAdmin registers POST /admin/rates, which lets any client rewrite
+	// the exchange rates. It is off unless explicitly enabled.
+	RatesAdmin bool
+}
+
+func Load() Config {
+	return Config{
+		Addr:          getEnv("EMS_ADDR", ":8080"),
+		StoreType:     getEnv("EMS_STORE", "memory"),
+		SQLitePath:    getEnv("EMS_SQLITE_PATH", "ems.db"),
+		JournalDir:    getEnv("EMS_JOURNAL_DIR", "data"),
+		SnapshotEvery: getEnvInt("EMS_SNAPSHOT_EVERY", 1000),
+		CursorSecret:  os.Getenv("EMS_CURSOR_SECRET"),
+		StrictIfMatch: getEnvBool("EMS_STRICT_IF_MATCH", false),
+		RatesFile:     os.Getenv("EMS_RATES_FILE"),
+		RatesAdmin:    getEnvBool("EMS_RATES_ADMIN", false),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
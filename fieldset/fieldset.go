@@ -0,0 +1,203 @@
+// Package fieldset implements sparse fieldsets: a fields query parameter
+// that picks which members of a resource a response carries, such as
+//
+//	fields=id,name
+//
+// Members are named as in JSON, and nested members are reached with dots,
+// as in manager.name.
+package fieldset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Schema is the members of a struct type that can be selected.
+type Schema struct {
+	members []schemaMember
+}
+
+type schemaMember struct {
+	name   string
+	index  []int
+	nested *Schema
+}
+
+// SchemaOf builds the schema of v's struct type from its json tags. Fields
+// tagged "-" cannot be selected, and the fields of untagged embedded
+// structs count as the outer type's own, as in encoding/json.
+func SchemaOf(v any) *Schema {
+	return schemaOf(reflect.TypeOf(v), make(map[reflect.Type]*Schema))
+}
+
+// schemaOf builds the schema of t. seen holds the schemas already begun, so
+// a type that refers to itself, such as an employee's manager, gets a
+// schema that refers to itself too.
+func schemaOf(t reflect.Type, seen map[reflect.Type]*Schema) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if s, ok := seen[t]; ok {
+		return s
+	}
+	s := &Schema{}
+	seen[t] = s
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		member := schemaMember{name: name, index: field.Index}
+		if inner := field.Type; nestable(inner) {
+			member.nested = schemaOf(inner, seen)
+		}
+		s.members = append(s.members, member)
+	}
+	return s
+}
+
+// nestable reports whether members of t can be selected: t is a struct, or
+// a pointer to one, that does not encode itself, as models.Money does.
+func nestable(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !t.Implements(marshalerType) && !reflect.PointerTo(t).Implements(marshalerType)
+}
+
+var marshalerType = reflect.TypeFor[json.Marshaler]()
+
+func (s *Schema) member(name string) (int, bool) {
+	for i, m := range s.members {
+		if m.name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Names lists the top-level members in declaration order.
+func (s *Schema) Names() []string {
+	names := make([]string, len(s.members))
+	for i, m := range s.members {
+		names[i] = m.name
+	}
+	return names
+}
+
+// Set is a parsed selection. A nil Set selects everything.
+type Set struct {
+	schema *Schema
+	// selected maps member positions in schema to the selection within
+	// them, which is nil when the whole member is wanted.
+	selected map[int]*Set
+}
+
+// Parse reads a comma-separated list of member paths. An empty param
+// returns a nil Set. Unknown members are an error.
+func Parse(param string, schema *Schema) (*Set, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+	root := &Set{schema: schema, selected: make(map[int]*Set)}
+	for _, path := range strings.Split(param, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return nil, errors.New("empty field name")
+		}
+		if err := root.add(path, strings.Split(path, ".")); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func (s *Set) add(path string, names []string) error {
+	i, ok := s.schema.member(names[0])
+	if !ok {
+		return fmt.Errorf("unknown field %q (want one of %s)", path, strings.Join(s.schema.Names(), ", "))
+	}
+	if len(names) == 1 {
+		s.selected[i] = nil
+		return nil
+	}
+	nested := s.schema.members[i].nested
+	if nested == nil {
+		return fmt.Errorf("unknown field %q (%s has no members)", path, names[0])
+	}
+	child, seen := s.selected[i]
+	if seen && child == nil {
+		// The whole member is already selected.
+		return nil
+	}
+	if child == nil {
+		child = &Set{schema: nested, selected: make(map[int]*Set)}
+		s.selected[i] = child
+	}
+	return child.add(path, names[1:])
+}
+
+// Apply returns the selected members of v, which must be of the schema's
+// type, in declaration order.
+func (s *Set) Apply(v any) Object {
+	return s.apply(reflect.ValueOf(v))
+}
+
+func (s *Set) apply(v reflect.Value) Object {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	object := make(Object, 0, len(s.selected))
+	for i, member := range s.schema.members {
+		child, ok := s.selected[i]
+		if !ok {
+			continue
+		}
+		field, err := v.FieldByIndexErr(member.index)
+		var value any
+		switch {
+		case err != nil:
+			// A nil embedded pointer on the way; leave the member empty.
+		case child != nil && field.Kind() == reflect.Pointer && field.IsNil():
+		case child != nil:
+			value = child.apply(field)
+		default:
+			value = field.Interface()
+		}
+		object = append(object, Member{Name: member.name, Value: value})
+	}
+	return object
+}
+
+// String lists the selected member paths in declaration order, in the form
+// Parse reads, so Sets selecting the same members print alike.
+func (s *Set) String() string {
+	if s == nil {
+		return ""
+	}
+	var paths []string
+	s.paths("", &paths)
+	return strings.Join(paths, ",")
+}
+
+func (s *Set) paths(prefix string, paths *[]string) {
+	for i, member := range s.schema.members {
+		child, ok := s.selected[i]
+		switch {
+		case !ok:
+		case child == nil:
+			*paths = append(*paths, prefix+member.name)
+		default:
+			child.paths(prefix+member.name+".", paths)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type office struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type person struct {
+	base
+	Name    string  `json:"name"`
+	Secret  string  `json:"-"`
+	Office  office  `json:"office"`
+	Manager *person `json:"manager,omitempty"`
+}
+
+func TestParse(t *testing.T) {
+	schema := SchemaOf(person{})
+	tests := []struct {
+		param   string
+		want    string
+		wantErr string
+	}{
+		{param: ""},
+		{param: "name, id", want: "id,name"},
+		{param: "manager.office.country,office.city", want: "office.city,manager.office.country"},
+		{param: "office.city,office", want: "office"},
+		{param: "secret", wantErr: `unknown field "secret" (want one of id, name, office, manager)`},
+		{param: "office.zip", wantErr: `unknown field "office.zip" (want one of city, country)`},
+		{param: "name.first", wantErr: `unknown field "name.first" (name has no members)`},
+		{param: "id,,name", wantErr: "empty field name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			set, err := Parse(tt.param, schema)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("Parse(%q) error = %q, want %q", tt.param, got, tt.wantErr)
+			}
+			if s := set.String(); err == nil && s != tt.want {
+				t.Errorf("Parse(%q).String() = %q, want %q", tt.param, s, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	boss := &person{base: base{ID: 1}, Name: "Alice", Office: office{City: "Oslo", Country: "NO"}}
+	p := person{base: base{ID: 2}, Name: "John", Secret: "x", Office: office{City: "Bergen", Country: "NO"}, Manager: boss}
+
+	tests := []struct {
+		param    string
+		value    person
+		wantJSON string
+		wantXML  string
+	}{
+		{
+			param:    "name,id",
+			value:    p,
+			wantJSON: `{"id":2,"name":"John"}`,
+			wantXML:  `<person><id>2</id><name>John</name></person>`,
+		},
+		{
+			param:    "office.city,manager.name",
+			value:    p,
+			wantJSON: `{"office":{"city":"Bergen"},"manager":{"name":"Alice"}}`,
+			wantXML:  `<person><office><city>Bergen</city></office><manager><name>Alice</name></manager></person>`,
+		},
+		{
+			param:    "office,office.city",
+			value:    p,
+			wantJSON: `{"office":{"city":"Bergen","country":"NO"}}`,
+		},
+		{
+			param:    "id,manager.name",
+			value:    *boss,
+			wantJSON: `{"id":1,"manager":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			set, err := Parse(tt.param, SchemaOf(person{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			object := set.Apply(tt.value)
+
+			gotJSON, err := json.Marshal(object)
+			if err != nil || string(gotJSON) != tt.wantJSON {
+				t.Errorf("JSON = %s (error %v), want %s", gotJSON, err, tt.wantJSON)
+			}
+			if tt.wantXML != "" {
+				var buf bytes.Buffer
+				err := xml.NewEncoder(&buf).EncodeElement(object, xml.StartElement{Name: xml.Name{Local: "person"}})
+				if gotXML := buf.String(); err != nil || gotXML != tt.wantXML {
+					t.Errorf("XML = %s (error %v), want %s", gotXML, err, tt.wantXML)
+				}
+			}
+
+			// Whole structs keep their own encoding, so use the json tags
+			// as the MessagePack codec does.
+			var packed bytes.Buffer
+			encoder := msgpack.NewEncoder(&packed)
+			encoder.SetCustomStructTag("json")
+			if err := encoder.Encode(object); err != nil {
+				t.Fatal(err)
+			}
+			var fromMsgpack, fromJSON map[string]any
+			msgpack.Unmarshal(packed.Bytes(), &fromMsgpack)
+			json.Unmarshal(gotJSON, &fromJSON)
+			if !reflect.DeepEqual(normalize(fromMsgpack), normalize(fromJSON)) {
+				t.Errorf("MessagePack = %v, want the same members as JSON %v", fromMsgpack, fromJSON)
+			}
+		})
+	}
+}
+
+// normalize turns every number into a float64 so MessagePack and JSON
+// decodings compare equal.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, inner := range v {
+			v[k] = normalize(inner)
+		}
+		return v
+	case int8:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return v
+}
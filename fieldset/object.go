@@ -0,0 +1,70 @@
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Member is one selected member of an Object.
+type Member struct {
+	Name  string
+	Value any
+}
+
+// Object is a projected resource. Unlike a map it keeps its members in
+// order, and it encodes as an object in JSON and MessagePack and as one
+// child element per member in XML.
+type Object []Member
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(m.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o Object) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, m := range o {
+		if err := e.EncodeElement(m.Value, xml.StartElement{Name: xml.Name{Local: m.Name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (o Object) EncodeMsgpack(e *msgpack.Encoder) error {
+	if err := e.EncodeMapLen(len(o)); err != nil {
+		return err
+	}
+	for _, m := range o {
+		if err := e.EncodeString(m.Name); err != nil {
+			return err
+		}
+		if err := e.Encode(m.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
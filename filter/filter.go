@@ -0,0 +1,271 @@
+// Package filter parses and evaluates filter expressions over employees, such
+// as
+//
+//	position eq "Developer" and salary gt 70000
+//	name sw "al" or (position in ("Manager", "Designer") and not salary lt 50000)
+//
+// Comparisons take the form `field op value`. Operators are eq, ne, gt, ge,
+// lt and le, plus sw (starts with) and co (contains) for text fields, and
+// in with a parenthesised list of values. sw and co ignore ASCII case.
+// Comparisons combine with and, or, not and parentheses; and binds tighter
+// than or. Keywords are case-insensitive, text values are double-quoted and
+// numbers are bare.
+//
+// Salaries compare by amount alone, whatever their currency, so
+// `salary gt 70000` would rank 70000 yen above 60000 dollars. The currency
+// field, the ISO 4217 code of the salary, narrows a filter to one currency,
+// as in
+//
+//	currency eq "EUR" and salary gt 70000
+//
+// The API rejects salary comparisons and sorting by salary while employees
+// are paid in more than one currency, unless the filter pins the currency
+// this way; Refers and Pinned tell it which filters do.
+package filter
+
+import (
+	"cmp"
+	"ems/models"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Op is a comparison operator.
+type Op string
+
+const (
+	OpEq         Op = "eq"
+	OpNe         Op = "ne"
+	OpGt         Op = "gt"
+	OpGe         Op = "ge"
+	OpLt         Op = "lt"
+	OpLe         Op = "le"
+	OpStartsWith Op = "sw"
+	OpContains   Op = "co"
+	OpIn         Op = "in"
+)
+
+// Kind is the type of a field or value.
+type Kind int
+
+const (
+	Text Kind = iota
+	Number
+)
+
+func (k Kind) String() string {
+	if k == Number {
+		return "number"
+	}
+	return "text"
+}
+
+// Fields lists the filterable employee fields by JSON name, plus currency
+// for the currency of the salary.
+var Fields = map[string]Kind{
+	"id":            Number,
+	"name":          Text,
+	"position":      Text,
+	"salary":        Number,
+	"currency":      Text,
+	"department_id": Number,
+	"manager_id":    Number,
+}
+
+// Expr is a node of a parsed filter.
+type Expr interface {
+	// Match reports whether e satisfies the expression.
+	Match(e models.Employee) bool
+}
+
+// And matches when both sides match.
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches when either side matches.
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches when Expr does not.
+type Not struct {
+	Expr Expr
+}
+
+// Comparison tests one field against one or more values. Values has exactly
+// one element for every operator except OpIn.
+type Comparison struct {
+	Field  string
+	Op     Op
+	Values []Value
+}
+
+// Value is a literal in a comparison. Text is set for Text values and Number
+// for Number values.
+type Value struct {
+	Kind   Kind
+	Text   string
+	Number float64
+}
+
+// Refers reports whether e compares field anywhere.
+func Refers(e Expr, field string) bool {
+	switch e := e.(type) {
+	case And:
+		return Refers(e.Left, field) || Refers(e.Right, field)
+	case Or:
+		return Refers(e.Left, field) || Refers(e.Right, field)
+	case Not:
+		return Refers(e.Expr, field)
+	case Comparison:
+		return e.Field == field
+	}
+	return false
+}
+
+// Pinned returns the value e holds field to: that of a `field eq value`
+// comparison among the terms joined by and at the top of e, which every
+// match must satisfy.
+func Pinned(e Expr, field string) (Value, bool) {
+	switch e := e.(type) {
+	case And:
+		if v, ok := Pinned(e.Left, field); ok {
+			return v, true
+		}
+		return Pinned(e.Right, field)
+	case Comparison:
+		if e.Field == field && e.Op == OpEq {
+			return e.Values[0], true
+		}
+	}
+	return Value{}, false
+}
+
+func (a And) Match(e models.Employee) bool { return a.Left.Match(e) && a.Right.Match(e) }
+func (o Or) Match(e models.Employee) bool  { return o.Left.Match(e) || o.Right.Match(e) }
+func (n Not) Match(e models.Employee) bool { return !n.Expr.Match(e) }
+
+// Units returns a Number value as an amount in models.Money units, rounded
+// to models.MoneyScale decimal places. Salaries are compared in units, so
+// every store agrees on them exactly. Amounts beyond the range of int64 are
+// clamped to it; salaries are capped far inside that range, so clamping
+// changes the outcome of no comparison.
+func (v Value) Units() int64 {
+	switch units := math.Round(v.Number * math.Pow10(models.MoneyScale)); {
+	case units >= math.MaxInt64:
+		return math.MaxInt64
+	case units <= math.MinInt64:
+		return math.MinInt64
+	default:
+		return int64(units)
+	}
+}
+
+func (c Comparison) Match(e models.Employee) bool {
+	switch {
+	case c.Field == "salary":
+		return matchOrdered(c.Op, e.Salary.Units(), c.Values, Value.Units)
+	case Fields[c.Field] == Number:
+		return matchOrdered(c.Op, numberField(e, c.Field), c.Values, func(v Value) float64 { return v.Number })
+	}
+	return c.matchText(textField(e, c.Field))
+}
+
+// matchOrdered compares got with values, converted by want, under op.
+func matchOrdered[T cmp.Ordered](op Op, got T, values []Value, want func(Value) T) bool {
+	switch op {
+	case OpEq:
+		return got == want(values[0])
+	case OpNe:
+		return got != want(values[0])
+	case OpGt:
+		return got > want(values[0])
+	case OpGe:
+		return got >= want(values[0])
+	case OpLt:
+		return got < want(values[0])
+	case OpLe:
+		return got <= want(values[0])
+	case OpIn:
+		for _, v := range values {
+			if got == want(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c Comparison) matchText(got string) bool {
+	want := c.Values[0].Text
+	switch c.Op {
+	case OpEq:
+		return got == want
+	case OpNe:
+		return got != want
+	case OpGt:
+		return got > want
+	case OpGe:
+		return got >= want
+	case OpLt:
+		return got < want
+	case OpLe:
+		return got <= want
+	case OpStartsWith:
+		return strings.HasPrefix(asciiLower(got), asciiLower(want))
+	case OpContains:
+		return strings.Contains(asciiLower(got), asciiLower(want))
+	case OpIn:
+		for _, v := range c.Values {
+			if got == v.Text {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numberField(e models.Employee, field string) float64 {
+	switch field {
+	case "id":
+		return float64(e.ID)
+	case "department_id":
+		return float64(e.DepartmentID)
+	}
+	return float64(e.ManagerID)
+}
+
+func textField(e models.Employee, field string) string {
+	switch field {
+	case "name":
+		return e.Name
+	case "currency":
+		return e.Salary.Currency()
+	}
+	return e.Position
+}
+
+// asciiLower folds only ASCII letters, matching SQLite's lower(), so every
+// store agrees on what sw and co match.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// Error describes why a filter could not be parsed. Pos is the byte offset
+// in the input where the problem was found.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
@@ -0,0 +1,130 @@
+package filter
+
+import (
+	"ems/models"
+	"errors"
+	"testing"
+)
+
+// usd is amount in US dollars.
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+func TestParseAndMatch(t *testing.T) {
+	employees := []models.Employee{
+		{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000")},
+		{ID: 2, Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 1},
+		{ID: 3, Name: "Bob Johnson", Position: "Designer", Salary: models.MustParseMoney("70000", "EUR"), DepartmentID: 2},
+		{ID: 4, Name: "Alan Turing", Position: "Developer", Salary: usd("90000"), ManagerID: 2},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []int
+	}{
+		{name: "Empty matches all", input: "", expected: []int{1, 2, 3, 4}},
+		{name: "Equality", input: `position eq "Developer"`, expected: []int{1, 4}},
+		{name: "Not equal", input: `position ne "Developer"`, expected: []int{2, 3}},
+		{name: "And", input: `position eq "Developer" and salary gt 70000`, expected: []int{4}},
+		{name: "Range", input: `salary ge 70000 and salary le 80000`, expected: []int{2, 3}},
+		{name: "Prefix ignores case", input: `name sw "al"`, expected: []int{2, 4}},
+		{name: "Contains", input: `name co "SON"`, expected: []int{3}},
+		{name: "In list", input: `position in ("Manager", "Designer")`, expected: []int{2, 3}},
+		{name: "Numeric in list", input: `id in (1, 3)`, expected: []int{1, 3}},
+		{name: "Or binds looser than and", input: `id eq 1 or id eq 2 and salary lt 0`, expected: []int{1}},
+		{name: "Parentheses", input: `(id eq 1 or id eq 2) and salary lt 70000`, expected: []int{1}},
+		{name: "Not", input: `not position eq "Developer"`, expected: []int{2, 3}},
+		{name: "Keywords ignore case", input: `Position EQ "Manager" OR Salary LT 65000`, expected: []int{1, 2}},
+		{name: "Department", input: `department_id eq 1`, expected: []int{2}},
+		{name: "Currency", input: `currency ne "USD"`, expected: []int{3}},
+		{name: "No department", input: `department_id eq 0`, expected: []int{1, 4}},
+		{name: "Manager", input: `manager_id eq 2`, expected: []int{4}},
+		{name: "Escaped quote", input: `name eq "Say \"hi\""`, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.input, err)
+			}
+			var got []int
+			for _, e := range employees {
+				if expr == nil || expr.Match(e) {
+					got = append(got, e.ID)
+				}
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Parse(%q) matched %v, want %v", tt.input, got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("Parse(%q) matched %v, want %v", tt.input, got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRefersAndPinned(t *testing.T) {
+	tests := []struct {
+		input  string
+		refers bool
+		pinned string
+	}{
+		{input: ``},
+		{input: `currency eq "EUR"`, pinned: "EUR"},
+		{input: `salary gt 1 and (id eq 1 and currency eq "EUR")`, refers: true, pinned: "EUR"},
+		{input: `salary gt 1 or currency eq "EUR"`, refers: true},
+		{input: `not currency eq "EUR" and not salary gt 1`, refers: true},
+		{input: `currency ne "EUR"`},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.input, err)
+		}
+		if refers := Refers(expr, "salary"); refers != tt.refers {
+			t.Errorf("Refers(%q, salary) = %v, want %v", tt.input, refers, tt.refers)
+		}
+		if v, ok := Pinned(expr, "currency"); v.Text != tt.pinned || ok != (tt.pinned != "") {
+			t.Errorf("Pinned(%q, currency) = %q, %v, want %q", tt.input, v.Text, ok, tt.pinned)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{input: `age eq 30`, pos: 0},
+		{input: `name eq`, pos: 7},
+		{input: `name eq 5`, pos: 8},
+		{input: `salary eq "high"`, pos: 10},
+		{input: `salary sw 5`, pos: 7},
+		{input: `name like "x"`, pos: 5},
+		{input: `name eq "x" and`, pos: 15},
+		{input: `(name eq "x"`, pos: 12},
+		{input: `name eq "x")`, pos: 11},
+		{input: `name eq "unterminated`, pos: 8},
+		{input: `position in ("a" "b")`, pos: 17},
+		{input: `salary gt 1.2.3`, pos: 10},
+		{input: `name eq "x" & salary gt 1`, pos: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.input, err)
+			}
+			if perr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error %q at position %d, want %d", tt.input, perr.Msg, perr.Pos, tt.pos)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Parse parses a filter expression. An empty or blank input yields a nil
+// Expr, which callers treat as "match everything".
+func Parse(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", describe(tok))}
+	}
+	return expr, nil
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+		case c == '"':
+			text, end, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: i})
+			i = end
+		case c == '-' || c == '.' || isDigit(c):
+			start := i
+			i++
+			for i < len(input) && (isDigit(input[i]) || input[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: input[start:i], pos: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(input) && (isIdentStart(input[i]) || isDigit(input[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: input[start:i], pos: start})
+		default:
+			return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(input)}), nil
+}
+
+// lexString reads a double-quoted string starting at input[start], allowing
+// \" and \\ escapes, and returns its value and the offset just past it.
+func lexString(input string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(input) && (input[i+1] == '"' || input[i+1] == '\\') {
+				i++
+				b.WriteByte(input[i])
+				continue
+			}
+			return "", 0, &Error{Pos: i, Msg: "invalid escape in string"}
+		default:
+			b.WriteByte(input[i])
+		}
+	}
+	return "", 0, &Error{Pos: start, Msg: "unterminated string"}
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the given case-insensitive
+// keyword, consuming it if so.
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokIdent && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.keyword("not") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("expected ')' but found %s", describe(closing))}
+		}
+		return expr, nil
+	case tokIdent:
+		return p.parseComparison(tok)
+	default:
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected a field name or '(' but found %s", describe(tok))}
+	}
+}
+
+func (p *parser) parseComparison(fieldTok token) (Expr, error) {
+	field := strings.ToLower(fieldTok.text)
+	kind, ok := Fields[field]
+	if !ok {
+		return nil, &Error{Pos: fieldTok.pos, Msg: fmt.Sprintf("unknown field %q", fieldTok.text)}
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokIdent {
+		return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("expected an operator but found %s", describe(opTok))}
+	}
+	op := Op(strings.ToLower(opTok.text))
+	switch op {
+	case OpEq, OpNe, OpGt, OpGe, OpLt, OpLe, OpIn:
+	case OpStartsWith, OpContains:
+		if kind != Text {
+			return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("operator %s needs a text field but %s is a %s", op, field, kind)}
+		}
+	default:
+		return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("unknown operator %q", opTok.text)}
+	}
+
+	if op != OpIn {
+		value, err := p.parseValue(field, kind)
+		if err != nil {
+			return nil, err
+		}
+		return Comparison{Field: field, Op: op, Values: []Value{value}}, nil
+	}
+
+	if open := p.next(); open.kind != tokLParen {
+		return nil, &Error{Pos: open.pos, Msg: fmt.Sprintf("expected '(' after in but found %s", describe(open))}
+	}
+	var values []Value
+	for {
+		value, err := p.parseValue(field, kind)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		sep := p.next()
+		if sep.kind == tokRParen {
+			break
+		}
+		if sep.kind != tokComma {
+			return nil, &Error{Pos: sep.pos, Msg: fmt.Sprintf("expected ',' or ')' but found %s", describe(sep))}
+		}
+	}
+	return Comparison{Field: field, Op: op, Values: values}, nil
+}
+
+func (p *parser) parseValue(field string, kind Kind) (Value, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokString && kind == Text:
+		return Value{Kind: Text, Text: tok.text}, nil
+	case tok.kind == tokNumber && kind == Number:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return Value{}, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid number %q", tok.text)}
+		}
+		return Value{Kind: Number, Number: n}, nil
+	case tok.kind == tokString || tok.kind == tokNumber:
+		return Value{}, &Error{Pos: tok.pos, Msg: fmt.Sprintf("%s is a %s field but found %s", field, kind, describe(tok))}
+	default:
+		return Value{}, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected a value but found %s", describe(tok))}
+	}
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokEOF:
+		return "end of input"
+	case tokString:
+		return fmt.Sprintf("string %q", tok.text)
+	case tokNumber:
+		return "number " + tok.text
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
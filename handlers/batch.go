@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/models"
+	"ems/store"
+	"ems/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxBatchOperations keeps a batch to one reasonably sized transaction.
+const maxBatchOperations = 1000
+
+const (
+	batchAtomic     = "atomic"
+	batchBestEffort = "best_effort"
+)
+
+type batchRequest struct {
+	// Mode is "atomic" (the default), where either every operation is
+	// applied or none is, or "best_effort", where each one stands alone.
+	Mode       string           `json:"mode"`
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchOperation struct {
+	Op       string          `json:"op"`
+	ID       int             `json:"id"`
+	IfMatch  string          `json:"if_match"`
+	Employee json.RawMessage `json:"employee"`
+}
+
+// batchItem is the outcome of one operation: the status code it would have
+// got as a request of its own, with the resulting employee or the problem.
+type batchItem struct {
+	Index    int              `json:"index"`
+	Status   int              `json:"status"`
+	Employee *models.Employee `json:"employee,omitempty"`
+	ETag     string           `json:"etag,omitempty"`
+	Error    *Problem         `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Mode      string      `json:"mode"`
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	Results   []batchItem `json:"results"`
+}
+
+// BatchEmployeesHandler serves POST /employees:batch, which creates,
+// updates and deletes employees in one request. A batch that is applied
+// gets 200 with a result per operation; an atomic batch that is rolled back
+// gets a 422 problem listing the same results.
+func (h *EmployeeHandler) BatchEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, badRequest("Invalid request payload"))
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = batchAtomic
+	}
+	if req.Mode != batchAtomic && req.Mode != batchBestEffort {
+		writeError(w, r, badRequest(`Batch mode must be "atomic" or "best_effort"`))
+		return
+	}
+	atomic := req.Mode == batchAtomic
+	if len(req.Operations) == 0 {
+		writeError(w, r, badRequest("Batch has no operations"))
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		writeError(w, r, badRequest(fmt.Sprintf("Batch has more than %d operations", maxBatchOperations)))
+		return
+	}
+
+	items := make([]batchItem, len(req.Operations))
+	ops := make([]store.BatchOp, 0, len(req.Operations))
+	indexes := make([]int, 0, len(req.Operations))
+	invalid := false
+	for i, operation := range req.Operations {
+		items[i].Index = i
+		op, err := h.batchOp(operation)
+		if err != nil {
+			items[i].setError(r, err)
+			invalid = true
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	// An atomic batch with a malformed operation never reaches the store.
+	if atomic && invalid {
+		for _, i := range indexes {
+			items[i].setError(r, store.ErrBatchAborted)
+		}
+	} else {
+		results, err := h.store.ApplyBatch(ops, atomic)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		for j, result := range results {
+			items[indexes[j]].setResult(r, ops[j], result)
+		}
+	}
+
+	succeeded, failed := 0, 0
+	for _, item := range items {
+		switch {
+		case item.Error == nil:
+			succeeded++
+		case item.Error.Type != problemBatchAborted.uri:
+			failed++
+		}
+	}
+	if atomic && failed > 0 {
+		p := problemFor(r, newProblem(problemBatchFailed,
+			fmt.Sprintf("%d of %d operations failed, so none were applied", failed, len(items))))
+		p.Operations = items
+		writeProblem(w, p)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batchResponse{
+		Mode:      req.Mode,
+		Succeeded: succeeded,
+		Failed:    failed,
+		Results:   items,
+	})
+}
+
+// batchOp checks one operation of the request and turns it into a store
+// operation.
+func (h *EmployeeHandler) batchOp(operation batchOperation) (store.BatchOp, error) {
+	op := store.BatchOp{Action: store.BatchAction(operation.Op), ID: operation.ID}
+	switch op.Action {
+	case store.BatchCreate:
+	case store.BatchUpdate, store.BatchDelete:
+		if op.ID < 1 {
+			return store.BatchOp{}, badRequest("Invalid employee ID")
+		}
+		if operation.IfMatch == "" {
+			if h.strictIfMatch {
+				return store.BatchOp{}, newProblem(problemPreconditionRequired, "if_match required")
+			}
+			break
+		}
+		version, ok := etagVersion(operation.IfMatch)
+		if !ok {
+			return store.BatchOp{}, badRequest("Invalid if_match: " + operation.IfMatch)
+		}
+		op.Version = version
+	default:
+		return store.BatchOp{}, badRequest(`Operation must be "create", "update" or "delete"`)
+	}
+
+	if op.Action == store.BatchDelete {
+		return op, nil
+	}
+	if len(operation.Employee) == 0 {
+		return store.BatchOp{}, badRequest("Operation has no employee")
+	}
+	employee, err := validation.DecodeEmployee(operation.Employee)
+	if err != nil {
+		return store.BatchOp{}, err
+	}
+	op.Name, op.Position, op.Salary = employee.Name, employee.Position, employee.Salary
+	op.DepartmentID, op.ManagerID = employee.DepartmentID, employee.ManagerID
+	return op, nil
+}
+
+func (item *batchItem) setResult(r *http.Request, op store.BatchOp, result store.BatchResult) {
+	var conflictErr *store.ConflictError
+	switch {
+	case result.Err != nil && op.Version != 0 && errors.As(result.Err, &conflictErr):
+		item.setError(r, newProblem(problemPreconditionFailed, "Employee has been modified"))
+	case result.Err != nil:
+		item.setError(r, result.Err)
+	case op.Action == store.BatchDelete:
+		item.Status = http.StatusNoContent
+	default:
+		item.Status = http.StatusOK
+		if op.Action == store.BatchCreate {
+			item.Status = http.StatusCreated
+		}
+		employee := result.Employee
+		item.Employee = &employee
+		item.ETag = etag(employee, codec.JSON{}, nil)
+	}
+}
+
+func (item *batchItem) setError(r *http.Request, err error) {
+	p := problemFor(r, err)
+	// The instance is the batch request, which the response already names.
+	p.Instance = ""
+	item.Status = p.Status
+	item.Error = &p
+}
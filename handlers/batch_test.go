@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"ems/store"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBatchEmployeesHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		strict           bool
+		payload          string
+		expectedCode     int
+		expectedStatuses []int
+		expectedCount    int
+		expectedBody     string
+	}{
+		{
+			name: "Atomic: everything succeeds",
+			payload: `{"operations":[
+				{"op":"create","employee":{"name":"Alice Smith","position":"Manager","salary":80000}},
+				{"op":"update","id":1,"if_match":"\"1\"","employee":{"name":"John Doe","position":"Lead","salary":90000}},
+				{"op":"delete","id":2}]}`,
+			expectedCode:     http.StatusOK,
+			expectedStatuses: []int{201, 200, 204},
+			expectedCount:    2,
+		},
+		{
+			name: "Atomic: one failure rolls back the rest",
+			payload: `{"mode":"atomic","operations":[
+				{"op":"create","employee":{"name":"Alice Smith","position":"Manager","salary":80000}},
+				{"op":"delete","id":9}]}`,
+			expectedCode:     http.StatusUnprocessableEntity,
+			expectedStatuses: []int{424, 404},
+			expectedCount:    2,
+			expectedBody:     "1 of 2 operations failed, so none were applied",
+		},
+		{
+			name: "Atomic: malformed operation",
+			payload: `{"operations":[
+				{"op":"delete","id":2},
+				{"op":"upsert","id":1}]}`,
+			expectedCode:     http.StatusUnprocessableEntity,
+			expectedStatuses: []int{424, 400},
+			expectedCount:    2,
+		},
+		{
+			name: "Best effort: per-item results",
+			payload: `{"mode":"best_effort","operations":[
+				{"op":"create","employee":{"name":"","position":"Manager","salary":80000}},
+				{"op":"update","id":1,"if_match":"\"4\"","employee":{"name":"John Doe","position":"Lead","salary":90000}},
+				{"op":"update","id":1,"employee":{"name":"John Doe","position":"Lead"}},
+				{"op":"delete","id":2,"if_match":"*"},
+				{"op":"create","employee":{"name":"Alice Smith","position":"Manager","salary":80000}}]}`,
+			expectedCode:     http.StatusOK,
+			expectedStatuses: []int{400, 412, 400, 204, 201},
+			expectedCount:    2,
+		},
+		{
+			name:             "Strict mode requires if_match",
+			strict:           true,
+			payload:          `{"mode":"best_effort","operations":[{"op":"delete","id":2},{"op":"delete","id":1,"if_match":"\"1\""}]}`,
+			expectedCode:     http.StatusOK,
+			expectedStatuses: []int{428, 204},
+			expectedCount:    1,
+		},
+		{
+			name:          "Unknown mode",
+			payload:       `{"mode":"sometimes","operations":[{"op":"delete","id":2}]}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 2,
+			expectedBody:  `Batch mode must be "atomic" or "best_effort"`,
+		},
+		{
+			name:          "No operations",
+			payload:       `{"operations":[]}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 2,
+			expectedBody:  "Batch has no operations",
+		},
+		{
+			name:          "Invalid JSON",
+			payload:       `{"operations":`,
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 2,
+			expectedBody:  "Invalid request payload",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+			h := NewEmployeeHandler(s, WithStrictIfMatch(tt.strict))
+
+			req, err := http.NewRequest("POST", "/employees:batch", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.BatchEmployeesHandler).ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tt.expectedCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, tt.expectedCode, recorder.Body.String())
+			}
+			if tt.expectedBody != "" {
+				if body := responseText(recorder); body != tt.expectedBody {
+					t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+				}
+			}
+			if tt.expectedStatuses != nil {
+				var response struct {
+					Results    []batchItem `json:"results"`
+					Operations []batchItem `json:"operations"`
+				}
+				if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				var statuses []int
+				for _, item := range append(response.Results, response.Operations...) {
+					statuses = append(statuses, item.Status)
+				}
+				if !reflect.DeepEqual(statuses, tt.expectedStatuses) {
+					t.Errorf("handler returned wrong item statuses: got %v want %v", statuses, tt.expectedStatuses)
+				}
+			}
+			if count, _ := s.CountEmployees(store.ListOptions{}); count != tt.expectedCount {
+				t.Errorf("employees after the batch: got %d want %d", count, tt.expectedCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/models"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// decodeCompensation reads the salary entry in the request body. Any id or
+// employee_id in the body is ignored; the store checks the rest.
+func decodeCompensation(r *http.Request) (models.Compensation, error) {
+	var c models.Compensation
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return models.Compensation{}, badRequest("Invalid request payload")
+	}
+	c.EffectiveDate = strings.TrimSpace(c.EffectiveDate)
+	c.Reason = strings.TrimSpace(c.Reason)
+	return c, nil
+}
+
+// CompensationHistoryHandler serves GET /employees/{id}/compensation, the
+// employee's salary history by effective date, future-dated entries
+// included.
+func (h *EmployeeHandler) CompensationHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := employeeID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	history, err := h.store.CompensationHistory(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, history)
+}
+
+// AddCompensationHandler serves POST /employees/{id}/compensation, which
+// records a salary change. A salary without a currency is in the one the
+// employee is paid in, and effective_date defaults to today; a later date
+// schedules the change.
+func (h *EmployeeHandler) AddCompensationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := employeeID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	c, err := decodeCompensation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	created, err := h.store.AddCompensation(id, c)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusCreated, created)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/store"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompensationHistoryHandler(t *testing.T) {
+	today := time.Now().UTC().Format(models.DateLayout)
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.UpdateEmployee(1, "John Doe", "Developer", usd("65000"))
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name         string
+		id           string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Valid employee",
+			id:           "1",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":1,"employee_id":1,"salary":{"amount":"60000","currency":"USD"},"effective_date":"` + today + `","reason":"hired"},` +
+				`{"id":2,"employee_id":1,"salary":{"amount":"65000","currency":"USD"},"effective_date":"` + today + `","reason":"salary updated"}]`,
+		},
+		{
+			name:         "Non-existent employee",
+			id:           "9",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "employee 9 not found",
+		},
+		{
+			name:         "Invalid ID",
+			id:           "abc",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid employee ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/employees/"+tt.id+"/compensation", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.CompensationHistoryHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestAddCompensationHandler(t *testing.T) {
+	today := time.Now().UTC().Format(models.DateLayout)
+	hired := models.Compensation{ID: 1, EmployeeID: 1, Salary: usd("60000"), EffectiveDate: today, Reason: "hired"}
+
+	tests := []struct {
+		name         string
+		target       string
+		payload      string
+		expectedCode int
+		expectedBody string
+		// history and salary are employee 1's entries and salary after the
+		// request.
+		history []models.Compensation
+		salary  models.Money
+	}{
+		{
+			name:         "Scheduled raise",
+			target:       "/employees/1/compensation",
+			payload:      `{"salary":{"amount":"70000.10","currency":" eur "},"effective_date":"2999-01-01","reason":"Annual raise"}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":2,"employee_id":1,"salary":{"amount":"70000.1","currency":"EUR"},"effective_date":"2999-01-01","reason":"Annual raise"}`,
+			history:      []models.Compensation{hired, {ID: 2, EmployeeID: 1, Salary: models.MustParseMoney("70000.1", "EUR"), EffectiveDate: "2999-01-01", Reason: "Annual raise"}},
+			salary:       usd("60000"),
+		},
+		{
+			name:         "Raise effective today",
+			target:       "/employees/1/compensation",
+			payload:      `{"salary":{"amount":"70000","currency":"USD"}}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":2,"employee_id":1,"salary":{"amount":"70000","currency":"USD"},"effective_date":"` + today + `"}`,
+			history:      []models.Compensation{hired, {ID: 2, EmployeeID: 1, Salary: usd("70000"), EffectiveDate: today}},
+			salary:       usd("70000"),
+		},
+		{
+			name:         "Invalid entry",
+			target:       "/employees/1/compensation",
+			payload:      `{"salary":{"amount":"0","currency":"XYZ"},"effective_date":"tomorrow"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "salary must be greater than 0; salary.currency must be an ISO 4217 currency code such as USD; effective_date must be a date such as 2024-01-31",
+			history:      []models.Compensation{hired},
+			salary:       usd("60000"),
+		},
+		{
+			name:         "Unknown field",
+			target:       "/employees/1/compensation",
+			payload:      `{"salary":70000,"bonus":5000}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request payload",
+			history:      []models.Compensation{hired},
+			salary:       usd("60000"),
+		},
+		{
+			name:         "Non-existent employee",
+			target:       "/employees/9/compensation",
+			payload:      `{"salary":70000}`,
+			expectedCode: http.StatusNotFound,
+			expectedBody: "employee 9 not found",
+			history:      []models.Compensation{hired},
+			salary:       usd("60000"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("POST", tt.target, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.AddCompensationHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+			if history, _ := s.CompensationHistory(1); !reflect.DeepEqual(history, tt.history) {
+				t.Errorf("history after the request = %+v, want %+v", history, tt.history)
+			}
+			if employee, _ := s.GetEmployeeByID(1); employee.Salary != tt.salary {
+				t.Errorf("salary after the request = %v, want %v", employee.Salary, tt.salary)
+			}
+		})
+	}
+}
@@ -1,25 +1,24 @@
 package handlers
 
-import (
-	"ems/models"
-	"ems/store"
-	"encoding/json"
-	"net/http"
-)
+import "net/http"
 
-func CreateEmployeeHandler(w http.ResponseWriter, r *http.Request) {
-	var employee models.Employee
-	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+func (h *EmployeeHandler) CreateEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+	employee, err := h.decodeEmployee(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if employee.Name == "" || employee.Position == "" || employee.Salary <= 0 {
-		http.Error(w, "Invalid employee data", http.StatusBadRequest)
+	createdEmployee, err := h.store.InsertEmployee(employee)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	createdEmployee := store.CreateEmployee(employee.Name, employee.Position, employee.Salary)
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdEmployee)
+	w.Header().Set("ETag", etag(createdEmployee, c, nil))
+	writeEncoded(w, r, c, http.StatusCreated, createdEmployee)
 }
@@ -5,44 +5,48 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"ems/models"
+	"ems/store"
 )
 
 func TestCreateEmployeeHandler(t *testing.T) {
+	h := NewEmployeeHandler(store.NewMemoryStore())
+
 	tests := []struct {
 		name         string
 		position     string
-		salary       float64
+		salary       models.Money
 		expectedCode int
 	}{
 		// Valid employee data
 		{
 			name:         "John Doe",
 			position:     "Developer",
-			salary:       60000.0,
+			salary:       usd("60000"),
 			expectedCode: http.StatusCreated,
 		},
 		// Invalid data: empty name
 		{
 			name:         "",
 			position:     "Manager",
-			salary:       80000.0,
+			salary:       usd("80000"),
 			expectedCode: http.StatusBadRequest,
 		},
 		// Invalid data: empty position
 		{
 			name:         "Alice Smith",
 			position:     "",
-			salary:       90000.0,
+			salary:       usd("90000"),
 			expectedCode: http.StatusBadRequest,
 		},
 		// Invalid data: negative salary
 		{
 			name:         "Bob Johnson",
 			position:     "Designer",
-			salary:       -50000.0,
+			salary:       usd("-50000"),
 			expectedCode: http.StatusBadRequest,
 		},
 	}
@@ -61,7 +65,7 @@ func TestCreateEmployeeHandler(t *testing.T) {
 				t.Fatal(err)
 			}
 			recorder := httptest.NewRecorder()
-			handler := http.HandlerFunc(CreateEmployeeHandler)
+			handler := http.HandlerFunc(h.CreateEmployeeHandler)
 
 			handler.ServeHTTP(recorder, req)
 
@@ -92,7 +96,7 @@ func TestCreateEmployeeHandler(t *testing.T) {
 			t.Fatal(err)
 		}
 		recorder := httptest.NewRecorder()
-		handler := http.HandlerFunc(CreateEmployeeHandler)
+		handler := http.HandlerFunc(h.CreateEmployeeHandler)
 
 		handler.ServeHTTP(recorder, req)
 
@@ -102,3 +106,37 @@ func TestCreateEmployeeHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestCreateEmployeeHandlerReportsEveryViolation(t *testing.T) {
+	h := NewEmployeeHandler(store.NewMemoryStore())
+
+	payload := []byte(`{"name":"","position":"Developer!","salary":-1,"nickname":"JD"}`)
+	req, err := http.NewRequest("POST", "/employees", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.CreateEmployeeHandler).ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			recorder.Code, http.StatusBadRequest)
+	}
+	var body struct {
+		Errors []struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error unmarshalling response body: %v", err)
+	}
+	var got []string
+	for _, e := range body.Errors {
+		got = append(got, e.Field+":"+e.Code)
+	}
+	want := []string{"nickname:unknown_field", "name:required", "position:invalid_characters", "salary:too_small"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("handler reported %v, want %v", got, want)
+	}
+}
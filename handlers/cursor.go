@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"ems/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the decoded form of the opaque continuation token handed to
+// clients. It records the sort the listing was made with and the sort-key
+// values of the employee at the edge of the page, so the next request can
+// resume by key rather than by offset.
+type cursor struct {
+	Sort     string          `json:"s,omitempty"`
+	Before   bool            `json:"b,omitempty"`
+	Boundary models.Employee `json:"k"`
+}
+
+// cursorCodec signs cursors with HMAC-SHA256 so clients cannot forge or
+// tamper with them.
+type cursorCodec struct {
+	secret []byte
+}
+
+func newCursorCodec(secret []byte) cursorCodec {
+	if len(secret) == 0 {
+		// Without a configured secret, cursors only stay valid for the
+		// lifetime of the process.
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	return cursorCodec{secret: secret}
+}
+
+func (c cursorCodec) encode(cur cursor) string {
+	payload, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+func (c cursorCodec) decode(token string) (cursor, error) {
+	encodedPayload, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return cursor{}, errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+		return cursor{}, errInvalidCursor
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	return cur, nil
+}
+
+func (c cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
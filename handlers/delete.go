@@ -1,22 +1,27 @@
 package handlers
 
 import (
-	"ems/store"
+	"ems/models"
 	"net/http"
 	"strconv"
 )
 
-func DeleteEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *EmployeeHandler) DeleteEmployeeHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Path[len("/employees/"):]
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id < 1 {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, badRequest("Invalid employee ID"))
+		return
+	}
+	if !h.requireIfMatch(w, r) {
 		return
 	}
 
-	err = store.DeleteEmployee(id)
+	err = h.store.DeleteEmployeeIf(id, func(current models.Employee) error {
+		return checkIfMatch(r, current)
+	})
 	if err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
@@ -11,7 +11,9 @@ import (
 
 func TestDeleteEmployeeHandler(t *testing.T) {
 	// Initialize some employees for testing
-	store.CreateEmployee("Eve Williams", "Tester", 65000.0)
+	s := store.NewMemoryStore()
+	s.CreateEmployee("Eve Williams", "Tester", usd("65000"))
+	h := NewEmployeeHandler(s)
 	tests := []struct {
 		name         string
 		id           int
@@ -21,7 +23,7 @@ func TestDeleteEmployeeHandler(t *testing.T) {
 		// Valid case: Delete existing employee
 		{
 			name:         "Valid case: Delete existing employee",
-			id:           2,
+			id:           1,
 			expectedCode: http.StatusNoContent,
 			expectedBody: "Employee Deleted Successfully",
 		},
@@ -30,7 +32,7 @@ func TestDeleteEmployeeHandler(t *testing.T) {
 			name:         "Invalid case: Delete non-existing employee",
 			id:           90,
 			expectedCode: http.StatusNotFound,
-			expectedBody: "Employee not found",
+			expectedBody: "employee 90 not found",
 		},
 		// Invalid case: Invalid employee ID
 		{
@@ -48,7 +50,7 @@ func TestDeleteEmployeeHandler(t *testing.T) {
 				t.Fatal(err)
 			}
 			recorder := httptest.NewRecorder()
-			handler := http.HandlerFunc(DeleteEmployeeHandler)
+			handler := http.HandlerFunc(h.DeleteEmployeeHandler)
 
 			handler.ServeHTTP(recorder, req)
 
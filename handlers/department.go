@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/filter"
+	"ems/models"
+	"ems/validation"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// departmentID reads the id from a /departments/{id} path, or from the
+// start of a longer one such as /departments/{id}/employees.
+func departmentID(r *http.Request) (int, error) {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/departments/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 1 {
+		return 0, badRequest("Invalid department ID")
+	}
+	return id, nil
+}
+
+// decodeDepartment reads and validates the department in the request body.
+// An id in the body is ignored; the path or the store decides it.
+func decodeDepartment(r *http.Request) (models.Department, error) {
+	var department models.Department
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&department); err != nil {
+		return models.Department{}, badRequest("Invalid request payload")
+	}
+	department.Name = strings.TrimSpace(department.Name)
+	return department, validation.Department(department)
+}
+
+// CreateDepartmentHandler serves POST /departments.
+func (h *EmployeeHandler) CreateDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	department, err := decodeDepartment(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	created, err := h.store.CreateDepartment(department.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusCreated, created)
+}
+
+// ListDepartmentsHandler serves GET /departments, every department in id
+// order.
+func (h *EmployeeHandler) ListDepartmentsHandler(w http.ResponseWriter, r *http.Request) {
+	departments, err := h.store.ListDepartments()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if departments == nil {
+		departments = []models.Department{}
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, departments)
+}
+
+// GetDepartmentHandler serves GET /departments/{id}.
+func (h *EmployeeHandler) GetDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := departmentID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	department, err := h.store.GetDepartmentByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, department)
+}
+
+// UpdateDepartmentHandler serves PUT /departments/{id}, which renames the
+// department.
+func (h *EmployeeHandler) UpdateDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := departmentID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	department, err := decodeDepartment(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	updated, err := h.store.UpdateDepartment(id, department.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, updated)
+}
+
+// DeleteDepartmentHandler serves DELETE /departments/{id}. A department
+// that still has employees is a conflict; move or delete them first.
+func (h *EmployeeHandler) DeleteDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := departmentID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.store.DeleteDepartment(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DepartmentEmployeesHandler serves GET /departments/{id}/employees, which
+// lists the department's employees with the same parameters and response
+// as GET /employees.
+func (h *EmployeeHandler) DepartmentEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := departmentID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if _, err := h.store.GetDepartmentByID(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.listEmployees(w, r, filter.Comparison{
+		Field:  "department_id",
+		Op:     filter.OpEq,
+		Values: []filter.Value{{Kind: filter.Number, Number: float64(id)}},
+	})
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/store"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCreateDepartmentHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		expectedCode int
+		expectedType string
+		expectedBody string
+		expected     []models.Department
+	}{
+		{
+			name:         "Valid department",
+			payload:      `{"id":9,"name":" Compliance "}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":2,"name":"Compliance"}`,
+			expected:     []models.Department{{ID: 1, Name: "Sales"}, {ID: 2, Name: "Compliance"}},
+		},
+		{
+			name:         "Taken name",
+			payload:      `{"name":"Sales"}`,
+			expectedCode: http.StatusConflict,
+			expectedType: "/problems/conflict",
+			expectedBody: `a department named "Sales" already exists`,
+			expected:     []models.Department{{ID: 1, Name: "Sales"}},
+		},
+		{
+			name:         "No name",
+			payload:      `{"name":" "}`,
+			expectedCode: http.StatusBadRequest,
+			expectedType: "/problems/validation-error",
+			expectedBody: "name is required",
+			expected:     []models.Department{{ID: 1, Name: "Sales"}},
+		},
+		{
+			name:         "Unknown field",
+			payload:      `{"name":"Compliance","budget":1}`,
+			expectedCode: http.StatusBadRequest,
+			expectedType: "/problems/invalid-request",
+			expectedBody: "Invalid request payload",
+			expected:     []models.Department{{ID: 1, Name: "Sales"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateDepartment("Sales")
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("POST", "/departments", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.CreateDepartmentHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+			if tt.expectedType != "" {
+				var p Problem
+				if err := json.Unmarshal(recorder.Body.Bytes(), &p); err != nil || p.Type != tt.expectedType {
+					t.Errorf("handler returned problem type %q (%v), want %q", p.Type, err, tt.expectedType)
+				}
+			}
+			if departments, _ := s.ListDepartments(); !reflect.DeepEqual(departments, tt.expected) {
+				t.Errorf("departments after the request = %+v, want %+v", departments, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetDepartmentHandler(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Sales")
+	s.CreateDepartment("Engineering")
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		target       string
+		expectedCode int
+		expectedBody string
+	}{
+		{"List", h.ListDepartmentsHandler, "/departments", http.StatusOK, `[{"id":1,"name":"Sales"},{"id":2,"name":"Engineering"}]`},
+		{"Valid department", h.GetDepartmentHandler, "/departments/2", http.StatusOK, `{"id":2,"name":"Engineering"}`},
+		{"Non-existent department", h.GetDepartmentHandler, "/departments/9", http.StatusNotFound, "department 9 not found"},
+		{"Invalid ID", h.GetDepartmentHandler, "/departments/abc", http.StatusBadRequest, "Invalid department ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			tt.handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestUpdateDepartmentHandler(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Sales")
+	s.CreateDepartment("Engineering")
+	h := NewEmployeeHandler(s)
+
+	rename := func(id, payload string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("PUT", "/departments/"+id, strings.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		http.HandlerFunc(h.UpdateDepartmentHandler).ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := rename("2", `{"name":"Research"}`)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, http.StatusOK)
+	}
+	if body, want := responseText(recorder), `{"id":2,"name":"Research"}`; body != want {
+		t.Errorf("handler returned unexpected body: got %v want %v", body, want)
+	}
+	if stored, err := s.GetDepartmentByID(2); err != nil || stored.Name != "Research" {
+		t.Errorf("GetDepartmentByID(2) = %+v, %v, want it renamed to Research", stored, err)
+	}
+
+	// Names stay unique.
+	recorder = rename("1", `{"name":"Research"}`)
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, http.StatusConflict)
+	}
+	if stored, _ := s.GetDepartmentByID(1); stored.Name != "Sales" {
+		t.Errorf("department 1 after a conflicting rename = %+v, want it still named Sales", stored)
+	}
+}
+
+func TestDeleteDepartmentHandler(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Sales")
+	s.CreateDepartment("Legal")
+	s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 1})
+	s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1})
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name         string
+		id           int
+		expectedCode int
+		expectedBody string
+		exists       bool
+	}{
+		{"Department with employees", 1, http.StatusConflict, "department 1 still has 2 employees", true},
+		{"Empty department", 2, http.StatusNoContent, "", false},
+		{"Non-existent department", 9, http.StatusNotFound, "department 9 not found", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", "/departments/"+strconv.Itoa(tt.id), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.DeleteDepartmentHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %q want %q", body, tt.expectedBody)
+			}
+			_, err = s.GetDepartmentByID(tt.id)
+			var notFound *store.NotFoundError
+			if exists := !errors.As(err, &notFound); exists != tt.exists {
+				t.Errorf("GetDepartmentByID(%d) after the delete error = %v, want the department kept: %v", tt.id, err, tt.exists)
+			}
+		})
+	}
+}
+
+func TestDepartmentEmployeesHandler(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Sales")
+	s.CreateDepartment("Engineering")
+	s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 1})
+	s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1})
+	s.InsertEmployee(models.Employee{Name: "Jane Roe", Position: "Designer", Salary: usd("90000"), DepartmentID: 2})
+	h := NewEmployeeHandler(s)
+
+	req, err := http.NewRequest("GET", "/departments/1/employees?size=10&fields=name&filter=salary+gt+70000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.DepartmentEmployeesHandler).ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+	}
+	var page models.Page[map[string]any]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	// Jane Roe earns more but is in another department.
+	if want := []map[string]any{{"name": "Alice Smith"}}; !reflect.DeepEqual(page.Items, want) || page.Total != 1 {
+		t.Errorf("handler returned page %+v, want only %v", page, want)
+	}
+	if want := "/departments/1/employees?fields=name&filter=salary+gt+70000&size=10"; page.Links.First != want {
+		t.Errorf("handler returned first link %q, want %q", page.Links.First, want)
+	}
+
+	req, err = http.NewRequest("GET", "/departments/9/employees?size=10", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(h.DepartmentEmployeesHandler).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusNotFound || responseText(recorder) != "department 9 not found" {
+		t.Errorf("handler returned %v %q for a missing department, want 404", recorder.Code, responseText(recorder))
+	}
+}
+
+func TestEmployeeDepartment(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      func(h *EmployeeHandler) http.HandlerFunc
+		method       string
+		target       string
+		payload      string
+		expectedCode int
+		expectedBody string
+		expected     []models.Employee
+	}{
+		{
+			name:         "Move to another department",
+			handler:      func(h *EmployeeHandler) http.HandlerFunc { return h.UpdateEmployeeHandler },
+			method:       "PUT",
+			target:       "/employees/1",
+			payload:      `{"name":"John Doe","position":"Developer","salary":60000,"department_id":2}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":1,"name":"John Doe","position":"Developer","salary":{"amount":"60000","currency":"USD"},"department_id":2}`,
+			expected:     []models.Employee{{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 2, Version: 2}},
+		},
+		{
+			name:         "Move to a missing department",
+			handler:      func(h *EmployeeHandler) http.HandlerFunc { return h.UpdateEmployeeHandler },
+			method:       "PUT",
+			target:       "/employees/1",
+			payload:      `{"name":"John Doe","position":"Developer","salary":60000,"department_id":9}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "department 9 does not exist",
+			expected:     []models.Employee{{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1, Version: 1}},
+		},
+		{
+			name:         "Create in a department",
+			handler:      func(h *EmployeeHandler) http.HandlerFunc { return h.CreateEmployeeHandler },
+			method:       "POST",
+			target:       "/employees",
+			payload:      `{"name":"Bob Johnson","position":"Designer","salary":70000,"department_id":2}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":2,"name":"Bob Johnson","position":"Designer","salary":{"amount":"70000","currency":"USD"},"department_id":2}`,
+			expected: []models.Employee{
+				{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1, Version: 1},
+				{ID: 2, Name: "Bob Johnson", Position: "Designer", Salary: usd("70000"), DepartmentID: 2, Version: 1},
+			},
+		},
+		{
+			name:         "Create in a missing department",
+			handler:      func(h *EmployeeHandler) http.HandlerFunc { return h.CreateEmployeeHandler },
+			method:       "POST",
+			target:       "/employees",
+			payload:      `{"name":"Bob Johnson","position":"Designer","salary":70000,"department_id":9}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "department 9 does not exist",
+			expected:     []models.Employee{{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1, Version: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateDepartment("Sales")
+			s.CreateDepartment("Engineering")
+			s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1})
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest(tt.method, tt.target, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			tt.handler(h).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+			if employees, _ := s.ListEmployees(store.ListOptions{Page: 1, PerPage: 10}); !reflect.DeepEqual(employees, tt.expected) {
+				t.Errorf("employees after the request = %+v, want %+v", employees, tt.expected)
+			}
+		})
+	}
+}
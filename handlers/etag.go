@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/fieldset"
+	"ems/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag is the strong entity tag of the representation of e's current
+// version that c encodes, narrowed to fields if there are any. No two
+// representations share a tag, since they differ byte for byte: the full
+// JSON one is the bare version, as before responses were negotiated, and
+// the others add their media type and fields, such as
+// "3;application/xml;fields=id,name".
+func etag(e models.Employee, c codec.Codec, fields *fieldset.Set) string {
+	tag := strconv.Itoa(e.Version)
+	if c.MediaType() != (codec.JSON{}).MediaType() || fields != nil {
+		tag += ";" + c.MediaType()
+	}
+	if fields != nil {
+		tag += ";fields=" + fields.String()
+	}
+	return strconv.Quote(tag)
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value
+// lists tag or is "*". If-Match uses strong comparison, so weak tags never
+// match; If-None-Match uses weak comparison, which ignores the W/ prefix.
+func etagMatches(header, tag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range etagList(header) {
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[len("W/"):]
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagList splits an If-Match or If-None-Match header value into its
+// entity tags. A tag from etag can hold commas, so the value is only split
+// between quoted strings.
+func etagList(header string) []string {
+	var tags []string
+	for {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			return tags
+		}
+		end := strings.IndexByte(header, ',')
+		if quote := strings.IndexByte(header, '"'); quote >= 0 && (end < 0 || quote < end) {
+			if closing := strings.IndexByte(header[quote+1:], '"'); closing >= 0 {
+				end = quote + 1 + closing + 1
+			}
+		}
+		if end < 0 {
+			end = len(header)
+		}
+		tags = append(tags, strings.TrimSpace(header[:end]))
+		header = header[end:]
+	}
+}
+
+// requireIfMatch rejects a write without If-Match in strict mode. It reports
+// false after writing the 428 response.
+func (h *EmployeeHandler) requireIfMatch(w http.ResponseWriter, r *http.Request) bool {
+	if h.strictIfMatch && r.Header.Get("If-Match") == "" {
+		writeError(w, r, newProblem(problemPreconditionRequired, "If-Match header required"))
+		return false
+	}
+	return true
+}
+
+// checkIfMatch compares the request's If-Match header, if any, with the
+// current employee. A tag of any representation of the current version
+// matches, but weak tags never do. It runs inside the store's write so the
+// version cannot change between the check and the write.
+func checkIfMatch(r *http.Request, current models.Employee) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	for _, candidate := range etagList(header) {
+		version, ok := etagVersion(candidate)
+		if ok && (version == 0 || version == current.Version) {
+			return nil
+		}
+	}
+	return newProblem(problemPreconditionFailed, "Employee has been modified")
+}
+
+// etagVersion is the version named by a tag from etag, or 0 for "*", which
+// matches any version. It reports false for anything else, weak tags
+// included.
+func etagVersion(tag string) (int, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "*" {
+		return 0, true
+	}
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	tag, _, _ = strings.Cut(tag[1:len(tag)-1], ";")
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"ems/patch"
+	"ems/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetEmployeeHandlerETag(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.UpdateEmployee(1, "John Doe", "Senior Developer", usd("70000"))
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name         string
+		ifNoneMatch  string
+		expectedCode int
+	}{
+		{name: "No condition", expectedCode: http.StatusOK},
+		{name: "Current version", ifNoneMatch: `"2"`, expectedCode: http.StatusNotModified},
+		{name: "Weak current version", ifNoneMatch: `W/"2"`, expectedCode: http.StatusNotModified},
+		{name: "One of several", ifNoneMatch: `"1", "2"`, expectedCode: http.StatusNotModified},
+		{name: "Wildcard", ifNoneMatch: `*`, expectedCode: http.StatusNotModified},
+		{name: "Old version", ifNoneMatch: `"1"`, expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/employees/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.GetEmployeeHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedCode)
+			}
+			if got := recorder.Header().Get("ETag"); got != `"2"` {
+				t.Errorf("handler returned ETag %s, want \"2\"", got)
+			}
+			if recorder.Code == http.StatusNotModified && recorder.Body.Len() != 0 {
+				t.Errorf("304 response has a body: %q", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestETagPerRepresentation(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name         string
+		target       string
+		accept       string
+		ifNoneMatch  string
+		expectedCode int
+		expectedETag string
+	}{
+		{name: "JSON", target: "/employees/1", expectedCode: http.StatusOK, expectedETag: `"1"`},
+		{name: "XML", target: "/employees/1", accept: "application/xml", expectedCode: http.StatusOK, expectedETag: `"1;application/xml"`},
+		{name: "CSV", target: "/employees/1", accept: "text/csv", expectedCode: http.StatusOK, expectedETag: `"1;text/csv"`},
+		{name: "JSON fieldset", target: "/employees/1?fields=name,id", expectedCode: http.StatusOK, expectedETag: `"1;application/json;fields=id,name"`},
+		{name: "MessagePack fieldset", target: "/employees/1?fields=salary", accept: "application/msgpack", expectedCode: http.StatusOK, expectedETag: `"1;application/msgpack;fields=salary"`},
+		{name: "XML against the JSON tag", target: "/employees/1", accept: "application/xml", ifNoneMatch: `"1"`, expectedCode: http.StatusOK, expectedETag: `"1;application/xml"`},
+		{name: "XML against its own tag", target: "/employees/1", accept: "application/xml", ifNoneMatch: `W/"1;application/xml"`, expectedCode: http.StatusNotModified, expectedETag: `"1;application/xml"`},
+		{name: "Fieldset against its own tag among others", target: "/employees/1?fields=name,id", ifNoneMatch: `"0", "1;application/json;fields=id,name", "2"`, expectedCode: http.StatusNotModified, expectedETag: `"1;application/json;fields=id,name"`},
+		{name: "JSON against a fieldset tag", target: "/employees/1", ifNoneMatch: `"1;application/json;fields=id,name"`, expectedCode: http.StatusOK, expectedETag: `"1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.GetEmployeeHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedCode)
+			}
+			if got := recorder.Header().Get("ETag"); got != tt.expectedETag {
+				t.Errorf("handler returned ETag %s, want %s", got, tt.expectedETag)
+			}
+		})
+	}
+}
+
+func TestConditionalWrites(t *testing.T) {
+	tests := []struct {
+		name         string
+		strict       bool
+		method       string
+		ifMatch      string
+		expectedCode int
+	}{
+		{name: "PUT without If-Match", method: "PUT", expectedCode: http.StatusOK},
+		{name: "PUT with current ETag", method: "PUT", ifMatch: `"1"`, expectedCode: http.StatusOK},
+		{name: "PUT with wildcard", method: "PUT", ifMatch: `*`, expectedCode: http.StatusOK},
+		{name: "PUT with stale ETag", method: "PUT", ifMatch: `"0"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "PUT with weak ETag", method: "PUT", ifMatch: `W/"1"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "PUT with the ETag of another representation", method: "PUT", ifMatch: `"1;application/xml;fields=id,name"`, expectedCode: http.StatusOK},
+		{name: "PUT with a stale ETag of another representation", method: "PUT", ifMatch: `"0;text/csv"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "PATCH with current ETag", method: "PATCH", ifMatch: `"1"`, expectedCode: http.StatusOK},
+		{name: "PATCH with stale ETag", method: "PATCH", ifMatch: `"7"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "DELETE with current ETag", method: "DELETE", ifMatch: `"1"`, expectedCode: http.StatusNoContent},
+		{name: "DELETE with stale ETag", method: "DELETE", ifMatch: `"2"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Strict PUT without If-Match", strict: true, method: "PUT", expectedCode: http.StatusPreconditionRequired},
+		{name: "Strict PATCH without If-Match", strict: true, method: "PATCH", expectedCode: http.StatusPreconditionRequired},
+		{name: "Strict DELETE without If-Match", strict: true, method: "DELETE", expectedCode: http.StatusPreconditionRequired},
+		{name: "Strict PUT with current ETag", strict: true, method: "PUT", ifMatch: `"1"`, expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			h := NewEmployeeHandler(s, WithStrictIfMatch(tt.strict))
+
+			var (
+				body    string
+				handler http.HandlerFunc
+			)
+			switch tt.method {
+			case "PUT":
+				body, handler = `{"name":"John Doe","position":"Developer","salary":65000}`, h.UpdateEmployeeHandler
+			case "PATCH":
+				body, handler = `{"salary":65000}`, h.PatchEmployeeHandler
+			case "DELETE":
+				handler = h.DeleteEmployeeHandler
+			}
+			req, err := http.NewRequest(tt.method, "/employees/1", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.method == "PATCH" {
+				req.Header.Set("Content-Type", patch.MergePatchType)
+			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedCode)
+			}
+
+			employee, err := s.GetEmployeeByID(1)
+			switch {
+			case recorder.Code == http.StatusOK:
+				if got := recorder.Header().Get("ETag"); got != `"2"` {
+					t.Errorf("handler returned ETag %s, want \"2\"", got)
+				}
+			case recorder.Code == http.StatusNoContent:
+				if err == nil {
+					t.Errorf("employee still exists after delete")
+				}
+			case err != nil || employee.Version != 1:
+				t.Errorf("rejected write changed the employee: %+v, %v", employee, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"ems/filter"
+	"ems/importer"
+	"ems/models"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportFields are the columns an export may select, in their default order.
+var exportFields = []string{"id", "name", "position", "salary", "currency", "department_id", "manager_id"}
+
+func exportValue(e models.Employee, field string) any {
+	switch field {
+	case "id":
+		return e.ID
+	case "name":
+		return e.Name
+	case "position":
+		return e.Position
+	case "currency":
+		return e.Salary.Currency()
+	case "department_id":
+		return e.DepartmentID
+	case "manager_id":
+		return e.ManagerID
+	default:
+		return e.Salary.Amount()
+	}
+}
+
+// exportWriter writes one export format. header is called once before the
+// rows, even when there are none, and close once after them.
+type exportWriter interface {
+	header(fields []string) error
+	row(e models.Employee) error
+	close() error
+}
+
+type exportFormat struct {
+	contentType string
+	extension   string
+	open        func(w io.Writer) exportWriter
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":    {"text/csv; charset=utf-8", "csv", newCSVExport},
+	"ndjson": {"application/x-ndjson", "ndjson", newNDJSONExport},
+	"xlsx":   {importer.XLSXType, "xlsx", newXLSXExport},
+}
+
+// ExportEmployeesHandler serves GET /employees:export, which streams every
+// employee matching the optional filter in id order. format is csv (the
+// default), ndjson or xlsx, and fields picks and orders the columns. The
+// export reads a single snapshot of the store, so it stays consistent while
+// writes go on.
+func (h *EmployeeHandler) ExportEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = "csv"
+	}
+	format, ok := exportFormats[formatName]
+	if !ok {
+		writeError(w, r, badRequest("Invalid format: must be csv, ndjson or xlsx"))
+		return
+	}
+
+	fields := exportFields
+	if query.Has("fields") {
+		fields = strings.Split(query.Get("fields"), ",")
+		for i, field := range fields {
+			fields[i] = strings.TrimSpace(field)
+			if !slices.Contains(exportFields, fields[i]) {
+				writeError(w, r, badRequest("Invalid field "+strconv.Quote(fields[i])))
+				return
+			}
+		}
+	}
+
+	where, err := filter.Parse(query.Get("filter"))
+	if err != nil {
+		writeError(w, r, badRequest("Invalid filter: "+err.Error()))
+		return
+	}
+	if err := h.checkCurrencies(where, nil); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Nothing is written until the store hands over the first employee, so
+	// a store that fails straight away still gets a proper error response.
+	var out exportWriter
+	start := func() error {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="employees.`+format.extension+`"`)
+		out = format.open(w)
+		return out.header(fields)
+	}
+	err = h.store.ExportEmployees(where, func(e models.Employee) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return out.row(e)
+	})
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err == nil {
+		err = out.close()
+	}
+	if err != nil && out == nil {
+		writeError(w, r, err)
+		return
+	}
+	if err != nil {
+		// The status line is long gone; abort the connection so the client
+		// sees a broken transfer rather than a short file.
+		log.Printf("%s %s: export failed: %v", r.Method, r.URL.RequestURI(), err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+type csvExport struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func newCSVExport(w io.Writer) exportWriter {
+	return &csvExport{w: csv.NewWriter(w)}
+}
+
+func (x *csvExport) header(fields []string) error {
+	x.fields = fields
+	return x.w.Write(fields)
+}
+
+func (x *csvExport) row(e models.Employee) error {
+	record := make([]string, len(x.fields))
+	for i, field := range x.fields {
+		switch v := exportValue(e, field).(type) {
+		case int:
+			record[i] = strconv.Itoa(v)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			record[i] = v
+		}
+	}
+	return x.w.Write(record)
+}
+
+func (x *csvExport) close() error {
+	x.w.Flush()
+	return x.w.Error()
+}
+
+type ndjsonExport struct {
+	w      io.Writer
+	fields []string
+	buf    []byte
+}
+
+func newNDJSONExport(w io.Writer) exportWriter {
+	return &ndjsonExport{w: w}
+}
+
+func (x *ndjsonExport) header(fields []string) error {
+	x.fields = fields
+	return nil
+}
+
+// row writes the selected fields in the order asked for, which a map or the
+// Employee struct could not do.
+func (x *ndjsonExport) row(e models.Employee) error {
+	x.buf = append(x.buf[:0], '{')
+	for i, field := range x.fields {
+		if i > 0 {
+			x.buf = append(x.buf, ',')
+		}
+		value, err := json.Marshal(exportValue(e, field))
+		if err != nil {
+			return err
+		}
+		x.buf = strconv.AppendQuote(x.buf, field)
+		x.buf = append(x.buf, ':')
+		x.buf = append(x.buf, value...)
+	}
+	x.buf = append(x.buf, '}', '\n')
+	_, err := x.w.Write(x.buf)
+	return err
+}
+
+func (x *ndjsonExport) close() error {
+	return nil
+}
+
+// xlsxExport writes rows through excelize's stream writer, which spills to
+// a temporary file rather than holding a large sheet in memory. The
+// workbook can only be zipped up once complete, so it reaches the client
+// in close.
+type xlsxExport struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	fields []string
+	next   int
+}
+
+func newXLSXExport(w io.Writer) exportWriter {
+	return &xlsxExport{w: w, file: excelize.NewFile(), next: 1}
+}
+
+func (x *xlsxExport) header(fields []string) error {
+	stream, err := x.file.NewStreamWriter(x.file.GetSheetName(0))
+	if err != nil {
+		return err
+	}
+	x.stream, x.fields = stream, fields
+	cells := make([]any, len(fields))
+	for i, field := range fields {
+		cells[i] = field
+	}
+	return x.setRow(cells)
+}
+
+func (x *xlsxExport) row(e models.Employee) error {
+	cells := make([]any, len(x.fields))
+	for i, field := range x.fields {
+		cells[i] = exportValue(e, field)
+		// Spreadsheets only hold floating-point numbers, but a salary
+		// should still be one there rather than text.
+		if field == "salary" {
+			cells[i] = e.Salary.Float64()
+		}
+	}
+	return x.setRow(cells)
+}
+
+func (x *xlsxExport) setRow(cells []any) error {
+	cell, err := excelize.CoordinatesToCellName(1, x.next)
+	if err != nil {
+		return err
+	}
+	x.next++
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxExport) close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
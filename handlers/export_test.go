@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"ems/importer"
+	"ems/models"
+	"ems/store"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExportEmployeesHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		query               string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "CSV by default",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,name,position,salary,currency,department_id,manager_id\n1,John Doe,Developer,60000,USD,0,0\n2,Alice Smith,\"Manager, Sales\",80000.5,USD,0,0\n3,Bob Johnson,Designer,70000,USD,0,0",
+		},
+		{
+			name:                "CSV with fields and filter",
+			query:               `?fields=salary,position&filter=salary+gt+65000`,
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "salary,position\n80000.5,\"Manager, Sales\"\n70000,Designer",
+		},
+		{
+			name:                "NDJSON",
+			query:               `?format=ndjson&fields=name,id`,
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody:        "{\"name\":\"John Doe\",\"id\":1}\n{\"name\":\"Alice Smith\",\"id\":2}\n{\"name\":\"Bob Johnson\",\"id\":3}",
+		},
+		{
+			name:                "Nothing matches",
+			query:               `?filter=salary+gt+1000000`,
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,name,position,salary,currency,department_id,manager_id",
+		},
+		{
+			name:         "Unknown field",
+			query:        `?fields=name,ssn`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `Invalid field "ssn"`,
+		},
+		{
+			name:         "Unknown format",
+			query:        `?format=pdf`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid format: must be csv, ndjson or xlsx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			s.CreateEmployee("Alice Smith", "Manager, Sales", usd("80000.5"))
+			s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("GET", "/employees:export"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.ExportEmployeesHandler).ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedCode)
+			}
+			if tt.expectedContentType != "" {
+				if contentType := recorder.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+					t.Errorf("handler returned wrong Content-Type: got %v want %v", contentType, tt.expectedContentType)
+				}
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %q want %q", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestExportEmployeesHandlerXLSX(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000.5"))
+	h := NewEmployeeHandler(s)
+
+	req, err := http.NewRequest("GET", "/employees:export?format=xlsx&fields=name,salary", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.ExportEmployeesHandler).ServeHTTP(recorder, req)
+	if status := recorder.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(recorder.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("response is not an XLSX file: %v", err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"name", "salary"}, {"John Doe", "60000"}, {"Alice Smith", "80000.5"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("exported rows = %v, want %v", rows, want)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	employees := []models.Employee{
+		{Name: "Carol King", Position: "CEO", Salary: usd("200000")},
+		{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 1, ManagerID: 1},
+		{Name: "Jane Roe", Position: "Designer", Salary: models.MustParseMoney("50000.5", "EUR"), DepartmentID: 2, ManagerID: 2},
+	}
+	for _, format := range []string{"csv", "xlsx"} {
+		t.Run(format, func(t *testing.T) {
+			source := store.NewMemoryStore()
+			source.CreateDepartment("Sales")
+			source.CreateDepartment("Engineering")
+			for _, e := range employees {
+				source.InsertEmployee(e)
+			}
+			req, err := http.NewRequest("GET", "/employees:export?format="+format, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exported := httptest.NewRecorder()
+			http.HandlerFunc(NewEmployeeHandler(source).ExportEmployeesHandler).ServeHTTP(exported, req)
+			if exported.Code != http.StatusOK {
+				t.Fatalf("export returned wrong status code: got %v want %v", exported.Code, http.StatusOK)
+			}
+
+			// Importing the export elsewhere keeps departments, managers
+			// and currencies.
+			s := store.NewMemoryStore()
+			s.CreateDepartment("Sales")
+			s.CreateDepartment("Engineering")
+			req, err = http.NewRequest("POST", "/employees:import", bytes.NewReader(exported.Body.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", exported.Header().Get("Content-Type"))
+			imported := httptest.NewRecorder()
+			http.HandlerFunc(NewEmployeeHandler(s).ImportEmployeesHandler).ServeHTTP(imported, req)
+			var report importer.Report
+			if err := json.Unmarshal(imported.Body.Bytes(), &report); err != nil || imported.Code != http.StatusOK || report.Imported != 3 || report.Failed != 0 {
+				t.Fatalf("import returned %v %s, want all 3 rows imported", imported.Code, imported.Body)
+			}
+
+			for i, want := range employees {
+				want.ID = i + 1
+				want.Version = 1
+				if got, err := s.GetEmployeeByID(i + 1); err != nil || got != want {
+					t.Errorf("GetEmployeeByID(%d) = %+v, %v, want %+v", i+1, got, err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"ems/fieldset"
+	"ems/models"
+	"net/http"
+)
+
+// employeeSchema is what the fields parameter can select from an employee.
+var employeeSchema = fieldset.SchemaOf(models.Employee{})
+
+// parseFields reads the fields query parameter. A nil set means the whole
+// employee.
+func parseFields(r *http.Request) (*fieldset.Set, error) {
+	fields, err := fieldset.Parse(r.URL.Query().Get("fields"), employeeSchema)
+	if err != nil {
+		return nil, badRequest("Invalid fields parameter: " + err.Error())
+	}
+	return fields, nil
+}
+
+// project narrows e down to fields, if any were asked for.
+func project(fields *fieldset.Set, e models.Employee) any {
+	if fields == nil {
+		return e
+	}
+	return fields.Apply(e)
+}
+
+// projectPage narrows every item of page down to fields, if any were asked
+// for.
+func projectPage(fields *fieldset.Set, page models.EmployeePage) any {
+	if fields == nil {
+		return page
+	}
+	items := make([]fieldset.Object, len(page.Items))
+	for i, e := range page.Items {
+		items[i] = fields.Apply(e)
+	}
+	return models.Page[fieldset.Object]{
+		Items:      items,
+		Total:      page.Total,
+		Page:       page.Page,
+		Size:       page.Size,
+		TotalPages: page.TotalPages,
+		Links:      page.Links,
+		Conversion: page.Conversion,
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/store"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newFieldsStore returns a store with three employees in two departments
+// and two currencies, so every field has something to show.
+func newFieldsStore() *store.MemoryStore {
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Sales")
+	s.CreateDepartment("Engineering")
+	s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 1})
+	s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1, ManagerID: 1})
+	s.InsertEmployee(models.Employee{Name: "Jane Roe", Position: "Designer", Salary: models.MustParseMoney("50000.5", "EUR"), DepartmentID: 2, ManagerID: 1})
+	return s
+}
+
+func TestGetSparseFieldset(t *testing.T) {
+	h := NewEmployeeHandler(newFieldsStore())
+
+	tests := []struct {
+		name         string
+		target       string
+		accept       string
+		expectedBody string
+	}{
+		{
+			name:         "Fields in the order of the employee",
+			target:       "/employees/2?fields=name,id,manager_id",
+			expectedBody: `{"id":2,"name":"John Doe","manager_id":1}`,
+		},
+		{
+			name:         "Zero id kept when asked for",
+			target:       "/employees/1?fields=name,manager_id",
+			expectedBody: `{"name":"Alice Smith","manager_id":0}`,
+		},
+		{
+			name:         "XML",
+			target:       "/employees/3?fields=salary",
+			accept:       "application/xml",
+			expectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<employee><salary><amount>50000.5</amount><currency>EUR</currency></salary></employee>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.GetEmployeeHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, http.StatusOK)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestListSparseFieldset(t *testing.T) {
+	h := NewEmployeeHandler(newFieldsStore())
+
+	req, err := http.NewRequest("GET", "/employees?page=1&size=2&fields=id,position", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+	}
+
+	var page models.Page[map[string]any]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{{"id": 1.0, "position": "Manager"}, {"id": 2.0, "position": "Developer"}}
+	if !reflect.DeepEqual(page.Items, want) || page.Total != 3 || page.TotalPages != 2 {
+		t.Errorf("handler returned page %+v, want items %v of 3 in 2 pages", page, want)
+	}
+	if want := "/employees?fields=id%2Cposition&page=2&size=2"; page.Links.Next != want {
+		t.Errorf("handler returned next link %q, want %q, keeping the fieldset", page.Links.Next, want)
+	}
+}
+
+func TestListSparseFieldsetAsCSV(t *testing.T) {
+	h := NewEmployeeHandler(newFieldsStore())
+
+	req, err := http.NewRequest("GET", "/employees?size=10&fields=name,department_id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/csv")
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+	}
+
+	got, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("undecodable CSV: %v", err)
+	}
+	want := [][]string{{"name", "department_id"}, {"Alice Smith", "1"}, {"John Doe", "1"}, {"Jane Roe", "2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handler returned records %q, want %q", got, want)
+	}
+}
+
+func TestInvalidFieldset(t *testing.T) {
+	h := NewEmployeeHandler(newFieldsStore())
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		target       string
+		expectedBody string
+	}{
+		{
+			name:         "Unknown field",
+			handler:      h.GetEmployeeHandler,
+			target:       "/employees/1?fields=id,ssn",
+			expectedBody: `Invalid fields parameter: unknown field "ssn" (want one of id, name, position, salary, department_id, manager_id)`,
+		},
+		{
+			name:         "Member of a scalar",
+			handler:      h.GetEmployeeHandler,
+			target:       "/employees/1?fields=name.first",
+			expectedBody: `Invalid fields parameter: unknown field "name.first" (name has no members)`,
+		},
+		{
+			name:         "Empty field name",
+			handler:      h.ListEmployeesHandler,
+			target:       "/employees?size=10&fields=id,,name",
+			expectedBody: "Invalid fields parameter: empty field name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			tt.handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, http.StatusBadRequest)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+		})
+	}
+}
@@ -1,26 +1,39 @@
 package handlers
 
 import (
-	"ems/store"
-	"encoding/json"
 	"net/http"
 	"strconv"
 )
 
-func GetEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *EmployeeHandler) GetEmployeeHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Path[len("/employees/"):]
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id < 1 {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, badRequest("Invalid employee ID"))
+		return
+	}
+	c, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+	fields, err := parseFields(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	employee, err := store.GetEmployeeByID(id)
+	employee, err := h.store.GetEmployeeByID(id)
 	if err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+		writeError(w, r, err)
+		return
+	}
+
+	tag := etag(employee, c, fields)
+	w.Header().Set("ETag", tag)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, tag, true) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(employee)
+	writeEncoded(w, r, c, http.StatusOK, project(fields, employee))
 }
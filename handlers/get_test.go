@@ -5,14 +5,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
-	"strings"
 	"testing"
 )
 
 func TestGetEmployeeHandler(t *testing.T) {
 	// Initialize some employees for testing
-	store.CreateEmployee("John Doe", "Developer", 60000.0)
-	store.CreateEmployee("Alice Smith", "Manager", 80000.0)
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	h := NewEmployeeHandler(s)
 
 	tests := []struct {
 		name         string
@@ -25,14 +26,14 @@ func TestGetEmployeeHandler(t *testing.T) {
 			name:         "Valid employee",
 			id:           1,
 			expectedCode: http.StatusOK,
-			expectedBody: `{"id":1,"name":"John Doe","position":"Developer","salary":60000}`,
+			expectedBody: `{"id":1,"name":"John Doe","position":"Developer","salary":{"amount":"60000","currency":"USD"}}`,
 		},
 		// Employee does not exist
 		{
 			name:         "Non-existent employee",
 			id:           90,
 			expectedCode: http.StatusNotFound,
-			expectedBody: "Employee not found",
+			expectedBody: "employee 90 not found",
 		},
 		// Invalid ID: less than 1
 		{
@@ -50,7 +51,7 @@ func TestGetEmployeeHandler(t *testing.T) {
 				t.Fatal(err)
 			}
 			recorder := httptest.NewRecorder()
-			handler := http.HandlerFunc(GetEmployeeHandler)
+			handler := http.HandlerFunc(h.GetEmployeeHandler)
 
 			handler.ServeHTTP(recorder, req)
 
@@ -61,7 +62,7 @@ func TestGetEmployeeHandler(t *testing.T) {
 
 			// Check response body for error cases
 			if recorder.Code != http.StatusOK {
-				body := strings.TrimSpace(recorder.Body.String())
+				body := responseText(recorder)
 				if body != tt.expectedBody {
 					t.Errorf("handler returned unexpected body: got %v want %v",
 						body, tt.expectedBody)
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/rates"
+	"ems/store"
+)
+
+// EmployeeHandler serves the employee endpoints on top of a repository.
+type EmployeeHandler struct {
+	store         store.EmployeeRepository
+	cursors       cursorCodec
+	strictIfMatch bool
+	codecs        *codec.Registry
+	rates         *rates.Table
+	ratesAdmin    bool
+}
+
+// Option configures an EmployeeHandler.
+type Option func(*handlerConfig)
+
+type handlerConfig struct {
+	cursorSecret  []byte
+	strictIfMatch bool
+	codecs        *codec.Registry
+	rates         *rates.Table
+	ratesAdmin    bool
+}
+
+// WithCursorSecret sets the key used to sign list cursors. Without it a
+// random key is generated, so cursors do not survive a restart.
+func WithCursorSecret(secret []byte) Option {
+	return func(c *handlerConfig) {
+		c.cursorSecret = secret
+	}
+}
+
+// WithStrictIfMatch makes PUT, PATCH and DELETE fail with 428 Precondition
+// Required unless they carry an If-Match header. Without it such requests
+// overwrite whatever version is current.
+func WithStrictIfMatch(strict bool) Option {
+	return func(c *handlerConfig) {
+		c.strictIfMatch = strict
+	}
+}
+
+// WithCodecs sets the media types employees can be sent and received in.
+// Without it codec.Default is used.
+func WithCodecs(codecs *codec.Registry) Option {
+	return func(c *handlerConfig) {
+		c.codecs = codecs
+	}
+}
+
+// WithRates sets the exchange rates salaries are converted with. Without it
+// the handler starts with an empty table, which only POST /admin/rates can
+// fill.
+func WithRates(table *rates.Table) Option {
+	return func(c *handlerConfig) {
+		c.rates = table
+	}
+}
+
+// WithRatesAdmin enables POST /admin/rates, through which any client that
+// reaches the server can rewrite the exchange rates. Without it the rates
+// are read-only over HTTP.
+func WithRatesAdmin(enabled bool) Option {
+	return func(c *handlerConfig) {
+		c.ratesAdmin = enabled
+	}
+}
+
+func NewEmployeeHandler(repo store.EmployeeRepository, opts ...Option) *EmployeeHandler {
+	cfg := handlerConfig{codecs: codec.Default(), rates: rates.New()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &EmployeeHandler{
+		store:         repo,
+		cursors:       newCursorCodec(cfg.cursorSecret),
+		strictIfMatch: cfg.strictIfMatch,
+		codecs:        cfg.codecs,
+		rates:         cfg.rates,
+		ratesAdmin:    cfg.ratesAdmin,
+	}
+}
+
+// RatesAdmin reports whether POST /admin/rates is enabled, for the router
+// to register it.
+func (h *EmployeeHandler) RatesAdmin() bool {
+	return h.ratesAdmin
+}
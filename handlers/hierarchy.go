@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// orgNode is one employee in a subtree response, with the employees who
+// report to them directly.
+type orgNode struct {
+	models.Employee
+	Reports []*orgNode `json:"reports"`
+}
+
+// employeeID reads the id from the start of an /employees/{id}/... path.
+func employeeID(r *http.Request) (int, error) {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/employees/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 1 {
+		return 0, badRequest("Invalid employee ID")
+	}
+	return id, nil
+}
+
+// DirectReportsHandler serves GET /employees/{id}/reports, the employees
+// whose manager is {id}, in id order.
+func (h *EmployeeHandler) DirectReportsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := employeeID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	reports, err := h.store.DirectReports(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, reports)
+}
+
+// ManagementChainHandler serves GET /employees/{id}/chain, the managers
+// above {id} from its own manager up to the root.
+func (h *EmployeeHandler) ManagementChainHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := employeeID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	chain, err := h.store.ManagementChain(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, chain)
+}
+
+// SubtreeHandler serves GET /employees/{id}/subtree, {id} with everyone
+// who reports to them, directly or not, nested under their managers.
+func (h *EmployeeHandler) SubtreeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := employeeID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	root, err := h.store.GetEmployeeByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	subordinates, err := h.store.Subordinates(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, buildTree(root, subordinates))
+}
+
+// buildTree nests subordinates under root. Each subordinate must come after
+// its manager, as Subordinates returns them.
+func buildTree(root models.Employee, subordinates []models.Employee) *orgNode {
+	nodes := map[int]*orgNode{root.ID: {Employee: root, Reports: []*orgNode{}}}
+	for _, e := range subordinates {
+		node := &orgNode{Employee: e, Reports: []*orgNode{}}
+		nodes[e.ID] = node
+		if manager, ok := nodes[e.ManagerID]; ok {
+			manager.Reports = append(manager.Reports, node)
+		}
+	}
+	return nodes[root.ID]
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/store"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newHierarchyStore returns a store holding a small organisation:
+//
+//	1 Carol King, reporting to no one
+//	  2 Alice Smith
+//	    3 John Doe
+//	    5 Bob Johnson
+//	  4 Jane Roe
+func newHierarchyStore() *store.MemoryStore {
+	s := store.NewMemoryStore()
+	s.InsertEmployee(models.Employee{Name: "Carol King", Position: "CEO", Salary: usd("200000")})
+	s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), ManagerID: 1})
+	s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), ManagerID: 2})
+	s.InsertEmployee(models.Employee{Name: "Jane Roe", Position: "Designer", Salary: usd("70000"), ManagerID: 1})
+	s.InsertEmployee(models.Employee{Name: "Bob Johnson", Position: "Tester", Salary: usd("50000"), ManagerID: 2})
+	return s
+}
+
+// shape renders a subtree as ids, each followed by its reports in
+// parentheses, such as 1(2(3) 4).
+func shape(n *orgNode) string {
+	if len(n.Reports) == 0 {
+		return fmt.Sprint(n.ID)
+	}
+	reports := make([]string, len(n.Reports))
+	for i, report := range n.Reports {
+		reports[i] = shape(report)
+	}
+	return fmt.Sprintf("%d(%s)", n.ID, strings.Join(reports, " "))
+}
+
+func TestHierarchyHandlers(t *testing.T) {
+	h := NewEmployeeHandler(newHierarchyStore())
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		target       string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Direct reports",
+			handler:      h.DirectReportsHandler,
+			target:       "/employees/1/reports",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":2,"name":"Alice Smith","position":"Manager","salary":{"amount":"80000","currency":"USD"},"manager_id":1},` +
+				`{"id":4,"name":"Jane Roe","position":"Designer","salary":{"amount":"70000","currency":"USD"},"manager_id":1}]`,
+		},
+		{
+			name:         "No direct reports",
+			handler:      h.DirectReportsHandler,
+			target:       "/employees/3/reports",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+		},
+		{
+			name:         "Reports of a non-existent employee",
+			handler:      h.DirectReportsHandler,
+			target:       "/employees/9/reports",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "employee 9 not found",
+		},
+		{
+			name:         "Reports with an invalid ID",
+			handler:      h.DirectReportsHandler,
+			target:       "/employees/abc/reports",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid employee ID",
+		},
+		{
+			name:         "Management chain",
+			handler:      h.ManagementChainHandler,
+			target:       "/employees/3/chain",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":2,"name":"Alice Smith","position":"Manager","salary":{"amount":"80000","currency":"USD"},"manager_id":1},` +
+				`{"id":1,"name":"Carol King","position":"CEO","salary":{"amount":"200000","currency":"USD"}}]`,
+		},
+		{
+			name:         "Management chain of the root",
+			handler:      h.ManagementChainHandler,
+			target:       "/employees/1/chain",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+		},
+		{
+			name:         "Subtree of a non-existent employee",
+			handler:      h.SubtreeHandler,
+			target:       "/employees/9/subtree",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "employee 9 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			tt.handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestSubtreeHandler(t *testing.T) {
+	h := NewEmployeeHandler(newHierarchyStore())
+
+	req, err := http.NewRequest("GET", "/employees/1/subtree", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.SubtreeHandler).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+	}
+
+	var root orgNode
+	if err := json.Unmarshal(recorder.Body.Bytes(), &root); err != nil {
+		t.Fatal(err)
+	}
+	if got := shape(&root); got != "1(2(3 5) 4)" {
+		t.Errorf("handler returned subtree %s, want 1(2(3 5) 4)", got)
+	}
+	if got := root.Reports[0].Reports[1].Employee; got.Name != "Bob Johnson" || got.ManagerID != 2 {
+		t.Errorf("handler returned %+v for employee 5, want Bob Johnson reporting to 2", got)
+	}
+}
+
+func TestChangeManager(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		payload      string
+		expectedCode int
+		expectedBody string
+		reports      map[int][]int
+	}{
+		{
+			name:         "New manager",
+			target:       "/employees/3",
+			payload:      `{"name":"John Doe","position":"Developer","salary":60000,"manager_id":4}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":3,"name":"John Doe","position":"Developer","salary":{"amount":"60000","currency":"USD"},"manager_id":4}`,
+			reports:      map[int][]int{1: {2, 4}, 2: {5}, 4: {3}},
+		},
+		{
+			name:         "Missing manager",
+			target:       "/employees/3",
+			payload:      `{"name":"John Doe","position":"Developer","salary":60000,"manager_id":9}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "employee 9 does not exist",
+			reports:      map[int][]int{1: {2, 4}, 2: {3, 5}, 4: {}},
+		},
+		{
+			name:         "Subordinate as manager",
+			target:       "/employees/1",
+			payload:      `{"name":"Carol King","position":"CEO","salary":200000,"manager_id":3}`,
+			expectedCode: http.StatusConflict,
+			expectedBody: "employee 1 cannot report to employee 3, who reports to them",
+			reports:      map[int][]int{1: {2, 4}, 2: {3, 5}, 3: {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newHierarchyStore()
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("PUT", tt.target, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.UpdateEmployeeHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+			for manager, want := range tt.reports {
+				reports, _ := s.DirectReports(manager)
+				got := []int{}
+				for _, e := range reports {
+					got = append(got, e.ID)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("DirectReports(%d) = %v, want %v", manager, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"ems/importer"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// maxImportSize caps the spreadsheet an import request may upload.
+const maxImportSize = 10 << 20
+
+// ImportEmployeesHandler serves POST /employees:import. The body is a CSV or
+// XLSX file, chosen by Content-Type. The name, position, salary, currency,
+// department_id and manager_id query parameters name the header of each
+// field's column when it differs from the field name, and dry_run=true
+// validates every row without writing.
+// The response is an importer.Report, listing the errors of each rejected
+// row.
+func (h *EmployeeHandler) ImportEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, ok := importer.FormatOf(mediaType)
+	if err != nil || !ok {
+		writeError(w, r, newProblem(problemUnsupportedMediaType, "Import accepts "+importer.CSVType+" or "+importer.XLSXType))
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := false
+	if query.Has("dry_run") {
+		dryRun, err = strconv.ParseBool(query.Get("dry_run"))
+		if err != nil {
+			writeError(w, r, badRequest("Invalid dry_run parameter"))
+			return
+		}
+	}
+	mapping := importer.Mapping{
+		Name:         query.Get("name"),
+		Position:     query.Get("position"),
+		Salary:       query.Get("salary"),
+		Currency:     query.Get("currency"),
+		DepartmentID: query.Get("department_id"),
+		ManagerID:    query.Get("manager_id"),
+	}
+
+	rows, err := importer.ReadRows(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, r, newProblem(problemTooLarge, "Import file is larger than "+strconv.Itoa(maxImportSize)+" bytes"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, badRequest("Invalid "+string(format)+" file: "+err.Error()))
+		return
+	}
+	parsed, err := importer.Parse(rows, mapping)
+	if err != nil {
+		writeError(w, r, badRequest("Invalid "+string(format)+" file: "+err.Error()))
+		return
+	}
+
+	report, err := importer.Import(h.store, parsed, dryRun)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"ems/importer"
+	"ems/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImportEmployeesHandler(t *testing.T) {
+	const csv = "Full Name,position,salary\nAlice Smith,Manager,80000\nBob Johnson,,70000\n"
+	tests := []struct {
+		name          string
+		query         string
+		contentType   string
+		payload       string
+		expectedCode  int
+		expectedBody  string
+		expectedCount int
+	}{
+		{
+			name:          "Import valid rows",
+			query:         "?name=Full+Name",
+			contentType:   importer.CSVType,
+			payload:       csv,
+			expectedCode:  http.StatusOK,
+			expectedBody:  `{"dry_run":false,"rows":2,"imported":1,"failed":1,"errors":[{"row":3,"errors":[{"field":"position","code":"required","message":"position is required"}]}]}`,
+			expectedCount: 2,
+		},
+		{
+			name:          "Dry run writes nothing",
+			query:         "?name=Full+Name&dry_run=true",
+			contentType:   importer.CSVType + "; charset=utf-8",
+			payload:       csv,
+			expectedCode:  http.StatusOK,
+			expectedBody:  `{"dry_run":true,"rows":2,"imported":1,"failed":1,"errors":[{"row":3,"errors":[{"field":"position","code":"required","message":"position is required"}]}]}`,
+			expectedCount: 1,
+		},
+		{
+			name:          "Missing column",
+			contentType:   importer.CSVType,
+			payload:       csv,
+			expectedCode:  http.StatusBadRequest,
+			expectedBody:  `Invalid csv file: no "name" column for name`,
+			expectedCount: 1,
+		},
+		{
+			name:          "Unsupported format",
+			contentType:   "application/json",
+			payload:       `[]`,
+			expectedCode:  http.StatusUnsupportedMediaType,
+			expectedBody:  "Import accepts " + importer.CSVType + " or " + importer.XLSXType,
+			expectedCount: 1,
+		},
+		{
+			name:          "Not a spreadsheet",
+			contentType:   importer.XLSXType,
+			payload:       csv,
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("POST", "/employees:import"+tt.query, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.ImportEmployeesHandler).ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedCode)
+			}
+			if tt.expectedBody != "" {
+				if body := responseText(recorder); body != tt.expectedBody {
+					t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+				}
+			}
+			if count, _ := s.CountEmployees(store.ListOptions{}); count != tt.expectedCount {
+				t.Errorf("employees after the import: got %d want %d", count, tt.expectedCount)
+			}
+		})
+	}
+}
@@ -1,33 +1,314 @@
 package handlers
 
 import (
+	"ems/codec"
+	"ems/fieldset"
+	"ems/filter"
+	"ems/models"
+	"ems/rates"
 	"ems/store"
-	"encoding/json"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 )
 
-func ListEmployeesHandler(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
+// ListEmployeesHandler serves GET /employees. With a page parameter it pages
+// by offset; without one it pages by signed keyset cursor. Either way the
+// response is a models.EmployeePage, and its links are repeated in a Link
+// header. With a currency parameter every salary is converted to that
+// currency with the rates in effect on as_of, today by default, and the
+// page records the rates used; filters and sorting still go by the salaries
+// as stored, so while salaries are in several currencies a filter or sort
+// on salary needs a currency filter too (see checkCurrencies).
+func (h *EmployeeHandler) ListEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	h.listEmployees(w, r, nil)
+}
 
-	page, err := strconv.Atoi(query.Get("page"))
-	if err != nil || page < 1 {
-		http.Error(w, "Invalid page number", http.StatusBadRequest)
+// listEmployees serves a listing of the employees matching scope, or of
+// all of them if it is nil, narrowed further by the request's filter.
+func (h *EmployeeHandler) listEmployees(w http.ResponseWriter, r *http.Request, scope filter.Expr) {
+	query := r.URL.Query()
+	c, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+	fields, err := parseFields(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	conv, err := h.converter(r, "")
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	page := 0
+	if query.Has("page") {
+		var err error
+		page, err = strconv.Atoi(query.Get("page"))
+		if err != nil || page < 1 {
+			writeError(w, r, badRequest("Invalid page number"))
+			return
+		}
+	}
+
 	perPage, err := strconv.Atoi(query.Get("size"))
 	if err != nil || perPage < 1 {
-		http.Error(w, "Invalid page size", http.StatusBadRequest)
+		writeError(w, r, badRequest("Invalid page size"))
 		return
 	}
 
-	employees := store.ListEmployees(page, perPage)
-	if len(employees) == 0 {
-		http.Error(w, "No employees found", http.StatusNotFound)
+	sort, err := store.ParseSort(strings.Join(query["sort"], ","))
+	if err != nil {
+		writeError(w, r, badRequest("Invalid sort parameter"))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(employees)
+	expr, err := filter.Parse(query.Get("filter"))
+	if err != nil {
+		writeError(w, r, badRequest("Invalid filter: "+err.Error()))
+		return
+	}
+	switch {
+	case expr == nil:
+		expr = scope
+	case scope != nil:
+		expr = filter.And{Left: scope, Right: expr}
+	}
+
+	opts := store.ListOptions{Page: page, PerPage: perPage, Sort: sort, Filter: expr}
+	if page == 0 {
+		h.listByCursor(w, r, c, fields, conv, opts)
+		return
+	}
+	if err := h.checkCurrencies(opts.Filter, opts.Sort); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	employees, err := h.store.ListEmployees(opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	total, err := h.store.CountEmployees(opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	totalPages := pageCount(total, perPage)
+	links := models.PageLinks{
+		Self:  r.URL.RequestURI(),
+		First: pageURL(r.URL, 1),
+		Last:  pageURL(r.URL, max(totalPages, 1)),
+	}
+	if page > 1 {
+		links.Prev = pageURL(r.URL, min(page-1, max(totalPages, 1)))
+	}
+	if page < totalPages {
+		links.Next = pageURL(r.URL, page+1)
+	}
+
+	writePage(w, r, c, fields, conv, models.EmployeePage{
+		Items:      employees,
+		Total:      total,
+		Page:       page,
+		Size:       perPage,
+		TotalPages: totalPages,
+		Links:      links,
+	})
+}
+
+func (h *EmployeeHandler) listByCursor(w http.ResponseWriter, r *http.Request, c codec.Codec, fields *fieldset.Set, conv *rates.Converter, opts store.ListOptions) {
+	query := r.URL.Query()
+	perPage := opts.PerPage
+
+	// Ask for one extra row to learn whether there is another page beyond
+	// this one without a separate count.
+	opts.Keyset = true
+	opts.PerPage = perPage + 1
+	if token := query.Get("cursor"); token != "" {
+		cur, err := h.cursors.decode(token)
+		if err != nil {
+			writeError(w, r, badRequest("Invalid cursor"))
+			return
+		}
+		cursorSort, err := store.ParseSort(cur.Sort)
+		if err != nil {
+			writeError(w, r, badRequest("Invalid cursor"))
+			return
+		}
+		if query.Has("sort") && store.FormatSort(opts.Sort) != cur.Sort {
+			writeError(w, r, badRequest("Cursor does not match sort parameter"))
+			return
+		}
+		opts.Sort = cursorSort
+		if cur.Before {
+			opts.Before = &cur.Boundary
+		} else {
+			opts.After = &cur.Boundary
+		}
+	}
+	if err := h.checkCurrencies(opts.Filter, opts.Sort); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	employees, err := h.store.ListEmployees(opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	total, err := h.store.CountEmployees(opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	hasMore := len(employees) > perPage
+	if hasMore {
+		if opts.Before != nil {
+			employees = employees[1:]
+		} else {
+			employees = employees[:perPage]
+		}
+	}
+
+	hasNext, hasPrev := hasMore, opts.After != nil
+	if opts.Before != nil {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	links := models.PageLinks{
+		Self:  r.URL.RequestURI(),
+		First: cursorURL(r.URL, ""),
+	}
+	sortParam := store.FormatSort(opts.Sort)
+	if hasNext && len(employees) > 0 {
+		next := cursor{Sort: sortParam, Boundary: boundaryOf(employees[len(employees)-1], opts.Sort)}
+		links.Next = cursorURL(r.URL, h.cursors.encode(next))
+	}
+	if hasPrev && len(employees) > 0 {
+		prev := cursor{Sort: sortParam, Before: true, Boundary: boundaryOf(employees[0], opts.Sort)}
+		links.Prev = cursorURL(r.URL, h.cursors.encode(prev))
+	}
+
+	writePage(w, r, c, fields, conv, models.EmployeePage{
+		Items:      employees,
+		Total:      total,
+		Size:       perPage,
+		TotalPages: pageCount(total, perPage),
+		Links:      links,
+	})
+}
+
+// writePage sends page, narrowed down to fields, with c and mirrors its
+// navigation links in a Link header. With conv set the salaries are
+// converted first.
+func writePage(w http.ResponseWriter, r *http.Request, c codec.Codec, fields *fieldset.Set, conv *rates.Converter, page models.EmployeePage) {
+	if page.Items == nil {
+		page.Items = []models.Employee{}
+	}
+	if conv != nil {
+		if err := convertSalaries(conv, page.Items); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		page.Conversion = conv.Conversion()
+	}
+
+	var links []string
+	for _, link := range []struct{ rel, target string }{
+		{"first", page.Links.First},
+		{"prev", page.Links.Prev},
+		{"next", page.Links.Next},
+		{"last", page.Links.Last},
+	} {
+		if link.target != "" {
+			links = append(links, `<`+link.target+`>; rel="`+link.rel+`"`)
+		}
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	writeEncoded(w, r, c, http.StatusOK, projectPage(fields, page))
+}
+
+// checkCurrencies rejects a filter that compares salaries, or a sort by
+// salary, while employees are paid in more than one currency: salaries
+// compare by amount alone, which means nothing across currencies. A filter
+// that pins the currency, such as currency eq "EUR" and salary gt 70000, is
+// always accepted.
+func (h *EmployeeHandler) checkCurrencies(where filter.Expr, sort []store.SortKey) error {
+	bySalary := slices.ContainsFunc(sort, func(key store.SortKey) bool { return key.Field == "salary" })
+	if !bySalary && !filter.Refers(where, "salary") {
+		return nil
+	}
+	if _, ok := filter.Pinned(where, "currency"); ok {
+		return nil
+	}
+	first, err := h.store.ListEmployees(store.ListOptions{Page: 1, PerPage: 1})
+	if err != nil || len(first) == 0 {
+		return err
+	}
+	others, err := h.store.CountEmployees(store.ListOptions{Filter: filter.Comparison{
+		Field:  "currency",
+		Op:     filter.OpNe,
+		Values: []filter.Value{{Kind: filter.Text, Text: first[0].Salary.Currency()}},
+	}})
+	if err != nil {
+		return err
+	}
+	if others > 0 {
+		return badRequest(`Salaries are in more than one currency: filter on one, as in currency eq "USD", to compare or sort by salary`)
+	}
+	return nil
+}
+
+func pageCount(total, perPage int) int {
+	return (total + perPage - 1) / perPage
+}
+
+// boundaryOf keeps only the fields a cursor needs to resume after e, so the
+// token does not carry data such as salary when it is not being sorted on.
+func boundaryOf(e models.Employee, sort []store.SortKey) models.Employee {
+	boundary := models.Employee{ID: e.ID}
+	for _, key := range sort {
+		switch key.Field {
+		case "name":
+			boundary.Name = e.Name
+		case "position":
+			boundary.Position = e.Position
+		case "salary":
+			boundary.Salary = e.Salary
+		case "department_id":
+			boundary.DepartmentID = e.DepartmentID
+		case "manager_id":
+			boundary.ManagerID = e.ManagerID
+		}
+	}
+	return boundary
+}
+
+// pageURL is the request URL pointing at another offset page.
+func pageURL(u *url.URL, page int) string {
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	return u.Path + "?" + query.Encode()
+}
+
+// cursorURL is the request URL with its cursor replaced, or removed when
+// token is empty.
+func cursorURL(u *url.URL, token string) string {
+	query := u.Query()
+	query.Del("cursor")
+	if token != "" {
+		query.Set("cursor", token)
+	}
+	return u.Path + "?" + query.Encode()
 }
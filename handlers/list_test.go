@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -13,10 +15,12 @@ import (
 
 func TestListEmployeesHandler(t *testing.T) {
 	// Initialize some employees for testing
-	store.CreateEmployee("John Doe", "Developer", 60000.0)
-	store.CreateEmployee("Alice Smith", "Manager", 80000.0)
-	store.CreateEmployee("Bob Johnson", "Designer", 70000.0)
-	store.CreateEmployee("Eve Williams", "Tester", 65000.0)
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	s.CreateEmployee("Eve Williams", "Tester", usd("65000"))
+	h := NewEmployeeHandler(s)
 
 	tests := []struct {
 		name              string
@@ -34,13 +38,13 @@ func TestListEmployeesHandler(t *testing.T) {
 			expectedCode:      http.StatusOK,
 			expectedBodyCount: 2,
 		},
-		// Valid case: List second page with 3 employees per page
+		// Valid case: List second page with 3 employees per page (only 1 left)
 		{
 			name:              "List second page with 3 employees per page",
 			page:              2,
 			size:              3,
 			expectedCode:      http.StatusOK,
-			expectedBodyCount: 3,
+			expectedBodyCount: 1,
 		},
 		// Invalid page number: Page 0
 		{
@@ -58,13 +62,13 @@ func TestListEmployeesHandler(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: "Invalid page size",
 		},
-		// No employees found
+		// Past the end: empty page rather than 404
 		{
-			name:         "No employees found",
-			page:         5,
-			size:         2,
-			expectedCode: http.StatusNotFound,
-			expectedBody: "No employees found",
+			name:              "Past the end returns an empty page",
+			page:              5,
+			size:              2,
+			expectedCode:      http.StatusOK,
+			expectedBodyCount: 0,
 		},
 	}
 
@@ -75,7 +79,7 @@ func TestListEmployeesHandler(t *testing.T) {
 				t.Fatal(err)
 			}
 			recorder := httptest.NewRecorder()
-			handler := http.HandlerFunc(ListEmployeesHandler)
+			handler := http.HandlerFunc(h.ListEmployeesHandler)
 
 			handler.ServeHTTP(recorder, req)
 
@@ -86,23 +90,393 @@ func TestListEmployeesHandler(t *testing.T) {
 
 			// Check response body for error cases
 			if recorder.Code != http.StatusOK {
-				body := strings.TrimSpace(recorder.Body.String())
+				body := responseText(recorder)
 				if body != tt.expectedBody {
 					t.Errorf("handler returned unexpected body: got %v want %v",
 						body, tt.expectedBody)
 				}
 			} else {
 				// Check response body for successful list case
-				var employees []models.Employee
-				if err := json.Unmarshal(recorder.Body.Bytes(), &employees); err != nil {
+				var page models.EmployeePage
+				if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
 					t.Fatalf("error unmarshalling response body: %v", err)
 				}
 
-				if len(employees) != tt.expectedBodyCount {
+				if len(page.Items) != tt.expectedBodyCount {
 					t.Errorf("handler returned unexpected number of employees: got %v want %v",
-						len(employees), tt.expectedBodyCount)
+						len(page.Items), tt.expectedBodyCount)
+				}
+				if page.Total != 4 || page.TotalPages != (4+tt.size-1)/tt.size {
+					t.Errorf("handler returned unexpected totals: got total %v pages %v",
+						page.Total, page.TotalPages)
 				}
 			}
 		})
 	}
 }
+
+func TestListEmployeesHandlerSort(t *testing.T) {
+	// Initialize some employees for testing
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedIDs  []int
+	}{
+		{
+			name:         "Sort by salary descending",
+			query:        "sort=-salary",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []int{2, 3, 1},
+		},
+		{
+			name:         "Repeated sort parameters",
+			query:        "sort=position&sort=-id",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []int{3, 1, 2},
+		},
+		{
+			name:         "Unknown sort field",
+			query:        "sort=age",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/employees?page=1&size=10&"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.ListEmployeesHandler)
+
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedCode)
+			}
+			if recorder.Code != http.StatusOK {
+				return
+			}
+
+			var page models.EmployeePage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+				t.Fatalf("error unmarshalling response body: %v", err)
+			}
+			var ids []int
+			for _, e := range page.Items {
+				ids = append(ids, e.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("handler returned employees in wrong order: got %v want %v", ids, tt.expectedIDs)
+			}
+		})
+	}
+}
+
+func TestListEmployeesHandlerCursor(t *testing.T) {
+	// Initialize some employees for testing
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	s.CreateEmployee("Eve Williams", "Tester", usd("65000"))
+	s.CreateEmployee("Carol White", "Developer", usd("70000"))
+	h := NewEmployeeHandler(s, WithCursorSecret([]byte("test secret")))
+
+	get := func(target string) (*httptest.ResponseRecorder, []int) {
+		t.Helper()
+		req, err := http.NewRequest("GET", target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+
+		var ids []int
+		if recorder.Code == http.StatusOK {
+			var page models.EmployeePage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+				t.Fatalf("error unmarshalling response body: %v", err)
+			}
+			for _, e := range page.Items {
+				ids = append(ids, e.ID)
+			}
+		}
+		return recorder, ids
+	}
+
+	// Walk forward through every page, inserting an employee part way
+	// through; the inserted salary sorts before the current position so it
+	// must not appear or shift later pages.
+	var seen []int
+	var pages []string
+	next := "/employees?size=2&sort=-salary"
+	for next != "" {
+		recorder, ids := get(next)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("GET %s returned %v", next, recorder.Code)
+		}
+		pages = append(pages, next)
+		seen = append(seen, ids...)
+		if len(pages) == 1 {
+			s.CreateEmployee("Dan Brown", "Manager", usd("90000"))
+		}
+		next = linkRel(recorder.Header().Get("Link"), "next")
+	}
+	if want := []int{2, 3, 5, 4, 1}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("forward walk returned %v, want %v", seen, want)
+	}
+
+	// Stepping back from the last page returns the page before it.
+	recorder, _ := get(pages[len(pages)-1])
+	prev := linkRel(recorder.Header().Get("Link"), "prev")
+	if prev == "" {
+		t.Fatalf("last page has no prev link")
+	}
+	if _, ids := get(prev); !reflect.DeepEqual(ids, []int{5, 4}) {
+		t.Errorf("prev page returned %v, want [5 4]", ids)
+	}
+
+	t.Run("Tampered cursor", func(t *testing.T) {
+		token := pages[1][strings.Index(pages[1], "cursor=")+len("cursor="):]
+		recorder, _ := get("/employees?size=2&cursor=x" + token)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("tampered cursor returned %v, want %v", recorder.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Cursor with different sort", func(t *testing.T) {
+		token := pages[1][strings.Index(pages[1], "cursor=")+len("cursor="):]
+		recorder, _ := get("/employees?size=2&sort=name&cursor=" + token)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("mismatched sort returned %v, want %v", recorder.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// linkRel extracts the target of the given relation from a Link header.
+func linkRel(header, rel string) string {
+	for _, link := range strings.Split(header, ", ") {
+		target, params, ok := strings.Cut(link, ">; ")
+		if ok && params == `rel="`+rel+`"` {
+			return strings.TrimPrefix(target, "<")
+		}
+	}
+	return ""
+}
+
+func TestListEmployeesHandlerEnvelope(t *testing.T) {
+	// Initialize some employees for testing
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	h := NewEmployeeHandler(s)
+
+	req, err := http.NewRequest("GET", "/employees?page=2&size=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+
+	var page models.EmployeePage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatalf("error unmarshalling response body: %v", err)
+	}
+	want := models.PageLinks{
+		Self:  "/employees?page=2&size=1",
+		First: "/employees?page=1&size=1",
+		Prev:  "/employees?page=1&size=1",
+		Next:  "/employees?page=3&size=1",
+		Last:  "/employees?page=3&size=1",
+	}
+	if page.Links != want {
+		t.Errorf("handler returned links %+v, want %+v", page.Links, want)
+	}
+	if page.Page != 2 || page.Size != 1 || page.Total != 3 || page.TotalPages != 3 {
+		t.Errorf("handler returned unexpected metadata: %+v", page)
+	}
+	if got := linkRel(recorder.Header().Get("Link"), "next"); got != want.Next {
+		t.Errorf("Link header next = %q, want %q", got, want.Next)
+	}
+
+	t.Run("Empty store", func(t *testing.T) {
+		h := NewEmployeeHandler(store.NewMemoryStore())
+		req, err := http.NewRequest("GET", "/employees?page=1&size=10", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", recorder.Code, http.StatusOK)
+		}
+		if body := responseText(recorder); !strings.Contains(body, `"items":[]`) {
+			t.Errorf("handler returned %s, want an empty items array", body)
+		}
+	})
+}
+
+func TestListEmployeesHandlerFilter(t *testing.T) {
+	// Initialize some employees for testing
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Alan Turing", "Developer", usd("90000"))
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name         string
+		filter       string
+		expectedCode int
+		expectedIDs  []int
+		expectedBody string
+	}{
+		{
+			name:         "Developers over 70k",
+			filter:       `position eq "Developer" and salary gt 70000`,
+			expectedCode: http.StatusOK,
+			expectedIDs:  []int{3},
+		},
+		{
+			name:         "Malformed filter",
+			filter:       `salary gt`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid filter: expected a value but found end of input at position 9",
+		},
+		{
+			name:         "Unknown field",
+			filter:       `age gt 30`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `Invalid filter: unknown field "age" at position 0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/employees?page=1&size=10&filter=" + url.QueryEscape(tt.filter)
+			req, err := http.NewRequest("GET", target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedCode)
+			}
+			if recorder.Code != http.StatusOK {
+				if body := responseText(recorder); body != tt.expectedBody {
+					t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+				}
+				return
+			}
+
+			var page models.EmployeePage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+				t.Fatalf("error unmarshalling response body: %v", err)
+			}
+			var ids []int
+			for _, e := range page.Items {
+				ids = append(ids, e.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) || page.Total != len(tt.expectedIDs) {
+				t.Errorf("handler returned %v (total %v), want %v", ids, page.Total, tt.expectedIDs)
+			}
+		})
+	}
+}
+
+func TestSalaryNeedsOneCurrency(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Jane Roe", "Designer", models.MustParseMoney("90000", "EUR"))
+	h := NewEmployeeHandler(s)
+	const mixed = `Salaries are in more than one currency: filter on one, as in currency eq "USD", to compare or sort by salary`
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		target       string
+		expectedCode int
+		expectedBody string
+		expectedIDs  []int
+	}{
+		{"Filter", h.ListEmployeesHandler, "/employees?page=1&size=10&filter=salary+gt+70000", http.StatusBadRequest, mixed, nil},
+		{"Filter under or", h.ListEmployeesHandler, "/employees?page=1&size=10&filter=" + url.QueryEscape(`currency eq "USD" or salary gt 70000`), http.StatusBadRequest, mixed, nil},
+		{"Sort", h.ListEmployeesHandler, "/employees?size=10&sort=-salary", http.StatusBadRequest, mixed, nil},
+		{"Report", h.SalaryReportHandler, "/reports/salaries?filter=salary+lt+100000", http.StatusBadRequest, mixed, nil},
+		{"Export", h.ExportEmployeesHandler, "/employees:export?format=csv&filter=salary+lt+100000", http.StatusBadRequest, mixed, nil},
+		{"Filter in one currency", h.ListEmployeesHandler, "/employees?page=1&size=10&filter=" + url.QueryEscape(`salary gt 70000 and currency eq "USD"`), http.StatusOK, "", []int{1}},
+		{"Sort in one currency", h.ListEmployeesHandler, "/employees?size=10&sort=salary&filter=" + url.QueryEscape(`currency eq "USD"`), http.StatusOK, "", []int{2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			tt.handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, tt.expectedCode, responseText(recorder))
+			}
+			if tt.expectedIDs == nil {
+				if body := responseText(recorder); body != tt.expectedBody {
+					t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+				}
+				return
+			}
+			var page models.EmployeePage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, e := range page.Items {
+				ids = append(ids, e.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("handler returned employees %v, want %v", ids, tt.expectedIDs)
+			}
+		})
+	}
+}
+
+func TestSalaryInOneCurrencyNeedsNoCurrencyFilter(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	h := NewEmployeeHandler(s)
+
+	req, err := http.NewRequest("GET", "/employees?page=1&size=10&sort=-salary&filter=salary+gt+50000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+	}
+	var page models.EmployeePage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Name != "Alice Smith" {
+		t.Errorf("handler returned employees %+v, want both by salary descending", page.Items)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"bytes"
+	"ems/codec"
+	"net/http"
+	"strings"
+)
+
+// negotiate picks the codec for the response from the Accept header. It
+// reports false after writing the 406 response.
+func (h *EmployeeHandler) negotiate(w http.ResponseWriter, r *http.Request) (codec.Codec, bool) {
+	w.Header().Add("Vary", "Accept")
+	c, ok := h.codecs.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		writeError(w, r, newProblem(problemNotAcceptable,
+			"Supported types are "+strings.Join(h.codecs.MediaTypes(), ", ")))
+		return nil, false
+	}
+	return c, true
+}
+
+// writeEncoded sends v with c. It encodes into a buffer first, so a value
+// the codec cannot handle still gets a proper error response.
+func writeEncoded(w http.ResponseWriter, r *http.Request, c codec.Codec, status int, v any) {
+	var buf bytes.Buffer
+	if err := c.Encode(&buf, v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", c.MediaType())
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"ems/codec"
+	"ems/models"
+	"ems/patch"
+	"ems/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	tests := []struct {
+		name                string
+		method              string
+		target              string
+		accept              string
+		contentType         string
+		payload             string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "Get as XML",
+			method:              "GET",
+			target:              "/employees/1",
+			accept:              "application/xml",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<employee><id>1</id><name>John Doe</name><position>Developer</position><salary><amount>60000</amount><currency>USD</currency></salary></employee>`,
+		},
+		{
+			name:                "Get prefers the client's higher quality",
+			method:              "GET",
+			target:              "/employees/1",
+			accept:              "application/json;q=0.5, text/csv",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "id,name,position,salary,currency,department_id,manager_id\n1,John Doe,Developer,60000,USD,0,0",
+		},
+		{
+			name:         "Get in an unsupported type",
+			method:       "GET",
+			target:       "/employees/1",
+			accept:       "text/html",
+			expectedCode: http.StatusNotAcceptable,
+			expectedBody: "Supported types are application/json, application/xml, text/csv, application/msgpack",
+		},
+		{
+			name:                "List as CSV",
+			method:              "GET",
+			target:              "/employees?page=1&size=10",
+			accept:              "text/csv",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "id,name,position,salary,currency,department_id,manager_id\n1,John Doe,Developer,60000,USD,0,0",
+		},
+		{
+			name:                "Create from XML",
+			method:              "POST",
+			target:              "/employees",
+			contentType:         "application/xml; charset=utf-8",
+			payload:             `<employee><name>Alice Smith</name><position>Manager</position><salary>80000</salary></employee>`,
+			expectedCode:        http.StatusCreated,
+			expectedContentType: "application/json",
+			expectedBody:        `{"id":2,"name":"Alice Smith","position":"Manager","salary":{"amount":"80000","currency":"USD"}}`,
+		},
+		{
+			name:         "Create from CSV breaking the rules",
+			method:       "POST",
+			target:       "/employees",
+			contentType:  "text/csv",
+			payload:      "name,position,salary\n,Manager,80000\n",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "name is required",
+		},
+		{
+			name:         "Create from malformed XML",
+			method:       "POST",
+			target:       "/employees",
+			contentType:  "application/xml",
+			payload:      `<employee><salary>lots</salary></employee>`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request payload",
+		},
+		{
+			name:         "Create from an unsupported type",
+			method:       "POST",
+			target:       "/employees",
+			contentType:  "text/plain",
+			payload:      `{"name":"Alice Smith","position":"Manager","salary":80000}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedBody: "Supported types are application/json, application/xml, text/csv, application/msgpack",
+		},
+		{
+			name:         "Create refuses before writing when nothing is acceptable",
+			method:       "POST",
+			target:       "/employees",
+			accept:       "text/html",
+			payload:      `{"name":"Alice Smith","position":"Manager","salary":80000}`,
+			expectedCode: http.StatusNotAcceptable,
+			expectedBody: "Supported types are application/json, application/xml, text/csv, application/msgpack",
+		},
+		{
+			name:                "Update from CSV, answered in XML",
+			method:              "PUT",
+			target:              "/employees/1",
+			accept:              "application/xml",
+			contentType:         "text/csv",
+			payload:             "salary,name,position\n65000,John Doe,Senior Developer\n",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<employee><id>1</id><name>John Doe</name><position>Senior Developer</position><salary><amount>65000</amount><currency>USD</currency></salary></employee>`,
+		}, {
+			name:                "Patch answered in XML",
+			method:              "PATCH",
+			target:              "/employees/1",
+			accept:              "application/xml",
+			contentType:         patch.MergePatchType,
+			payload:             `{"salary":65000}`,
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<employee><id>1</id><name>John Doe</name><position>Developer</position><salary><amount>65000</amount><currency>USD</currency></salary></employee>`,
+		},
+		{
+			name:         "Patch refuses before writing when nothing is acceptable",
+			method:       "PATCH",
+			target:       "/employees/1",
+			accept:       "text/html",
+			contentType:  patch.MergePatchType,
+			payload:      `{"salary":65000}`,
+			expectedCode: http.StatusNotAcceptable,
+			expectedBody: "Supported types are application/json, application/xml, text/csv, application/msgpack",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest(tt.method, tt.target, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			handler := map[string]http.HandlerFunc{
+				"GET /employees/1":              h.GetEmployeeHandler,
+				"GET /employees?page=1&size=10": h.ListEmployeesHandler,
+				"POST /employees":               h.CreateEmployeeHandler,
+				"PUT /employees/1":              h.UpdateEmployeeHandler,
+				"PATCH /employees/1":            h.PatchEmployeeHandler,
+			}[tt.method+" "+tt.target]
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedCode)
+			}
+			if tt.expectedContentType != "" {
+				if contentType := recorder.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+					t.Errorf("handler returned wrong Content-Type: got %v want %v", contentType, tt.expectedContentType)
+				}
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %q want %q", body, tt.expectedBody)
+			}
+			if tt.expectedCode != http.StatusOK && tt.method == "PATCH" {
+				if employee, _ := s.GetEmployeeByID(1); employee.Salary != usd("60000") {
+					t.Errorf("refused patch changed the employee: %+v", employee)
+				}
+			}
+		})
+	}
+}
+
+func TestContentNegotiationMessagePack(t *testing.T) {
+	s := store.NewMemoryStore()
+	h := NewEmployeeHandler(s)
+	want := models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000")}
+
+	var body bytes.Buffer
+	if err := (codec.MessagePack{}).Encode(&body, want); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/employees", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set("Accept", "application/msgpack")
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.CreateEmployeeHandler).ServeHTTP(recorder, req)
+
+	if status := recorder.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var got models.Employee
+	if err := (codec.MessagePack{}).Decode(recorder.Body, &got); err != nil {
+		t.Fatalf("response is not MessagePack: %v", err)
+	}
+	want.ID = 1
+	if got != want {
+		t.Errorf("handler returned %+v, want %+v", got, want)
+	}
+}
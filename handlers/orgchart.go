@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"ems/orgchart"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// queryID reads an optional positive id from query parameter name. It is 0
+// when the parameter is absent.
+func queryID(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return 0, badRequest("Invalid " + name + ": must be a positive integer")
+	}
+	return id, nil
+}
+
+// OrgChartHandler serves GET /orgchart, which draws the reporting tree as
+// svg (the default), dot or mermaid. root limits the chart to one employee
+// and everyone below them, and department to one department's members.
+func (h *EmployeeHandler) OrgChartHandler(w http.ResponseWriter, r *http.Request) {
+	formatName := r.URL.Query().Get("format")
+	if formatName == "" {
+		formatName = string(orgchart.SVG)
+	}
+	format, ok := orgchart.FormatOf(formatName)
+	if !ok {
+		writeError(w, r, badRequest("Invalid format: must be svg, dot or mermaid"))
+		return
+	}
+	var opts orgchart.Options
+	var err error
+	if opts.RootID, err = queryID(r, "root"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if opts.DepartmentID, err = queryID(r, "department"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if opts.RootID != 0 && opts.DepartmentID != 0 {
+		writeError(w, r, badRequest("Invalid parameters: root and department cannot be combined"))
+		return
+	}
+
+	chart, err := orgchart.Build(h.store, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.MediaType())
+	w.WriteHeader(http.StatusOK)
+	if err := orgchart.Render(w, chart, format); err != nil {
+		log.Printf("%s %s: org chart failed: %v", r.Method, r.URL.RequestURI(), err)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOrgChartHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		expectedCode int
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name:         "DOT",
+			target:       "/orgchart?format=dot",
+			expectedCode: http.StatusOK,
+			expectedType: "text/vnd.graphviz; charset=utf-8",
+			expectedBody: "digraph orgchart {\n\tnode [shape=box];\n\te1 [label=\"Carol King\\nCEO\"];\n\te2 [label=\"Alice Smith\\nManager\"];\n\te1 -> e2;\n\te3 [label=\"John Doe\\nDeveloper\"];\n\te2 -> e3;\n}",
+		},
+		{
+			name:         "Mermaid rooted at an employee",
+			target:       "/orgchart?format=mermaid&root=2",
+			expectedCode: http.StatusOK,
+			expectedType: "text/plain; charset=utf-8",
+			expectedBody: "graph TD\n\te2[\"Alice Smith<br/>Manager\"]\n\te3[\"John Doe<br/>Developer\"]\n\te2 --> e3",
+		},
+		{
+			name:         "Mermaid for a department",
+			target:       "/orgchart?format=mermaid&department=1",
+			expectedCode: http.StatusOK,
+			expectedType: "text/plain; charset=utf-8",
+			expectedBody: "graph TD\n\te3[\"John Doe<br/>Developer\"]",
+		},
+		{
+			name:         "Unknown format",
+			target:       "/orgchart?format=png",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid format: must be svg, dot or mermaid",
+		},
+		{
+			name:         "Invalid root",
+			target:       "/orgchart?root=abc",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid root: must be a positive integer",
+		},
+		{
+			name:         "Root and department",
+			target:       "/orgchart?root=1&department=1",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid parameters: root and department cannot be combined",
+		},
+		{
+			name:         "Missing root",
+			target:       "/orgchart?root=9",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "employee 9 not found",
+		},
+		{
+			name:         "Missing department",
+			target:       "/orgchart?department=9",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "department 9 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateDepartment("Engineering")
+			s.CreateEmployee("Carol King", "CEO", usd("200000"))
+			s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), ManagerID: 1})
+			s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), ManagerID: 2, DepartmentID: 1})
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			h.OrgChartHandler(recorder, req)
+
+			if status := recorder.Code; status != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedCode)
+			}
+			if tt.expectedType != "" {
+				if contentType := recorder.Header().Get("Content-Type"); contentType != tt.expectedType {
+					t.Errorf("handler returned wrong content type: got %q want %q", contentType, tt.expectedType)
+				}
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %q want %q", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestOrgChartHandlerSVG(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateEmployee("Carol King", "CEO", usd("200000"))
+	h := NewEmployeeHandler(s)
+
+	req := httptest.NewRequest("GET", "/orgchart", nil)
+	recorder := httptest.NewRecorder()
+	h.OrgChartHandler(recorder, req)
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "image/svg+xml" {
+		t.Errorf("handler returned wrong content type: got %q want image/svg+xml", contentType)
+	}
+	if body := recorder.Body.String(); !strings.HasPrefix(body, "<svg ") || !strings.Contains(body, ">Carol King</text>") {
+		t.Errorf("handler returned unexpected body: %s", body)
+	}
+}
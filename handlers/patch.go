@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/patch"
+	"ems/store"
+	"ems/validation"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// acceptPatch lists the patch formats PatchEmployeeHandler understands, for
+// the Accept-Patch header (RFC 5789).
+var acceptPatch = strings.Join([]string{patch.MergePatchType, patch.JSONPatchType}, ", ")
+
+// PatchEmployeeHandler applies a JSON Merge Patch or JSON Patch document to
+// an employee. The patched employee must pass the same checks as a created
+// one, and the read, If-Match check, patch and write happen atomically in
+// the store.
+func (h *EmployeeHandler) PatchEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Path[len("/employees/"):]
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 1 {
+		writeError(w, r, badRequest("Invalid employee ID"))
+		return
+	}
+	if !h.requireIfMatch(w, r) {
+		return
+	}
+	c, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var parse func([]byte) (patch.Patch, error)
+	switch {
+	case err != nil:
+	case mediaType == patch.MergePatchType:
+		parse = patch.ParseMergePatch
+	case mediaType == patch.JSONPatchType:
+		parse = patch.ParseJSONPatch
+	}
+	if parse == nil {
+		w.Header().Set("Accept-Patch", acceptPatch)
+		writeError(w, r, newProblem(problemUnsupportedMediaType, "Unsupported patch format"))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, badRequest("Invalid request payload"))
+		return
+	}
+	p, err := parse(body)
+	if err != nil {
+		writeError(w, r, badRequest("Invalid patch document: "+err.Error()))
+		return
+	}
+
+	patchedEmployee, err := h.store.PatchEmployee(id, func(current models.Employee) (models.Employee, error) {
+		if err := checkIfMatch(r, current); err != nil {
+			return models.Employee{}, err
+		}
+		return applyPatch(p, current)
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(patchedEmployee, c, nil))
+	writeEncoded(w, r, c, http.StatusOK, patchedEmployee)
+}
+
+// applyPatch runs p over the JSON form of current and decodes the result
+// back into an employee.
+func applyPatch(p patch.Patch, current models.Employee) (models.Employee, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return models.Employee{}, err
+	}
+	patched, err := p.Apply(doc)
+	if errors.Is(err, patch.ErrTestFailed) {
+		return models.Employee{}, &store.ConflictError{Msg: "Patch test failed: " + err.Error()}
+	}
+	if err != nil {
+		return models.Employee{}, newProblem(problemUnprocessable, "Patch could not be applied: "+err.Error())
+	}
+
+	employee, err := validation.DecodeEmployee(patched)
+	var errs validation.Errors
+	if err != nil && !errors.As(err, &errs) {
+		return models.Employee{}, err
+	}
+	if employee.ID != current.ID {
+		errs = append(errs, validation.Violation{Field: "id", Code: validation.CodeReadOnly, Message: "id cannot be changed"})
+	}
+	if len(errs) > 0 {
+		return models.Employee{}, errs
+	}
+	return employee, nil
+}
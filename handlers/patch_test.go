@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/patch"
+	"ems/store"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPatchEmployeeHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           int
+		contentType  string
+		payload      string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Merge patch: raise salary",
+			id:           1,
+			contentType:  patch.MergePatchType,
+			payload:      `{"salary":65000}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":1,"name":"John Doe","position":"Developer","salary":{"amount":"65000","currency":"USD"}}`,
+		},
+		{
+			name:         "Merge patch with charset parameter",
+			id:           1,
+			contentType:  patch.MergePatchType + "; charset=utf-8",
+			payload:      `{"position":"Senior Developer"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":1,"name":"John Doe","position":"Senior Developer","salary":{"amount":"60000","currency":"USD"}}`,
+		},
+		{
+			name:         "Merge patch: removing a required field",
+			id:           1,
+			contentType:  patch.MergePatchType,
+			payload:      `{"name":null}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "name is required",
+		},
+		{
+			name:         "Merge patch: negative salary",
+			id:           1,
+			contentType:  patch.MergePatchType,
+			payload:      `{"salary":-1}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "salary must be greater than 0",
+		},
+		{
+			name:         "Merge patch: unknown field",
+			id:           1,
+			contentType:  patch.MergePatchType,
+			payload:      `{"nickname":"JD"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "unknown field nickname",
+		},
+		{
+			name:         "Merge patch: changing the ID",
+			id:           1,
+			contentType:  patch.MergePatchType,
+			payload:      `{"id":7}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "id cannot be changed",
+		},
+		{
+			name:         "JSON patch: test and replace",
+			id:           2,
+			contentType:  patch.JSONPatchType,
+			payload:      `[{"op":"test","path":"/salary/amount","value":"80000"},{"op":"replace","path":"/salary","value":88000}]`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":2,"name":"Alice Smith","position":"Manager","salary":{"amount":"88000","currency":"USD"}}`,
+		},
+		{
+			name:         "JSON patch: failed test",
+			id:           2,
+			contentType:  patch.JSONPatchType,
+			payload:      `[{"op":"test","path":"/salary","value":1},{"op":"replace","path":"/salary","value":99000}]`,
+			expectedCode: http.StatusConflict,
+			expectedBody: "Patch test failed: operation 0 (test): test failed: value at /salary differs",
+		},
+		{
+			name:         "JSON patch: missing path",
+			id:           2,
+			contentType:  patch.JSONPatchType,
+			payload:      `[{"op":"remove","path":"/department"}]`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: "Patch could not be applied: operation 0 (remove): /department does not exist",
+		},
+		{
+			name:         "JSON patch: malformed operation",
+			id:           2,
+			contentType:  patch.JSONPatchType,
+			payload:      `[{"op":"replace","path":"/salary"}]`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid patch document: operation 0 (replace): missing value",
+		},
+		{
+			name:         "Unsupported content type",
+			id:           1,
+			contentType:  "application/json",
+			payload:      `{"salary":65000}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedBody: "Unsupported patch format",
+		},
+		{
+			name:         "Non-existent employee",
+			id:           80,
+			contentType:  patch.MergePatchType,
+			payload:      `{"salary":65000}`,
+			expectedCode: http.StatusNotFound,
+			expectedBody: "employee 80 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("PATCH", "/employees/"+strconv.Itoa(tt.id), strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+			recorder := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.PatchEmployeeHandler)
+
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedCode)
+			}
+			body := responseText(recorder)
+			if body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v",
+					body, tt.expectedBody)
+			}
+
+			if recorder.Code == http.StatusUnsupportedMediaType && recorder.Header().Get("Accept-Patch") == "" {
+				t.Errorf("handler did not advertise Accept-Patch")
+			}
+			// A rejected patch must leave the employee untouched.
+			if recorder.Code != http.StatusOK && tt.id <= 2 {
+				before := map[int]models.Money{1: usd("60000"), 2: usd("80000")}[tt.id]
+				if employee, _ := s.GetEmployeeByID(tt.id); employee.Salary != before {
+					t.Errorf("rejected patch changed the employee: %+v", employee)
+				}
+			}
+		})
+	}
+}
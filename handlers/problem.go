@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"ems/store"
+	"ems/validation"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Problem is an RFC 7807 problem details object, the body of every error
+// response.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors lists the individual violations of a validation problem.
+	Errors validation.Errors `json:"errors,omitempty"`
+	// Operations lists the outcome of every operation of a rolled-back
+	// batch.
+	Operations []batchItem `json:"operations,omitempty"`
+}
+
+// problemType is one kind of problem. Its URI is relative to the server, so
+// clients can switch on it without string-matching titles or details.
+type problemType struct {
+	uri    string
+	title  string
+	status int
+}
+
+var (
+	problemInvalidRequest       = problemType{"/problems/invalid-request", "Invalid request", http.StatusBadRequest}
+	problemValidation           = problemType{"/problems/validation-error", "Invalid data", http.StatusBadRequest}
+	problemNotFound             = problemType{"/problems/not-found", "Not found", http.StatusNotFound}
+	problemMethodNotAllowed     = problemType{"/problems/method-not-allowed", "Method not allowed", http.StatusMethodNotAllowed}
+	problemNotAcceptable        = problemType{"/problems/not-acceptable", "Not acceptable", http.StatusNotAcceptable}
+	problemConflict             = problemType{"/problems/conflict", "Conflict", http.StatusConflict}
+	problemPreconditionFailed   = problemType{"/problems/precondition-failed", "Precondition failed", http.StatusPreconditionFailed}
+	problemTooLarge             = problemType{"/problems/too-large", "Request body too large", http.StatusRequestEntityTooLarge}
+	problemUnsupportedMediaType = problemType{"/problems/unsupported-media-type", "Unsupported media type", http.StatusUnsupportedMediaType}
+	problemUnprocessable        = problemType{"/problems/unprocessable", "Request could not be applied", http.StatusUnprocessableEntity}
+	problemPreconditionRequired = problemType{"/problems/precondition-required", "Precondition required", http.StatusPreconditionRequired}
+	problemBatchFailed          = problemType{"/problems/batch-failed", "Batch was not applied", http.StatusUnprocessableEntity}
+	problemBatchAborted         = problemType{"/problems/batch-aborted", "Operation was not applied", http.StatusFailedDependency}
+	problemInternal             = problemType{"/problems/internal-error", "Internal server error", http.StatusInternalServerError}
+)
+
+// requestError is an error a handler raises itself, carrying the problem
+// type to report it as.
+type requestError struct {
+	kind   problemType
+	detail string
+}
+
+func (e *requestError) Error() string { return e.detail }
+
+func newProblem(kind problemType, detail string) error {
+	return &requestError{kind: kind, detail: detail}
+}
+
+func badRequest(detail string) error {
+	return newProblem(problemInvalidRequest, detail)
+}
+
+// writeError maps err to a problem and writes it.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, problemFor(r, err))
+}
+
+// problemFor maps err to a problem about r. Store errors get their matching
+// problem type; anything unrecognised is logged and reported as a 500
+// without leaking its text.
+func problemFor(r *http.Request, err error) Problem {
+	var (
+		requestErr    *requestError
+		notFoundErr   *store.NotFoundError
+		conflictErr   *store.ConflictError
+		validationErr *store.ValidationError
+		violations    validation.Errors
+	)
+	p := Problem{Instance: r.URL.RequestURI()}
+	switch {
+	case errors.As(err, &requestErr):
+		p.setType(requestErr.kind)
+		p.Detail = requestErr.detail
+	case errors.As(err, &notFoundErr):
+		p.setType(problemNotFound)
+		p.Detail = notFoundErr.Error()
+	case errors.As(err, &conflictErr):
+		p.setType(problemConflict)
+		p.Detail = conflictErr.Error()
+	case errors.As(err, &validationErr):
+		p.setViolations(validationErr.Errors)
+	case errors.As(err, &violations):
+		p.setViolations(violations)
+	case errors.Is(err, store.ErrBatchAborted):
+		p.setType(problemBatchAborted)
+		p.Detail = err.Error()
+	default:
+		log.Printf("%s %s: %v", r.Method, r.URL.RequestURI(), err)
+		p.setType(problemInternal)
+	}
+	return p
+}
+
+func (p *Problem) setType(kind problemType) {
+	p.Type, p.Title, p.Status = kind.uri, kind.title, kind.status
+}
+
+func (p *Problem) setViolations(violations validation.Errors) {
+	p.setType(problemValidation)
+	p.Detail = violations.Error()
+	p.Errors = violations
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// NotFoundHandler reports requests that match no route as a problem.
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, newProblem(problemNotFound, "No route for "+r.URL.Path))
+}
+
+// MethodNotAllowedHandler reports requests whose path matches a route but
+// whose method does not as a problem.
+func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, newProblem(problemMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path))
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/store"
+	"ems/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// usd is amount in US dollars.
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+// responseText is the detail of a problem response, or the trimmed body of
+// any other response.
+func responseText(recorder *httptest.ResponseRecorder) string {
+	if recorder.Header().Get("Content-Type") != "application/problem+json" {
+		return strings.TrimSpace(recorder.Body.String())
+	}
+	var p Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &p); err != nil {
+		return "undecodable problem: " + recorder.Body.String()
+	}
+	return p.Detail
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedType   string
+		expectedDetail string
+		expectedErrors int
+	}{
+		{
+			name:           "Not found",
+			err:            &store.NotFoundError{ID: 7},
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "/problems/not-found",
+			expectedDetail: "employee 7 not found",
+		},
+		{
+			name:           "Wrapped conflict",
+			err:            errors.Join(errors.New("context"), &store.ConflictError{Msg: "stale"}),
+			expectedStatus: http.StatusConflict,
+			expectedType:   "/problems/conflict",
+			expectedDetail: "stale",
+		},
+		{
+			name: "Store validation",
+			err: &store.ValidationError{Errors: validation.Errors{
+				{Field: "name", Code: validation.CodeRequired, Message: "name is required"},
+				{Field: "salary", Code: validation.CodeTooSmall, Message: "salary must be greater than 0"},
+			}},
+			expectedStatus: http.StatusBadRequest,
+			expectedType:   "/problems/validation-error",
+			expectedDetail: "name is required; salary must be greater than 0",
+			expectedErrors: 2,
+		},
+		{
+			name:           "Handler problem",
+			err:            badRequest("Invalid limit"),
+			expectedStatus: http.StatusBadRequest,
+			expectedType:   "/problems/invalid-request",
+			expectedDetail: "Invalid limit",
+		},
+		{
+			name:           "Unexpected error is not leaked",
+			err:            errors.New("disk on fire"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedType:   "/problems/internal-error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/employees/7?x=1", nil)
+			recorder := httptest.NewRecorder()
+			writeError(recorder, req, tt.err)
+
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("writeError returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedStatus)
+			}
+			if ct := recorder.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("writeError returned Content-Type %q", ct)
+			}
+			var p Problem
+			if err := json.Unmarshal(recorder.Body.Bytes(), &p); err != nil {
+				t.Fatalf("error unmarshalling problem: %v", err)
+			}
+			if p.Type != tt.expectedType || p.Status != tt.expectedStatus || p.Detail != tt.expectedDetail ||
+				p.Instance != "/employees/7?x=1" || p.Title == "" || len(p.Errors) != tt.expectedErrors {
+				t.Errorf("writeError wrote %+v", p)
+			}
+		})
+	}
+}
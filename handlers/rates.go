@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/models"
+	"ems/rates"
+	"ems/validation"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRatesSize caps the body of a POST /admin/rates request.
+const maxRatesSize = 1 << 20
+
+// ratesBody is the body of the /admin/rates endpoints.
+type ratesBody struct {
+	Rates []models.ExchangeRate `json:"rates"`
+}
+
+// ListRatesHandler serves GET /admin/rates, every exchange rate in the
+// table by date.
+func (h *EmployeeHandler) ListRatesHandler(w http.ResponseWriter, r *http.Request) {
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, ratesBody{Rates: h.rates.Rates()})
+}
+
+// AddRatesHandler serves POST /admin/rates, which adds dated exchange rates
+// to the table, replacing any published on the same day for the same
+// currencies. The body is either JSON with a rates array or, with a
+// Content-Type of text/csv, a file in the format the table is kept in. The
+// response is the whole table. The router only serves it with
+// WithRatesAdmin set.
+func (h *EmployeeHandler) AddRatesHandler(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxRatesSize)
+	var added []models.ExchangeRate
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		var err error
+		added, err = rates.ReadCSV(body)
+		if err != nil {
+			writeError(w, r, ratesPayloadError(err, "Invalid rates file: "+err.Error()))
+			return
+		}
+	} else {
+		var payload ratesBody
+		decoder := json.NewDecoder(body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&payload); err != nil {
+			writeError(w, r, ratesPayloadError(err, "Invalid request payload"))
+			return
+		}
+		added = payload.Rates
+	}
+	if len(added) == 0 {
+		writeError(w, r, badRequest("No rates given"))
+		return
+	}
+
+	if err := h.rates.Add(added); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, ratesBody{Rates: h.rates.Rates()})
+}
+
+func ratesPayloadError(err error, detail string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return newProblem(problemTooLarge, "Rates are larger than "+strconv.Itoa(maxRatesSize)+" bytes")
+	}
+	return badRequest(detail)
+}
+
+// converter reads the currency and as_of query parameters into a converter
+// of salaries, or nil when no currency was asked for and fallback is empty.
+// as_of picks the day whose rates apply and defaults to today in UTC.
+func (h *EmployeeHandler) converter(r *http.Request, fallback string) (*rates.Converter, error) {
+	query := r.URL.Query()
+	currency := fallback
+	if query.Has("currency") {
+		currency = strings.ToUpper(strings.TrimSpace(query.Get("currency")))
+		if !validation.IsCurrency(currency) {
+			return nil, badRequest("Invalid currency: must be an ISO 4217 code such as USD")
+		}
+	}
+	if currency == "" {
+		return nil, nil
+	}
+	day := time.Now().UTC().Format(models.DateLayout)
+	if query.Has("as_of") {
+		day = query.Get("as_of")
+		if !validation.IsDate(day) {
+			return nil, badRequest("Invalid as_of date: must be a date such as 2024-01-31")
+		}
+	}
+	return h.rates.Converter(currency, day), nil
+}
+
+// convertSalaries converts the salary of every employee with conv. A
+// salary that cannot be converted fails the whole request, rather than
+// leaving a mix of currencies.
+func convertSalaries(conv *rates.Converter, employees []models.Employee) error {
+	for i := range employees {
+		salary, err := convertSalary(conv, employees[i].Salary)
+		if err != nil {
+			return err
+		}
+		employees[i].Salary = salary
+	}
+	return nil
+}
+
+func convertSalary(conv *rates.Converter, salary models.Money) (models.Money, error) {
+	converted, err := conv.Convert(salary)
+	if err != nil {
+		return models.Money{}, newProblem(problemUnprocessable, "Cannot convert salaries: "+err.Error())
+	}
+	return converted, nil
+}
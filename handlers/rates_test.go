@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/rates"
+	"ems/store"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRates are the rates newRatesTable starts with, from EUR and to JPY.
+var testRates = []models.ExchangeRate{
+	{Date: "2026-01-02", From: "EUR", To: "USD", Rate: "1.1"},
+	{Date: "2026-01-15", From: "USD", To: "JPY", Rate: "150"},
+	{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.2"},
+}
+
+func newRatesTable(t *testing.T) *rates.Table {
+	t.Helper()
+	table := rates.New()
+	if err := table.Add(testRates); err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+// newRatesStore returns a store paying salaries in three currencies:
+//
+//	1 Carol King   200000 USD
+//	2 Alice Smith   80000 USD    Sales
+//	3 John Doe      60000 USD    Sales
+//	4 Jane Roe    50000.5 EUR    Engineering
+//	5 Taro Yamada 9000000 JPY    Engineering
+func newRatesStore() *store.MemoryStore {
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Sales")
+	s.CreateDepartment("Engineering")
+	s.CreateDepartment("Legal")
+	s.InsertEmployee(models.Employee{Name: "Carol King", Position: "CEO", Salary: usd("200000")})
+	s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 1})
+	s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1})
+	s.InsertEmployee(models.Employee{Name: "Jane Roe", Position: "Designer", Salary: models.MustParseMoney("50000.5", "EUR"), DepartmentID: 2})
+	s.InsertEmployee(models.Employee{Name: "Taro Yamada", Position: "Tester", Salary: models.MustParseMoney("9000000", "JPY"), DepartmentID: 2})
+	return s
+}
+
+func TestListRatesHandler(t *testing.T) {
+	h := NewEmployeeHandler(store.NewMemoryStore(), WithRates(newRatesTable(t)))
+
+	req, err := http.NewRequest("GET", "/admin/rates", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.ListRatesHandler).ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, http.StatusOK)
+	}
+	expected := `{"rates":[{"date":"2026-01-02","from":"EUR","to":"USD","rate":"1.1"},` +
+		`{"date":"2026-01-15","from":"USD","to":"JPY","rate":"150"},` +
+		`{"date":"2026-02-02","from":"EUR","to":"USD","rate":"1.2"}]}`
+	if body := responseText(recorder); body != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", body, expected)
+	}
+}
+
+func TestAddRatesHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		payload      string
+		expectedCode int
+		expectedBody string
+		expected     []models.ExchangeRate
+	}{
+		{
+			name:         "JSON replacing a rate",
+			payload:      `{"rates":[{"date":"2026-02-02","from":"eur","to":"usd","rate":"1.25"},{"date":"2026-03-01","from":"GBP","to":"USD","rate":"1.3"}]}`,
+			expectedCode: http.StatusOK,
+			expected: []models.ExchangeRate{
+				testRates[0],
+				testRates[1],
+				{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.25"},
+				{Date: "2026-03-01", From: "GBP", To: "USD", Rate: "1.3"},
+			},
+		},
+		{
+			name:         "CSV",
+			contentType:  "text/csv; charset=utf-8",
+			payload:      "date,from,to,rate\n2026-03-01,GBP,USD,1.3\n",
+			expectedCode: http.StatusOK,
+			expected:     append(append([]models.ExchangeRate{}, testRates...), models.ExchangeRate{Date: "2026-03-01", From: "GBP", To: "USD", Rate: "1.3"}),
+		},
+		{
+			name:         "Invalid rate",
+			payload:      `{"rates":[{"date":"2026-03-01","from":"GBP","to":"USD","rate":"1.3"},{"date":"2026-03-01","from":"GBP","to":"XYZ","rate":"0"}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "rates[1].to must be an ISO 4217 currency code such as USD; rates[1].rate must be a positive decimal such as 1.0843",
+			expected:     testRates,
+		},
+		{
+			name:         "CSV without a rate column",
+			contentType:  "text/csv",
+			payload:      "date,from,to\n2026-03-01,GBP,USD\n",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `Invalid rates file: missing "rate" column`,
+			expected:     testRates,
+		},
+		{
+			name:         "No rates",
+			payload:      `{"rates":[]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "No rates given",
+			expected:     testRates,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := newRatesTable(t)
+			h := NewEmployeeHandler(store.NewMemoryStore(), WithRates(table))
+
+			req, err := http.NewRequest("POST", "/admin/rates", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.AddRatesHandler).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if tt.expectedCode == http.StatusOK {
+				var got ratesBody
+				if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil || !reflect.DeepEqual(got.Rates, tt.expected) {
+					t.Errorf("handler returned rates %+v (%v), want %+v", got.Rates, err, tt.expected)
+				}
+			} else if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+			if stored := table.Rates(); !reflect.DeepEqual(stored, tt.expected) {
+				t.Errorf("table rates after the request = %+v, want %+v", stored, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListInOneCurrency(t *testing.T) {
+	s := newRatesStore()
+	h := NewEmployeeHandler(s, WithRates(newRatesTable(t)))
+
+	tests := []struct {
+		name       string
+		target     string
+		salaries   []models.Money
+		conversion models.Conversion
+		next       string
+	}{
+		{
+			name:     "Latest rates",
+			target:   "/employees?page=1&size=10&currency=usd&as_of=2026-03-01",
+			salaries: []models.Money{usd("200000"), usd("80000"), usd("60000"), usd("60000.6"), usd("60000")},
+			conversion: models.Conversion{Currency: "USD", AsOf: "2026-03-01", Rates: []models.ExchangeRate{
+				{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.2"},
+				{Date: "2026-01-15", From: "JPY", To: "USD", Rate: "0.0066666667"},
+			}},
+		},
+		{
+			name:     "Earlier rates",
+			target:   "/employees?page=1&size=1&fields=salary&currency=EUR&as_of=2026-01-20",
+			salaries: []models.Money{models.MustParseMoney("181818.18", "EUR")},
+			conversion: models.Conversion{Currency: "EUR", AsOf: "2026-01-20", Rates: []models.ExchangeRate{
+				{Date: "2026-01-02", From: "USD", To: "EUR", Rate: "0.9090909091"},
+			}},
+			next: "/employees?as_of=2026-01-20&currency=EUR&fields=salary&page=2&size=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.ListEmployeesHandler).ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+			}
+
+			var page models.EmployeePage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			var salaries []models.Money
+			for _, e := range page.Items {
+				salaries = append(salaries, e.Salary)
+			}
+			if !reflect.DeepEqual(salaries, tt.salaries) {
+				t.Errorf("handler returned salaries %v, want %v", salaries, tt.salaries)
+			}
+			if page.Conversion == nil || !reflect.DeepEqual(*page.Conversion, tt.conversion) {
+				t.Errorf("handler returned conversion %+v, want %+v", page.Conversion, tt.conversion)
+			}
+			if page.Links.Next != tt.next {
+				t.Errorf("handler returned next link %q, want %q", page.Links.Next, tt.next)
+			}
+		})
+	}
+
+	// Converting a listing leaves the stored salaries alone.
+	if employee, _ := s.GetEmployeeByID(4); employee.Salary != models.MustParseMoney("50000.5", "EUR") {
+		t.Errorf("stored salary after a converted listing = %v, want it unconverted", employee.Salary)
+	}
+}
+
+func TestSalaryReportHandler(t *testing.T) {
+	h := NewEmployeeHandler(newRatesStore(), WithRates(newRatesTable(t)))
+
+	tests := []struct {
+		name   string
+		target string
+		want   models.SalaryReport
+	}{
+		{
+			name:   "In dollars",
+			target: "/reports/salaries?as_of=2026-03-01",
+			want: models.SalaryReport{
+				Currency:  "USD",
+				Headcount: 5,
+				Total:     usd("460000.6"),
+				Departments: []models.DepartmentSalaries{
+					{DepartmentID: 0, Headcount: 1, Total: usd("200000")},
+					{DepartmentID: 1, Headcount: 2, Total: usd("140000")},
+					{DepartmentID: 2, Headcount: 2, Total: usd("120000.6")},
+				},
+				Conversion: &models.Conversion{Currency: "USD", AsOf: "2026-03-01", Rates: []models.ExchangeRate{
+					{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.2"},
+					{Date: "2026-01-15", From: "JPY", To: "USD", Rate: "0.0066666667"},
+				}},
+			},
+		},
+		{
+			name:   "Filtered in yen",
+			target: "/reports/salaries?currency=JPY&as_of=2026-03-01&filter=department_id+eq+2",
+			want: models.SalaryReport{
+				Currency:  "JPY",
+				Headcount: 2,
+				Total:     models.MustParseMoney("18000090", "JPY"),
+				Departments: []models.DepartmentSalaries{
+					{DepartmentID: 2, Headcount: 2, Total: models.MustParseMoney("18000090", "JPY")},
+				},
+				Conversion: &models.Conversion{Currency: "JPY", AsOf: "2026-03-01", Rates: []models.ExchangeRate{
+					{Date: "2026-01-15", From: "EUR", To: "JPY", Rate: "180"},
+				}},
+			},
+		},
+		{
+			name:   "Empty",
+			target: "/reports/salaries?currency=EUR&as_of=2026-03-01&filter=department_id+eq+3",
+			want: models.SalaryReport{
+				Currency:    "EUR",
+				Total:       models.MustParseMoney("0", "EUR"),
+				Departments: []models.DepartmentSalaries{},
+				Conversion:  &models.Conversion{Currency: "EUR", AsOf: "2026-03-01", Rates: []models.ExchangeRate{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.SalaryReportHandler).ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+			}
+
+			var got models.SalaryReport
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("handler returned report %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConversionRejected(t *testing.T) {
+	today := time.Now().UTC().Format(models.DateLayout)
+	h := NewEmployeeHandler(newRatesStore(), WithRates(newRatesTable(t)))
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		target       string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "No rate in effect",
+			handler:      h.ListEmployeesHandler,
+			target:       "/employees?page=1&size=10&currency=USD&as_of=2026-01-10",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: "Cannot convert salaries: no exchange rate from JPY to USD on or before 2026-01-10",
+		},
+		{
+			name:         "Invalid currency",
+			handler:      h.ListEmployeesHandler,
+			target:       "/employees?page=1&size=10&currency=dollars",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid currency: must be an ISO 4217 code such as USD",
+		},
+		{
+			name:         "Invalid as_of",
+			handler:      h.ListEmployeesHandler,
+			target:       "/employees?page=1&size=10&currency=USD&as_of=yesterday",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid as_of date: must be a date such as 2024-01-31",
+		},
+		{
+			name:         "Report without a rate",
+			handler:      h.SalaryReportHandler,
+			target:       "/reports/salaries?currency=GBP",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: "Cannot convert salaries: no exchange rate from USD to GBP on or before " + today,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			tt.handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", recorder.Code, tt.expectedCode)
+			}
+			if body := responseText(recorder); body != tt.expectedBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestRatesAdminIsOffByDefault(t *testing.T) {
+	if NewEmployeeHandler(store.NewMemoryStore()).RatesAdmin() {
+		t.Errorf("RatesAdmin() = true without WithRatesAdmin, want POST /admin/rates off by default")
+	}
+	if !NewEmployeeHandler(store.NewMemoryStore(), WithRatesAdmin(true)).RatesAdmin() {
+		t.Errorf("RatesAdmin() = false with WithRatesAdmin(true)")
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"ems/codec"
+	"ems/filter"
+	"ems/models"
+	"net/http"
+	"slices"
+)
+
+// SalaryReportHandler serves GET /reports/salaries, the total salary and
+// headcount of the employees matching the optional filter, overall and by
+// department. Salaries are converted to currency, models.DefaultCurrency
+// unless given, with the rates in effect on as_of, and the report records
+// the rates used. Each salary is converted and rounded before it is added,
+// so the totals match a converted listing.
+func (h *EmployeeHandler) SalaryReportHandler(w http.ResponseWriter, r *http.Request) {
+	conv, err := h.converter(r, models.DefaultCurrency)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	where, err := filter.Parse(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeError(w, r, badRequest("Invalid filter: "+err.Error()))
+		return
+	}
+	if err := h.checkCurrencies(where, nil); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	zero := models.NewMoney(0, conv.Currency())
+	report := models.SalaryReport{Currency: zero.Currency(), Total: zero}
+	departments := map[int]*models.DepartmentSalaries{}
+	err = h.store.ExportEmployees(where, func(e models.Employee) error {
+		salary, err := convertSalary(conv, e.Salary)
+		if err != nil {
+			return err
+		}
+		department, ok := departments[e.DepartmentID]
+		if !ok {
+			department = &models.DepartmentSalaries{DepartmentID: e.DepartmentID, Total: zero}
+			departments[e.DepartmentID] = department
+		}
+		if department.Total, err = department.Total.Add(salary); err != nil {
+			return err
+		}
+		if report.Total, err = report.Total.Add(salary); err != nil {
+			return err
+		}
+		department.Headcount++
+		report.Headcount++
+		return nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	report.Departments = []models.DepartmentSalaries{}
+	for _, department := range departments {
+		report.Departments = append(report.Departments, *department)
+	}
+	slices.SortFunc(report.Departments, func(a, b models.DepartmentSalaries) int {
+		return a.DepartmentID - b.DepartmentID
+	})
+	report.Conversion = conv.Conversion()
+	writeEncoded(w, r, codec.JSON{}, http.StatusOK, report)
+}
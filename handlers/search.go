@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"ems/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultSearchLimit = 20
+
+// SearchEmployeesHandler serves GET /employees/search?q=, returning employees
+// ranked by how well their name and position match the query.
+func (h *EmployeeHandler) SearchEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		writeError(w, r, badRequest("Missing search query"))
+		return
+	}
+
+	limit := defaultSearchLimit
+	if query.Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 {
+			writeError(w, r, badRequest("Invalid limit"))
+			return
+		}
+	}
+
+	results, err := h.store.SearchEmployees(q, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if results == nil {
+		results = []models.EmployeeSearchResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
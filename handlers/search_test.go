@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"ems/models"
+	"ems/store"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearchEmployeesHandler(t *testing.T) {
+	// Initialize some employees for testing
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	h := NewEmployeeHandler(s)
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedCount int
+		expectedFirst int
+		expectedBody  string
+	}{
+		{
+			name:          "Misspelled name",
+			query:         "q=jonson",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+			expectedFirst: 3,
+		},
+		{
+			name:          "Prefix with limit",
+			query:         "q=jo&limit=1",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+			expectedFirst: 1,
+		},
+		{
+			name:          "No matches",
+			query:         "q=zzzzzz",
+			expectedCode:  http.StatusOK,
+			expectedCount: 0,
+		},
+		{
+			name:         "Missing query",
+			query:        "q=",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Missing search query",
+		},
+		{
+			name:         "Invalid limit",
+			query:        "q=john&limit=0",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/employees/search?"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.SearchEmployeesHandler)
+
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v",
+					recorder.Code, tt.expectedCode)
+			}
+
+			if recorder.Code != http.StatusOK {
+				body := responseText(recorder)
+				if body != tt.expectedBody {
+					t.Errorf("handler returned unexpected body: got %v want %v",
+						body, tt.expectedBody)
+				}
+				return
+			}
+
+			var results []models.EmployeeSearchResult
+			if err := json.Unmarshal(recorder.Body.Bytes(), &results); err != nil {
+				t.Fatalf("error unmarshalling response body: %v", err)
+			}
+			if len(results) != tt.expectedCount {
+				t.Fatalf("handler returned %v results, want %v", len(results), tt.expectedCount)
+			}
+			if tt.expectedCount > 0 && results[0].ID != tt.expectedFirst {
+				t.Errorf("handler ranked employee %v first, want %v", results[0].ID, tt.expectedFirst)
+			}
+		})
+	}
+}
@@ -2,37 +2,47 @@ package handlers
 
 import (
 	"ems/models"
-	"ems/store"
-	"encoding/json"
 	"net/http"
 	"strconv"
 )
 
-func UpdateEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *EmployeeHandler) UpdateEmployeeHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Path[len("/employees/"):]
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id < 1 {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeError(w, r, badRequest("Invalid employee ID"))
 		return
 	}
-
-	var employee models.Employee
-	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if !h.requireIfMatch(w, r) {
 		return
 	}
 
-	if employee.Name == "" || employee.Position == "" || employee.Salary <= 0 {
-		http.Error(w, "Invalid employee data", http.StatusBadRequest)
+	c, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+	employee, err := h.decodeEmployee(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	updatedEmployee, err := store.UpdateEmployee(id, employee.Name, employee.Position, employee.Salary)
+	updatedEmployee, err := h.store.PatchEmployee(id, func(current models.Employee) (models.Employee, error) {
+		if err := checkIfMatch(r, current); err != nil {
+			return models.Employee{}, err
+		}
+		current.Name = employee.Name
+		current.Position = employee.Position
+		current.Salary = employee.Salary
+		current.DepartmentID = employee.DepartmentID
+		current.ManagerID = employee.ManagerID
+		return current, nil
+	})
 	if err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedEmployee)
+	w.Header().Set("ETag", etag(updatedEmployee, c, nil))
+	writeEncoded(w, r, c, http.StatusOK, updatedEmployee)
 }
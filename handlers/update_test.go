@@ -14,8 +14,10 @@ import (
 
 func TestUpdateEmployeeHandler(t *testing.T) {
 	// Initialize some employees for testing
-	store.CreateEmployee("John Doe", "Developer", 60000.0)
-	store.CreateEmployee("Alice Smith", "Manager", 80000.0)
+	s := store.NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	h := NewEmployeeHandler(s)
 
 	tests := []struct {
 		name         string
@@ -28,33 +30,33 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 		{
 			name:         "Update existing employee",
 			id:           1,
-			payload:      models.Employee{Name: "Updated John Doe", Position: "Senior Developer", Salary: 70000.0},
+			payload:      models.Employee{Name: "Updated John Doe", Position: "Senior Developer", Salary: usd("70000")},
 			expectedCode: http.StatusOK,
-			expectedBody: `{"id":1,"name":"Updated John Doe","position":"Senior Developer","salary":70000}`,
+			expectedBody: `{"id":1,"name":"Updated John Doe","position":"Senior Developer","salary":{"amount":"70000","currency":"USD"}}`,
 		},
 		// Employee does not exist
 		{
 			name:         "Update non-existent employee",
 			id:           80,
-			payload:      models.Employee{Name: "New Employee", Position: "Tester", Salary: 50000.0},
+			payload:      models.Employee{Name: "New Employee", Position: "Tester", Salary: usd("50000")},
 			expectedCode: http.StatusNotFound,
-			expectedBody: "Employee not found",
+			expectedBody: "employee 80 not found",
 		},
 		// Invalid payload: Missing name
 		{
 			name:         "Invalid payload: Missing name",
 			id:           1,
-			payload:      models.Employee{Position: "Senior Developer", Salary: 70000.0},
+			payload:      models.Employee{Position: "Senior Developer", Salary: usd("70000")},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: "Invalid employee data",
+			expectedBody: "name is required",
 		},
 		// Invalid payload: Negative salary
 		{
 			name:         "Invalid payload: Negative salary",
 			id:           1,
-			payload:      models.Employee{Name: "Updated John Doe", Position: "Senior Developer", Salary: -50000.0},
+			payload:      models.Employee{Name: "Updated John Doe", Position: "Senior Developer", Salary: usd("-50000")},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: "Invalid employee data",
+			expectedBody: "salary must be greater than 0",
 		},
 	}
 
@@ -70,7 +72,7 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 				t.Fatal(err)
 			}
 			recorder := httptest.NewRecorder()
-			handler := http.HandlerFunc(UpdateEmployeeHandler)
+			handler := http.HandlerFunc(h.UpdateEmployeeHandler)
 
 			handler.ServeHTTP(recorder, req)
 
@@ -81,7 +83,7 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 
 			// Check response body for error cases
 			if recorder.Code != http.StatusOK {
-				body := strings.TrimSpace(recorder.Body.String())
+				body := responseText(recorder)
 				if body != tt.expectedBody {
 					t.Errorf("handler returned unexpected body: got %v want %v",
 						body, tt.expectedBody)
@@ -103,3 +105,87 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateEmployeeFromCSV(t *testing.T) {
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Sales")
+	s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 1})
+	s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1, ManagerID: 1})
+	h := NewEmployeeHandler(s)
+
+	// A CSV employee sent back as it was fetched changes nothing.
+	req, err := http.NewRequest("GET", "/employees/2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/csv")
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(h.GetEmployeeHandler).ServeHTTP(recorder, req)
+	fetched := recorder.Body.String()
+	if want := "id,name,position,salary,currency,department_id,manager_id\n2,John Doe,Developer,60000,USD,1,1\n"; fetched != want {
+		t.Errorf("handler returned unexpected body: got %q want %q", fetched, want)
+	}
+
+	req, err = http.NewRequest("PUT", "/employees/2", strings.NewReader(fetched))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(h.UpdateEmployeeHandler).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+	}
+	if employee, _ := s.GetEmployeeByID(2); employee.DepartmentID != 1 || employee.ManagerID != 1 {
+		t.Errorf("CSV update changed the department or manager: %+v", employee)
+	}
+
+	// And a manager_id column moves the employee to another manager.
+	s.InsertEmployee(models.Employee{Name: "Carol King", Position: "Manager", Salary: usd("90000"), DepartmentID: 1})
+	req, err = http.NewRequest("PUT", "/employees/2", strings.NewReader("name,position,salary,department_id,manager_id\nJohn Doe,Developer,60000,1,3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(h.UpdateEmployeeHandler).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+	}
+	if reports, _ := s.DirectReports(3); len(reports) != 1 || reports[0].ID != 2 {
+		t.Errorf("DirectReports(3) after a CSV update = %+v, want employee 2", reports)
+	}
+}
+
+func TestUpdateEmployeeKeepsCurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		payload     string
+	}{
+		{"Plain number", "application/json", `{"name":"Anna Weber","position":"Developer","salary":70000}`},
+		{"Amount without a currency", "application/json", `{"name":"Anna Weber","position":"Developer","salary":{"amount":"70000"}}`},
+		{"CSV without a currency column", "text/csv", "name,position,salary\nAnna Weber,Developer,70000\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			s.InsertEmployee(models.Employee{Name: "Anna Weber", Position: "Developer", Salary: models.MustParseMoney("60000", "EUR")})
+			h := NewEmployeeHandler(s)
+
+			req, err := http.NewRequest("PUT", "/employees/1", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(h.UpdateEmployeeHandler).ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s", recorder.Code, http.StatusOK, responseText(recorder))
+			}
+			if employee, _ := s.GetEmployeeByID(1); employee.Salary != models.MustParseMoney("70000", "EUR") {
+				t.Errorf("salary after the update = %v, want 70000 EUR", employee.Salary)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"bytes"
+	"ems/codec"
+	"ems/models"
+	"ems/validation"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decodeEmployee reads and validates the employee in the request body, in
+// whichever registered format its Content-Type names; JSON if it has none.
+// A body that does not decode is a bad request; one that breaks the rules
+// yields validation.Errors. Only JSON bodies get type and unknown-field
+// violations, as the other formats cannot tell them apart from a body that
+// does not decode.
+func (h *EmployeeHandler) decodeEmployee(r *http.Request) (models.Employee, error) {
+	var c codec.Codec = codec.JSON{}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var ok bool
+		if c, ok = h.codecs.Lookup(contentType); !ok {
+			return models.Employee{}, newProblem(problemUnsupportedMediaType,
+				"Supported types are "+strings.Join(h.codecs.MediaTypes(), ", "))
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return models.Employee{}, badRequest("Invalid request payload")
+	}
+	if c.MediaType() == (codec.JSON{}).MediaType() {
+		employee, err := validation.DecodeEmployee(body)
+		if _, ok := err.(validation.Errors); err != nil && !ok {
+			return models.Employee{}, badRequest("Invalid request payload")
+		}
+		return employee, err
+	}
+
+	var employee models.Employee
+	if err := c.Decode(bytes.NewReader(body), &employee); err != nil {
+		return models.Employee{}, badRequest("Invalid request payload")
+	}
+	return employee, validation.EmployeeInput(employee)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"ems/config"
+	"ems/importer"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// runImport implements `ems import [-dry-run] [-format F] [-name H]
+// [-position H] [-salary H] [-currency H] [-department-id H] [-manager-id H]
+// FILE`, loading a CSV or XLSX file into the configured store. A dry run
+// writes nothing, so it may use the memory store to just check a file.
+func runImport(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "validate every row without writing anything")
+	formatName := fs.String("format", "", "csv or xlsx (default: from the file extension)")
+	var mapping importer.Mapping
+	fs.StringVar(&mapping.Name, "name", "", `header of the name column (default "name")`)
+	fs.StringVar(&mapping.Position, "position", "", `header of the position column (default "position")`)
+	fs.StringVar(&mapping.Salary, "salary", "", `header of the salary column (default "salary")`)
+	fs.StringVar(&mapping.Currency, "currency", "", `header of the optional currency column (default "currency")`)
+	fs.StringVar(&mapping.DepartmentID, "department-id", "", `header of the optional department id column (default "department_id")`)
+	fs.StringVar(&mapping.ManagerID, "manager-id", "", `header of the optional manager id column (default "manager_id")`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if cfg.StoreType == "memory" && !*dryRun {
+		return errors.New("import requires a persistent store (EMS_STORE=journal or sqlite); use -dry-run to only check the file")
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: ems import [flags] FILE")
+	}
+	path := fs.Arg(0)
+
+	if *formatName == "" {
+		*formatName = filepath.Ext(path)
+	}
+	format, ok := importer.FormatOf(*formatName)
+	if !ok {
+		return fmt.Errorf("unknown format %q (want csv or xlsx)", *formatName)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rows, err := importer.ReadRows(f, format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	parsed, err := importer.Parse(rows, mapping)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	repo, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		defer closer.Close()
+	}
+	report, err := importer.Import(repo, parsed, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range report.Errors {
+		fmt.Fprintf(os.Stdout, "row %d: %v\n", rowErr.Row, rowErr.Errors)
+	}
+	verb := "imported"
+	if report.DryRun {
+		verb = "valid"
+	}
+	fmt.Fprintf(os.Stdout, "%d of %d rows %s\n", report.Imported, report.Rows, verb)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d rows failed", report.Failed)
+	}
+	return nil
+}
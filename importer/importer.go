@@ -0,0 +1,283 @@
+// Package importer reads employees from CSV and XLSX spreadsheets, validates
+// every row and creates the valid ones through the store.
+package importer
+
+import (
+	"ems/models"
+	"ems/store"
+	"ems/validation"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format is a spreadsheet file format.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// Media types of the supported formats.
+const (
+	CSVType  = "text/csv"
+	XLSXType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// batchSize is how many rows go to the store in one batch.
+const batchSize = 500
+
+// ErrUnsupportedFormat is returned for formats other than CSV and XLSX.
+var ErrUnsupportedFormat = errors.New("unsupported import format")
+
+// ReadRows reads every row of the file in r. For XLSX only the first sheet
+// is read, and cells are read raw so number formats do not get in the way.
+func ReadRows(r io.Reader, format Format) ([][]string, error) {
+	switch format {
+	case CSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case XLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return f.GetRows(f.GetSheetName(0), excelize.Options{RawCellValue: true})
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// Mapping names the header of the column each employee field is read from.
+// An empty header means the field's own name. Headers match regardless of
+// case and surrounding space. The currency, department_id and manager_id
+// columns are optional unless mapped; salaries in rows without a currency
+// are in models.DefaultCurrency, and an empty or 0 id means no department
+// or no manager. A manager is named by their id in the store imported into,
+// so an export imports back into an empty store only when every manager
+// comes before their reports.
+type Mapping struct {
+	Name         string
+	Position     string
+	Salary       string
+	Currency     string
+	DepartmentID string
+	ManagerID    string
+}
+
+// Row is one data row of a spreadsheet. Number is the row's position in
+// the file, counting the header as 1, so people can find it.
+type Row struct {
+	Number   int
+	Employee models.Employee
+	Errors   validation.Errors
+}
+
+// Parse maps rows, the first of which is the header, to employees and
+// validates each of them. Blank rows are skipped. An error means the header
+// lacks a mapped column; problems with individual rows are in their Errors.
+func Parse(rows [][]string, mapping Mapping) ([]Row, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range rows[0] {
+		key := strings.ToLower(strings.TrimSpace(header))
+		if _, dup := columns[key]; !dup {
+			columns[key] = i
+		}
+	}
+	column := func(field, header string) (int, error) {
+		if header == "" {
+			header = field
+		}
+		i, ok := columns[strings.ToLower(strings.TrimSpace(header))]
+		if !ok {
+			return 0, fmt.Errorf("no %q column for %s", header, field)
+		}
+		return i, nil
+	}
+	nameCol, err := column("name", mapping.Name)
+	if err != nil {
+		return nil, err
+	}
+	positionCol, err := column("position", mapping.Position)
+	if err != nil {
+		return nil, err
+	}
+	salaryCol, err := column("salary", mapping.Salary)
+	if err != nil {
+		return nil, err
+	}
+	optional := func(field, header string) (int, error) {
+		i, err := column(field, header)
+		if err != nil && header == "" {
+			return -1, nil
+		}
+		return i, err
+	}
+	currencyCol, err := optional("currency", mapping.Currency)
+	if err != nil {
+		return nil, err
+	}
+	departmentCol, err := optional("department_id", mapping.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+	managerCol, err := optional("manager_id", mapping.ManagerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed []Row
+	for i, cells := range rows[1:] {
+		if blank(cells) {
+			continue
+		}
+		cell := func(col int) string {
+			if col >= 0 && col < len(cells) {
+				return cells[col]
+			}
+			return ""
+		}
+		row := Row{Number: i + 2}
+		var err error
+		row.Employee, err = validation.ParseEmployee(cell(nameCol), cell(positionCol), cell(salaryCol), cell(currencyCol))
+		var violations validation.Errors
+		switch {
+		case errors.As(err, &violations):
+			row.Errors = violations
+		case err != nil:
+			row.Errors = validation.Errors{{Code: validation.CodeInvalidFormat, Message: err.Error()}}
+		}
+		var v validation.Validator
+		row.Employee.DepartmentID = parseID(&v, "department_id", cell(departmentCol))
+		row.Employee.ManagerID = parseID(&v, "manager_id", cell(managerCol))
+		var idViolations validation.Errors
+		if errors.As(v.Err(), &idViolations) {
+			row.Errors = append(row.Errors, idViolations...)
+		}
+		parsed = append(parsed, row)
+	}
+	return parsed, nil
+}
+
+// parseID reads the id of a department or manager, reporting anything but
+// a whole number that is 0 or more to v. An empty cell is 0.
+func parseID(v *validation.Validator, field, cell string) int {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return 0
+	}
+	id, err := strconv.Atoi(cell)
+	if err != nil || id < 0 {
+		v.Add(field, validation.CodeInvalidType, field+" must be a whole number")
+		return 0
+	}
+	return id
+}
+
+func blank(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// RowError lists what is wrong with one row.
+type RowError struct {
+	Row    int               `json:"row"`
+	Errors validation.Errors `json:"errors"`
+}
+
+// Report is the outcome of an import. Imported counts the rows that were
+// created, or in a dry run the rows that would have been.
+type Report struct {
+	DryRun   bool       `json:"dry_run"`
+	Rows     int        `json:"rows"`
+	Imported int        `json:"imported"`
+	Failed   int        `json:"failed"`
+	Errors   []RowError `json:"errors"`
+}
+
+// Import creates the employees of every valid row in repo, unless dryRun
+// is set, and reports on every row. Rows are written in batches, each on a
+// best-effort basis, so one bad row never holds up the others. The error is
+// only for failures of the store itself, which can leave earlier batches
+// written.
+func Import(repo store.EmployeeRepository, rows []Row, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Rows: len(rows), Errors: []RowError{}}
+	var (
+		ops    []store.BatchOp
+		opRows []int
+	)
+	for _, row := range rows {
+		if row.Errors != nil {
+			report.Errors = append(report.Errors, RowError{Row: row.Number, Errors: row.Errors})
+			continue
+		}
+		ops = append(ops, store.BatchOp{
+			Action:       store.BatchCreate,
+			Name:         row.Employee.Name,
+			Position:     row.Employee.Position,
+			Salary:       row.Employee.Salary,
+			DepartmentID: row.Employee.DepartmentID,
+			ManagerID:    row.Employee.ManagerID,
+		})
+		opRows = append(opRows, row.Number)
+	}
+
+	if dryRun {
+		report.Imported = len(ops)
+	} else {
+		for start := 0; start < len(ops); start += batchSize {
+			end := min(start+batchSize, len(ops))
+			results, err := repo.ApplyBatch(ops[start:end], false)
+			if err != nil {
+				return Report{}, err
+			}
+			for i, result := range results {
+				var validationErr *store.ValidationError
+				switch {
+				case result.Err == nil:
+					report.Imported++
+				case errors.As(result.Err, &validationErr):
+					report.Errors = append(report.Errors, RowError{Row: opRows[start+i], Errors: validationErr.Errors})
+				default:
+					return Report{}, result.Err
+				}
+			}
+		}
+	}
+
+	report.Failed = report.Rows - report.Imported
+	// Store rejections come after the parse errors; keep the report in row
+	// order.
+	slices.SortStableFunc(report.Errors, func(a, b RowError) int { return a.Row - b.Row })
+	return report, nil
+}
+
+// FormatOf returns the format for a media type or a file extension, such as
+// "text/csv" or ".xlsx".
+func FormatOf(s string) (Format, bool) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "csv", CSVType:
+		return CSV, true
+	case "xlsx", XLSXType:
+		return XLSX, true
+	}
+	return "", false
+}
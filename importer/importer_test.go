@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"bytes"
+	"ems/models"
+	"ems/store"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const sheet = `Full Name,Title,Annual Salary,Notes
+John Doe,Developer,60000,
+,,,
+Alice Smith,Manager,lots,
+"Bob <b>",Designer,0,
+Eve Williams,Tester,65000.5,part time
+`
+
+func violations(rows []Row) map[int][]string {
+	got := make(map[int][]string)
+	for _, row := range rows {
+		for _, v := range row.Errors {
+			got[row.Number] = append(got[row.Number], v.Field+":"+v.Code)
+		}
+	}
+	return got
+}
+
+func TestParse(t *testing.T) {
+	rows, err := ReadRows(strings.NewReader(sheet), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Parse(rows, Mapping{}); err == nil {
+		t.Errorf("Parse() without a mapping for non-default headers succeeded, want an error")
+	}
+
+	parsed, err := Parse(rows, Mapping{Name: "full name", Position: " TITLE ", Salary: "Annual Salary"})
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	var numbers []int
+	for _, row := range parsed {
+		numbers = append(numbers, row.Number)
+	}
+	if want := []int{2, 4, 5, 6}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("Parse() row numbers = %v, want %v", numbers, want)
+	}
+	want := map[int][]string{
+		4: {"salary:invalid_type"},
+		5: {"name:invalid_characters", "salary:too_small"},
+	}
+	if got := violations(parsed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() violations = %v, want %v", got, want)
+	}
+	if got := parsed[3].Employee.Salary; got != models.MustParseMoney("65000.5", "USD") {
+		t.Errorf("Parse() salary = %v, want 65000.5 USD", got)
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	rows, err := ReadRows(strings.NewReader("name,position,salary,currency\nJohn Doe,Developer,60000,eur\nAlice Smith,Manager,80000,\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(rows, Mapping{})
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	var got []models.Money
+	for _, row := range parsed {
+		got = append(got, row.Employee.Salary)
+	}
+	if want := []models.Money{models.MustParseMoney("60000", "EUR"), models.MustParseMoney("80000", "USD")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() salaries = %v, want %v", got, want)
+	}
+
+	if _, err := Parse(rows, Mapping{Currency: "Pay Currency"}); err == nil {
+		t.Errorf("Parse() with a missing mapped currency column succeeded, want an error")
+	}
+}
+
+func TestReadRowsXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	sheetName := f.GetSheetName(0)
+	f.SetSheetRow(sheetName, "A1", &[]any{"Name", "Position", "Salary"})
+	f.SetSheetRow(sheetName, "A2", &[]any{"John Doe", "Developer", 60000})
+	// A thousands format must not leak into the salary.
+	style, _ := f.NewStyle(&excelize.Style{NumFmt: 3})
+	f.SetCellStyle(sheetName, "C2", "C2", style)
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadRows(&buf, XLSX)
+	if err != nil {
+		t.Fatalf("ReadRows() unexpected error: %v", err)
+	}
+	parsed, err := Parse(rows, Mapping{})
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if len(parsed) != 1 || parsed[0].Errors != nil || parsed[0].Employee.Salary != models.MustParseMoney("60000", "USD") {
+		t.Errorf("Parse() = %+v, want one valid row with salary 60000", parsed)
+	}
+}
+
+func TestImport(t *testing.T) {
+	rows, _ := ReadRows(strings.NewReader(sheet), CSV)
+	parsed, _ := Parse(rows, Mapping{Name: "Full Name", Position: "Title", Salary: "Annual Salary"})
+
+	for _, dryRun := range []bool{true, false} {
+		s := store.NewMemoryStore()
+		report, err := Import(s, parsed, dryRun)
+		if err != nil {
+			t.Fatalf("Import(dryRun=%v) unexpected error: %v", dryRun, err)
+		}
+		if report.Rows != 4 || report.Imported != 2 || report.Failed != 2 || len(report.Errors) != 2 {
+			t.Errorf("Import(dryRun=%v) report = %+v, want 4 rows, 2 imported, 2 failed", dryRun, report)
+		}
+
+		wantCount := 2
+		if dryRun {
+			wantCount = 0
+		}
+		if count, _ := s.CountEmployees(store.ListOptions{}); count != wantCount {
+			t.Errorf("Import(dryRun=%v) left %d employees, want %d", dryRun, count, wantCount)
+		}
+	}
+}
+
+func TestParseReferences(t *testing.T) {
+	rows, err := ReadRows(strings.NewReader("name,position,salary,Dept,manager_id\nJohn Doe,Developer,60000,2,1\nAlice Smith,Manager,80000,,0\nBob Johnson,Designer,70000,sales,-1\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(rows, Mapping{DepartmentID: "dept"})
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if e := parsed[0].Employee; parsed[0].Errors != nil || e.DepartmentID != 2 || e.ManagerID != 1 {
+		t.Errorf("Parse() row 2 = %+v, want department 2 and manager 1", parsed[0])
+	}
+	if e := parsed[1].Employee; parsed[1].Errors != nil || e.DepartmentID != 0 || e.ManagerID != 0 {
+		t.Errorf("Parse() row 3 = %+v, want no department or manager", parsed[1])
+	}
+	var fields []string
+	for _, v := range parsed[2].Errors {
+		fields = append(fields, v.Field)
+	}
+	if want := []string{"department_id", "manager_id"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Parse() row 4 errors = %v, want violations of %v", parsed[2].Errors, want)
+	}
+
+	if _, err := Parse(rows, Mapping{ManagerID: "boss"}); err == nil {
+		t.Errorf("Parse() with a missing mapped manager_id column succeeded, want an error")
+	}
+}
@@ -1,13 +1,103 @@
 package main
 
 import (
+	"ems/config"
+	"ems/handlers"
+	"ems/rates"
 	"ems/router"
+	"ems/store"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 )
 
 func main() {
-	r := router.SetupRouter()
-	log.Println("Server is running on port 8080")
-	http.ListenAndServe(":8080", r)
+	cfg := config.Load()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("import: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "orgchart" {
+		if err := runOrgChart(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("orgchart: %v", err)
+		}
+		return
+	}
+
+	repo, err := openStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open %s store: %v", cfg.StoreType, err)
+	}
+
+	var opts []handlers.Option
+	if cfg.CursorSecret != "" {
+		opts = append(opts, handlers.WithCursorSecret([]byte(cfg.CursorSecret)))
+	}
+	opts = append(opts, handlers.WithStrictIfMatch(cfg.StrictIfMatch))
+	if cfg.RatesFile != "" {
+		table, err := rates.Open(cfg.RatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+		opts = append(opts, handlers.WithRates(table))
+	}
+	opts = append(opts, handlers.WithRatesAdmin(cfg.RatesAdmin))
+
+	go applyDueCompensation(repo, compensationInterval)
+
+	r := router.SetupRouter(repo, opts...)
+	log.Printf("Server is running on %s (%s store)", cfg.Addr, cfg.StoreType)
+	http.ListenAndServe(cfg.Addr, r)
+}
+
+// compensationInterval is how often the server looks for future-dated
+// salary changes that have come into effect.
+const compensationInterval = time.Minute
+
+// applyDueCompensation brings salaries in line with their histories now and
+// then every interval, so scheduled changes take effect on their day.
+func applyDueCompensation(repo store.EmployeeRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		applied, err := repo.ApplyDueCompensation(time.Now())
+		if err != nil {
+			log.Printf("compensation: %v", err)
+		} else if applied > 0 {
+			log.Printf("compensation: applied %d scheduled salary changes", applied)
+		}
+		<-ticker.C
+	}
+}
+
+func openStore(cfg config.Config) (store.EmployeeRepository, error) {
+	switch cfg.StoreType {
+	case "memory":
+		return store.NewMemoryStore(), nil
+	case "journal":
+		return store.NewJournalStore(cfg.JournalDir, cfg.SnapshotEvery)
+	case "sqlite":
+		s, err := store.NewSQLiteStore(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.CheckSchema(); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("%w; run `ems migrate up` first", err)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown store type %q", cfg.StoreType)
+	}
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"ems/config"
+	"ems/store"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runMigrate implements `ems migrate [up|down|status] [-to N]` against the
+// configured SQLite database.
+func runMigrate(cfg config.Config, args []string) error {
+	if cfg.StoreType != "sqlite" {
+		return fmt.Errorf("migrate requires EMS_STORE=sqlite, got %q", cfg.StoreType)
+	}
+
+	direction := "up"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		direction, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("migrate "+direction, flag.ContinueOnError)
+	to := fs.Int("to", -1, "target schema version (default: latest for up, 0 for down)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	s, err := store.NewSQLiteStore(cfg.SQLitePath)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	switch direction {
+	case "up":
+		err = s.MigrateUp(*to)
+	case "down":
+		if *to < 0 {
+			*to = 0
+		}
+		err = s.MigrateDown(*to)
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q (want up, down or status)", direction)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	for _, m := range store.Migrations() {
+		state := "pending"
+		if m.Version <= version {
+			state = "applied"
+		}
+		fmt.Fprintf(os.Stdout, "%4d  %-30s %s\n", m.Version, m.Name, state)
+	}
+	fmt.Fprintf(os.Stdout, "schema version %d (latest %d)\n", version, store.LatestSchemaVersion())
+	return nil
+}
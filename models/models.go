@@ -1,8 +1,112 @@
 package models
 
+import "encoding/xml"
+
 type Employee struct {
-	ID       int     `json:"id"`
-	Name     string  `json:"name"`
-	Position string  `json:"position"`
-	Salary   float64 `json:"salary"`
+	ID       int    `json:"id" xml:"id"`
+	Name     string `json:"name" xml:"name"`
+	Position string `json:"position" xml:"position"`
+	Salary   Money  `json:"salary" xml:"salary"`
+	// DepartmentID is the department the employee belongs to, or 0 for
+	// none.
+	DepartmentID int `json:"department_id,omitempty" xml:"department_id,omitempty"`
+	// ManagerID is the employee this one reports to, or 0 for none.
+	ManagerID int `json:"manager_id,omitempty" xml:"manager_id,omitempty"`
+	// Version starts at 1 and goes up by one with every change. It is sent
+	// as the ETag rather than in the body.
+	Version int `json:"-" xml:"-"`
+}
+
+// Department groups employees. Every employee belongs to at most one.
+type Department struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+// DateLayout is the layout of calendar dates such as effective dates.
+const DateLayout = "2006-01-02"
+
+// Compensation is one entry of an employee's salary history. The entry in
+// effect on a day is the one with the latest EffectiveDate on or before it,
+// the last recorded winning a tie, and the employee's Salary is its Salary.
+type Compensation struct {
+	ID         int   `json:"id" xml:"id"`
+	EmployeeID int   `json:"employee_id" xml:"employee_id"`
+	Salary     Money `json:"salary" xml:"salary"`
+	// EffectiveDate is the day the salary applies from, in DateLayout.
+	EffectiveDate string `json:"effective_date" xml:"effective_date"`
+	Reason        string `json:"reason,omitempty" xml:"reason,omitempty"`
+}
+
+// Page is one page of a GET /employees listing. T is Employee, or a
+// projection of one when the client asked for a sparse fieldset.
+type Page[T any] struct {
+	XMLName    xml.Name  `json:"-" xml:"employees"`
+	Items      []T       `json:"items" xml:"items>employee"`
+	Total      int       `json:"total" xml:"total"`
+	Page       int       `json:"page,omitempty" xml:"page,omitempty"`
+	Size       int       `json:"size" xml:"size"`
+	TotalPages int       `json:"total_pages" xml:"total_pages"`
+	Links      PageLinks `json:"links" xml:"links"`
+	// Conversion is set when the salaries were converted to another
+	// currency.
+	Conversion *Conversion `json:"conversion,omitempty" xml:"conversion,omitempty"`
+}
+
+// EmployeePage is a page of whole employees.
+type EmployeePage = Page[Employee]
+
+// PageLinks are the URLs of the current and neighbouring pages. Links that
+// do not apply to the current page are omitted.
+type PageLinks struct {
+	Self  string `json:"self" xml:"self"`
+	First string `json:"first,omitempty" xml:"first,omitempty"`
+	Prev  string `json:"prev,omitempty" xml:"prev,omitempty"`
+	Next  string `json:"next,omitempty" xml:"next,omitempty"`
+	Last  string `json:"last,omitempty" xml:"last,omitempty"`
+}
+
+// EmployeeSearchResult is an employee matched by a search, with its
+// relevance score. Higher scores are better matches.
+type EmployeeSearchResult struct {
+	Employee
+	Score float64 `json:"score"`
+}
+
+// ExchangeRate is one dated rate: on Date, one unit of From was worth Rate
+// units of To.
+type ExchangeRate struct {
+	// Date is the day the rate was published, in DateLayout.
+	Date string `json:"date" xml:"date"`
+	From string `json:"from" xml:"from"`
+	To   string `json:"to" xml:"to"`
+	// Rate is a positive decimal such as "1.0843", kept as a string so it
+	// is exact.
+	Rate string `json:"rate" xml:"rate"`
+}
+
+// Conversion records how the salaries in a response were converted to
+// Currency: with the rates in effect on AsOf, each published on its Date.
+type Conversion struct {
+	Currency string         `json:"currency" xml:"currency"`
+	AsOf     string         `json:"as_of" xml:"as_of"`
+	Rates    []ExchangeRate `json:"rates" xml:"rates>rate"`
+}
+
+// SalaryReport totals salaries in one currency, overall and by department.
+type SalaryReport struct {
+	Currency  string `json:"currency"`
+	Headcount int    `json:"headcount"`
+	Total     Money  `json:"total"`
+	// Departments are in id order, with the employees in no department
+	// first under id 0.
+	Departments []DepartmentSalaries `json:"departments"`
+	Conversion  *Conversion          `json:"conversion"`
+}
+
+// DepartmentSalaries is the salary total of one department's employees.
+type DepartmentSalaries struct {
+	DepartmentID int   `json:"department_id"`
+	Headcount    int   `json:"headcount"`
+	Total        Money `json:"total"`
 }
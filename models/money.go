@@ -0,0 +1,320 @@
+package models
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// DefaultCurrency is the currency of a new employee whose salary is given
+// without one, as every salary was before salaries carried a currency.
+const DefaultCurrency = "USD"
+
+// MoneyScale is the number of decimal places Money keeps, enough for the
+// minor unit of every ISO 4217 currency.
+const MoneyScale = 4
+
+// moneyFactor is 10^MoneyScale, the number of units in one whole amount.
+const moneyFactor = 10_000
+
+// ErrCurrencyMismatch is returned when adding or subtracting amounts of
+// different currencies.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an exact decimal amount of a currency. The amount is held as a
+// whole number of units of 10^-MoneyScale, so sums never drift, and the
+// zero value is an amount of 0 in no currency. Two Money values are equal
+// with == exactly when they have the same amount and currency.
+//
+// In JSON, MessagePack and XML it is an object with the amount as a decimal
+// string and the ISO 4217 currency code, such as
+// {"amount":"60000.5","currency":"EUR"}. A plain number decodes as an amount
+// in no currency, like an object without one, rounded to MoneyScale decimal
+// places since it may have been a float. Whoever stores it decides the
+// currency with DefaultTo.
+type Money struct {
+	units    int64
+	currency string
+}
+
+// NewMoney returns units of 10^-MoneyScale of currency.
+func NewMoney(units int64, currency string) Money {
+	return Money{units: units, currency: normalizeCurrency(currency)}
+}
+
+// amountPattern is a decimal number as accepted in JSON, with an optional
+// sign, fraction and exponent. The exponent is its fourth group.
+var amountPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE]([+-]?\d+))?$`)
+
+// maxAmountLength and maxAmountExponent bound the amounts parseUnits
+// accepts, so that an amount such as 1e999999 is rejected before it is
+// expanded into a number with a million digits. Both are far beyond any
+// amount that fits in Money.
+const (
+	maxAmountLength   = 64
+	maxAmountExponent = 40
+)
+
+// ParseMoney parses a decimal amount such as "60000.50" of currency. The
+// amount may have at most MoneyScale decimal places.
+func ParseMoney(amount, currency string) (Money, error) {
+	units, err := parseUnits(amount, false)
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(units, currency), nil
+}
+
+// MustParseMoney is ParseMoney for amounts known to be valid. It panics if
+// amount cannot be parsed.
+func MustParseMoney(amount, currency string) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// parseUnits converts a decimal amount to units. With round set, extra
+// decimal places are rounded half away from zero rather than rejected.
+func parseUnits(amount string, round bool) (int64, error) {
+	amount = strings.TrimSpace(amount)
+	if len(amount) > maxAmountLength {
+		return 0, fmt.Errorf("amount %q is longer than %d characters", amount, maxAmountLength)
+	}
+	match := amountPattern.FindStringSubmatch(amount)
+	if match == nil {
+		return 0, fmt.Errorf("amount %q is not a decimal number", amount)
+	}
+	if exp, err := strconv.Atoi(match[4]); match[4] != "" && (err != nil || exp > maxAmountExponent || exp < -maxAmountExponent) {
+		return 0, fmt.Errorf("amount %q has an exponent beyond ±%d", amount, maxAmountExponent)
+	}
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return 0, fmt.Errorf("amount %q is not a decimal number", amount)
+	}
+	r.Mul(r, big.NewRat(moneyFactor, 1))
+	if !r.IsInt() && !round {
+		return 0, fmt.Errorf("amount %q has more than %d decimal places", amount, MoneyScale)
+	}
+	units, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		units.Add(units, big.NewInt(int64(r.Sign())))
+	}
+	if !units.IsInt64() {
+		return 0, fmt.Errorf("amount %q is too large", amount)
+	}
+	return units.Int64(), nil
+}
+
+func normalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// Units returns the amount in units of 10^-MoneyScale.
+func (m Money) Units() int64 { return m.units }
+
+// Currency returns the ISO 4217 code of m's currency.
+func (m Money) Currency() string { return m.currency }
+
+// DefaultTo returns m, or m's amount in currency if m has no currency of
+// its own.
+func (m Money) DefaultTo(currency string) Money {
+	if m.currency != "" {
+		return m
+	}
+	return NewMoney(m.units, currency)
+}
+
+// Amount returns the amount as a decimal string without trailing zeros,
+// such as "60000.5".
+func (m Money) Amount() string {
+	sign := ""
+	units := uint64(m.units)
+	if m.units < 0 {
+		sign = "-"
+		units = uint64(-m.units)
+	}
+	whole := strconv.FormatUint(units/moneyFactor, 10)
+	fraction := units % moneyFactor
+	if fraction == 0 {
+		return sign + whole
+	}
+	digits := strings.TrimRight(fmt.Sprintf("%0*d", MoneyScale, fraction), "0")
+	return sign + whole + "." + digits
+}
+
+// String returns the amount followed by the currency, such as "60000.5 EUR".
+func (m Money) String() string {
+	return strings.TrimSpace(m.Amount() + " " + m.currency)
+}
+
+// Float64 returns the amount as the nearest float64, for spreadsheet cells
+// and other consumers that cannot take an exact decimal.
+func (m Money) Float64() float64 {
+	return float64(m.units) / moneyFactor
+}
+
+// Places returns the number of decimal places the amount needs.
+func (m Money) Places() int {
+	fraction := m.units % moneyFactor
+	places := MoneyScale
+	for places > 0 && fraction%10 == 0 {
+		fraction /= 10
+		places--
+	}
+	return places
+}
+
+// Cmp compares the amounts of m and o, ignoring their currencies, and
+// returns -1, 0 or +1.
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.units < o.units:
+		return -1
+	case m.units > o.units:
+		return 1
+	}
+	return 0
+}
+
+// Add returns m + o. Both must be in the same currency, unless one of them
+// is the zero Money, which adds nothing.
+func (m Money) Add(o Money) (Money, error) {
+	if m == (Money{}) {
+		return o, nil
+	}
+	if o == (Money{}) {
+		return m, nil
+	}
+	if m.currency != o.currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	sum := m.units + o.units
+	if (sum > m.units) != (o.units > 0) {
+		return Money{}, errors.New("amount out of range")
+	}
+	return Money{units: sum, currency: m.currency}, nil
+}
+
+// Sub returns m - o, on the same terms as Add.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.units == math.MinInt64 {
+		return Money{}, errors.New("amount out of range")
+	}
+	return m.Add(Money{units: -o.units, currency: o.currency})
+}
+
+// moneyObject is how Money is encoded.
+type moneyObject struct {
+	Amount   string `json:"amount" xml:"amount" msgpack:"amount"`
+	Currency string `json:"currency" xml:"currency" msgpack:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyObject{Amount: m.Amount(), Currency: m.currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		return m.setNumber(number.String())
+	}
+	var object struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return errors.New("money must be a number or an object with an amount and a currency")
+	}
+	var amount string
+	if err := json.Unmarshal(object.Amount, &amount); err != nil {
+		if err := json.Unmarshal(object.Amount, &number); err != nil {
+			return errors.New("amount must be a decimal string or number")
+		}
+		amount = number.String()
+	}
+	return m.set(amount, object.Currency)
+}
+
+// setNumber sets m to a plain number, in no currency.
+func (m *Money) setNumber(amount string) error {
+	units, err := parseUnits(amount, true)
+	if err != nil {
+		return err
+	}
+	*m = NewMoney(units, "")
+	return nil
+}
+
+func (m *Money) set(amount, currency string) error {
+	parsed, err := ParseMoney(amount, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(moneyObject{Amount: m.Amount(), Currency: m.currency}, start)
+}
+
+// UnmarshalXML reads an element with amount and currency children, or a
+// plain number as salaries were once written.
+func (m *Money) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var element struct {
+		Text     string `xml:",chardata"`
+		Amount   string `xml:"amount"`
+		Currency string `xml:"currency"`
+	}
+	if err := d.DecodeElement(&element, &start); err != nil {
+		return err
+	}
+	if element.Amount == "" && element.Currency == "" {
+		return m.setNumber(element.Text)
+	}
+	return m.set(element.Amount, element.Currency)
+}
+
+func (m Money) EncodeMsgpack(e *msgpack.Encoder) error {
+	return e.Encode(moneyObject{Amount: m.Amount(), Currency: m.currency})
+}
+
+// DecodeMsgpack reads a map with amount and currency, or a plain number.
+func (m *Money) DecodeMsgpack(d *msgpack.Decoder) error {
+	v, err := d.DecodeInterface()
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		amount, currency := "", ""
+		switch a := v["amount"].(type) {
+		case string:
+			amount = a
+		case nil:
+		default:
+			amount = fmt.Sprint(a)
+		}
+		if c, ok := v["currency"].(string); ok {
+			currency = c
+		}
+		return m.set(amount, currency)
+	case float32:
+		return m.setNumber(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	case float64:
+		return m.setNumber(strconv.FormatFloat(v, 'f', -1, 64))
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return m.setNumber(fmt.Sprint(v))
+	}
+	return fmt.Errorf("money must be a number or a map with an amount and a currency, not %T", v)
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+		units  int64
+		err    bool
+	}{
+		{amount: "60000", want: "60000", units: 600000000},
+		{amount: " 60000.50 ", want: "60000.5", units: 600005000},
+		{amount: "0.0001", want: "0.0001", units: 1},
+		{amount: "-12.3", want: "-12.3", units: -123000},
+		{amount: "6e4", want: "60000", units: 600000000},
+		{amount: ".5", want: "0.5", units: 5000},
+		{amount: "0.00001", err: true},
+		{amount: "60,000", err: true},
+		{amount: "1/3", err: true},
+		{amount: "Inf", err: true},
+		{amount: "", err: true},
+		{amount: "1e30", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			m, err := ParseMoney(tt.amount, " eur")
+			if tt.err {
+				if err == nil {
+					t.Errorf("ParseMoney(%q) = %v, want an error", tt.amount, m)
+				}
+				return
+			}
+			if err != nil || m.Amount() != tt.want || m.Units() != tt.units || m.Currency() != "EUR" {
+				t.Errorf("ParseMoney(%q) = %v (%d units), %v, want %s EUR (%d units)", tt.amount, m, m.Units(), err, tt.want, tt.units)
+			}
+		})
+	}
+}
+
+func TestParseMoneyBoundsWork(t *testing.T) {
+	tests := map[string]string{
+		"1e999999":                            "exponent beyond ±40",
+		"1e-999999":                           "exponent beyond ±40",
+		"1e99999999999999999999":              "exponent beyond ±40",
+		"0." + strings.Repeat("0", 100) + "1": "longer than 64 characters",
+	}
+	for amount, want := range tests {
+		if _, err := ParseMoney(amount, "USD"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseMoney(%.20q) error = %v, want one saying %q", amount, err, want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// 0.1 + 0.2 is exactly 0.3, unlike with float64.
+	sum, err := MustParseMoney("0.1", "USD").Add(MustParseMoney("0.2", "USD"))
+	if err != nil || sum != MustParseMoney("0.3", "USD") {
+		t.Errorf("0.1 + 0.2 = %v, %v, want 0.3 USD", sum, err)
+	}
+	if total, err := (Money{}).Add(sum); err != nil || total != sum {
+		t.Errorf("zero + %v = %v, %v, want %v", sum, total, err, sum)
+	}
+	if diff, err := sum.Sub(MustParseMoney("0.3", "USD")); err != nil || diff.Units() != 0 {
+		t.Errorf("0.3 - 0.3 = %v, %v, want 0", diff, err)
+	}
+	if _, err := sum.Add(MustParseMoney("1", "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + EUR error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := NewMoney(1<<62, "USD").Add(NewMoney(1<<62, "USD")); err == nil {
+		t.Errorf("overflowing Add() succeeded, want an error")
+	}
+	if places := MustParseMoney("12.340", "USD").Places(); places != 2 {
+		t.Errorf("Places() of 12.34 = %d, want 2", places)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	m := MustParseMoney("60000.25", "INR")
+	data, err := json.Marshal(m)
+	if err != nil || string(data) != `{"amount":"60000.25","currency":"INR"}` {
+		t.Errorf("Marshal() = %s, %v", data, err)
+	}
+
+	tests := []struct {
+		data string
+		want Money
+		err  bool
+	}{
+		{data: string(data), want: m},
+		{data: `{"amount":60000.25,"currency":"inr"}`, want: m},
+		{data: `60000.25`, want: MustParseMoney("60000.25", "")},
+		// Plain numbers may be floats, so extra places are rounded.
+		{data: `0.30000000000000004`, want: MustParseMoney("0.3", "")},
+		{data: `{"amount":"0.00001","currency":"USD"}`, err: true},
+		{data: `{"currency":"USD"}`, err: true},
+		{data: `6e4`, want: MustParseMoney("60000", "")},
+		{data: `1e999999`, err: true},
+		{data: `"lots"`, err: true},
+		{data: `true`, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.err {
+				if err == nil {
+					t.Errorf("Unmarshal(%s) = %v, want an error", tt.data, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Unmarshal(%s) = %v, %v, want %v", tt.data, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrencyDigits(t *testing.T) {
+	for code, want := range map[string]int{"USD": 2, "JPY": 0, "KWD": 3, "CLF": 4} {
+		if digits, ok := CurrencyDigits(code); !ok || digits != want {
+			t.Errorf("CurrencyDigits(%q) = %d, %v, want %d", code, digits, ok, want)
+		}
+	}
+	if _, ok := CurrencyDigits("usd"); ok {
+		t.Errorf("CurrencyDigits() accepted a lower-case code")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"ems/config"
+	"ems/orgchart"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// runOrgChart implements `ems orgchart [-format F] [-root ID]
+// [-department ID] [-o FILE]`, drawing the reporting tree of the configured
+// store to FILE or standard output.
+func runOrgChart(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("orgchart", flag.ContinueOnError)
+	formatName := fs.String("format", "", "svg, dot or mermaid (default: from the output file extension, else svg)")
+	output := fs.String("o", "", "file to write (default: standard output)")
+	var opts orgchart.Options
+	fs.IntVar(&opts.RootID, "root", 0, "employee id to root the chart at")
+	fs.IntVar(&opts.DepartmentID, "department", 0, "department id to limit the chart to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: ems orgchart [flags]")
+	}
+
+	if *formatName == "" {
+		*formatName = string(orgchart.SVG)
+		if *output != "" {
+			*formatName = filepath.Ext(*output)
+		}
+	}
+	format, ok := orgchart.FormatOf(*formatName)
+	if !ok {
+		return fmt.Errorf("unknown format %q (want svg, dot or mermaid)", *formatName)
+	}
+
+	repo, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		defer closer.Close()
+	}
+	chart, err := orgchart.Build(repo, opts)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return orgchart.Render(os.Stdout, chart, format)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := orgchart.Render(f, chart, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,204 @@
+// Package orgchart builds the reporting tree from the store and renders it
+// as a Graphviz DOT graph, a standalone SVG image or a Mermaid flowchart.
+package orgchart
+
+import (
+	"bufio"
+	"ems/filter"
+	"ems/models"
+	"ems/store"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format is an output format for a chart.
+type Format string
+
+const (
+	DOT     Format = "dot"
+	SVG     Format = "svg"
+	Mermaid Format = "mermaid"
+)
+
+// ErrUnsupportedFormat is returned for formats other than DOT, SVG and
+// Mermaid.
+var ErrUnsupportedFormat = errors.New("unsupported chart format")
+
+// FormatOf returns the format with the given name or file extension, such
+// as "svg" or ".mmd".
+func FormatOf(name string) (Format, bool) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "dot", "gv":
+		return DOT, true
+	case "svg":
+		return SVG, true
+	case "mermaid", "mmd":
+		return Mermaid, true
+	}
+	return "", false
+}
+
+// MediaType returns the Content-Type a chart in f is served with.
+func (f Format) MediaType() string {
+	switch f {
+	case DOT:
+		return "text/vnd.graphviz; charset=utf-8"
+	case SVG:
+		return "image/svg+xml"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Node is one employee in a chart, with their direct reports in id order.
+type Node struct {
+	Employee models.Employee
+	Reports  []*Node
+}
+
+// Chart is a forest of reporting trees. Roots are in id order.
+type Chart struct {
+	Roots []*Node
+}
+
+// Options picks the part of the organisation a chart shows. At most one of
+// the fields may be set; when neither is, the chart shows everyone.
+type Options struct {
+	// RootID roots the chart at one employee and everyone below them.
+	RootID int
+	// DepartmentID limits the chart to one department's members. Members
+	// whose manager is outside the department become roots.
+	DepartmentID int
+}
+
+// Build reads the employees opts selects from repo and arranges them by
+// manager. A missing root employee or department is a store.NotFoundError.
+func Build(repo store.EmployeeRepository, opts Options) (*Chart, error) {
+	var employees []models.Employee
+	switch {
+	case opts.RootID != 0 && opts.DepartmentID != 0:
+		return nil, errors.New("a chart cannot be rooted at both an employee and a department")
+	case opts.RootID != 0:
+		root, err := repo.GetEmployeeByID(opts.RootID)
+		if err != nil {
+			return nil, err
+		}
+		subordinates, err := repo.Subordinates(opts.RootID)
+		if err != nil {
+			return nil, err
+		}
+		// Everyone below the root has their manager in the set, so the
+		// root's manager is the only reference to cut.
+		root.ManagerID = 0
+		employees = append([]models.Employee{root}, subordinates...)
+	default:
+		var where filter.Expr
+		if opts.DepartmentID != 0 {
+			if _, err := repo.GetDepartmentByID(opts.DepartmentID); err != nil {
+				return nil, err
+			}
+			where = filter.Comparison{
+				Field:  "department_id",
+				Op:     filter.OpEq,
+				Values: []filter.Value{{Kind: filter.Number, Number: float64(opts.DepartmentID)}},
+			}
+		}
+		err := repo.ExportEmployees(where, func(e models.Employee) error {
+			employees = append(employees, e)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return arrange(employees), nil
+}
+
+// arrange nests employees under their managers. Anyone whose manager is not
+// among them is a root.
+func arrange(employees []models.Employee) *Chart {
+	slices.SortFunc(employees, func(a, b models.Employee) int { return a.ID - b.ID })
+	nodes := make(map[int]*Node, len(employees))
+	for _, e := range employees {
+		nodes[e.ID] = &Node{Employee: e}
+	}
+	chart := &Chart{}
+	for _, e := range employees {
+		node := nodes[e.ID]
+		if manager, ok := nodes[e.ManagerID]; ok {
+			manager.Reports = append(manager.Reports, node)
+		} else {
+			chart.Roots = append(chart.Roots, node)
+		}
+	}
+	return chart
+}
+
+// walk calls fn with every node of the chart, parents before their
+// reports. parent is nil for the roots.
+func (c *Chart) walk(fn func(node, parent *Node)) {
+	var visit func(node, parent *Node)
+	visit = func(node, parent *Node) {
+		fn(node, parent)
+		for _, report := range node.Reports {
+			visit(report, node)
+		}
+	}
+	for _, root := range c.Roots {
+		visit(root, nil)
+	}
+}
+
+// Render writes c to w in format.
+func Render(w io.Writer, c *Chart, format Format) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case DOT:
+		writeDOT(bw, c)
+	case SVG:
+		writeSVG(bw, c)
+	case Mermaid:
+		writeMermaid(bw, c)
+	default:
+		return fmt.Errorf("%w %q", ErrUnsupportedFormat, format)
+	}
+	return bw.Flush()
+}
+
+// nodeID names a node in DOT and Mermaid output.
+func nodeID(e models.Employee) string {
+	return "e" + strconv.Itoa(e.ID)
+}
+
+func writeDOT(w *bufio.Writer, c *Chart) {
+	w.WriteString("digraph orgchart {\n")
+	w.WriteString("\tnode [shape=box];\n")
+	c.walk(func(node, parent *Node) {
+		e := node.Employee
+		label := strconv.Quote(e.Name + "\n" + e.Position)
+		fmt.Fprintf(w, "\t%s [label=%s];\n", nodeID(e), label)
+		if parent != nil {
+			fmt.Fprintf(w, "\t%s -> %s;\n", nodeID(parent.Employee), nodeID(e))
+		}
+	})
+	w.WriteString("}\n")
+}
+
+// mermaidEscaper replaces the characters that would end or confuse a
+// quoted Mermaid label with entity codes.
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ")
+
+func writeMermaid(w *bufio.Writer, c *Chart) {
+	w.WriteString("graph TD\n")
+	c.walk(func(node, parent *Node) {
+		e := node.Employee
+		fmt.Fprintf(w, "\t%s[\"%s<br/>%s\"]\n", nodeID(e), mermaidEscaper.Replace(e.Name), mermaidEscaper.Replace(e.Position))
+		if parent != nil {
+			fmt.Fprintf(w, "\t%s --> %s\n", nodeID(parent.Employee), nodeID(e))
+		}
+	})
+}
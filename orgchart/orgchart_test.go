@@ -0,0 +1,186 @@
+package orgchart
+
+import (
+	"bytes"
+	"ems/models"
+	"ems/store"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// usd is amount in US dollars.
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+func newTestStore(t *testing.T) store.EmployeeRepository {
+	t.Helper()
+	s := store.NewMemoryStore()
+	s.CreateDepartment("Engineering")
+	for _, e := range []models.Employee{
+		{Name: "Carol King", Position: "CEO", Salary: usd("200000")},
+		{Name: "Alice Smith", Position: "CTO", Salary: usd("150000"), ManagerID: 1, DepartmentID: 1},
+		{Name: "John Doe", Position: "Developer", Salary: usd("60000"), ManagerID: 2, DepartmentID: 1},
+		{Name: "Bob Johnson", Position: "Designer", Salary: usd("70000"), ManagerID: 1},
+	} {
+		if _, err := s.InsertEmployee(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func render(t *testing.T, c *Chart, format Format) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Render(&buf, c, format); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestBuild(t *testing.T) {
+	s := newTestStore(t)
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{name: "Everyone", want: "1(2(3) 4)"},
+		{name: "Rooted at an employee", opts: Options{RootID: 2}, want: "2(3)"},
+		{name: "One department", opts: Options{DepartmentID: 1}, want: "2(3)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chart, err := Build(s, tt.opts)
+			if err != nil {
+				t.Fatalf("Build() unexpected error: %v", err)
+			}
+			if got := shape(chart.Roots); got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	var notFound *store.NotFoundError
+	if _, err := Build(s, Options{RootID: 9}); !errors.As(err, &notFound) {
+		t.Errorf("Build() with a missing root error = %v, want a NotFoundError", err)
+	}
+	if _, err := Build(s, Options{DepartmentID: 9}); !errors.As(err, &notFound) {
+		t.Errorf("Build() with a missing department error = %v, want a NotFoundError", err)
+	}
+}
+
+// shape writes nodes as ids with their reports in parentheses.
+func shape(nodes []*Node) string {
+	var parts []string
+	for _, node := range nodes {
+		part := strconv.Itoa(node.Employee.ID)
+		if len(node.Reports) > 0 {
+			part += "(" + shape(node.Reports) + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// labelChart is a chart whose labels hold characters every format must
+// escape, which the validation rules keep out of the store.
+func labelChart() *Chart {
+	return arrange([]models.Employee{
+		{ID: 1, Name: "Carol King", Position: "CEO"},
+		{ID: 2, Name: "Alice Smith", Position: "CTO", ManagerID: 1},
+		{ID: 3, Name: `John "JD" Doe`, Position: "Developer", ManagerID: 2},
+		{ID: 4, Name: "Bob Johnson", Position: "R&D <Lead>", ManagerID: 1},
+	})
+}
+
+func TestRenderText(t *testing.T) {
+	chart := labelChart()
+
+	wantDOT := `digraph orgchart {
+	node [shape=box];
+	e1 [label="Carol King\nCEO"];
+	e2 [label="Alice Smith\nCTO"];
+	e1 -> e2;
+	e3 [label="John \"JD\" Doe\nDeveloper"];
+	e2 -> e3;
+	e4 [label="Bob Johnson\nR&D <Lead>"];
+	e1 -> e4;
+}
+`
+	if got := render(t, chart, DOT); got != wantDOT {
+		t.Errorf("Render(DOT) = %q, want %q", got, wantDOT)
+	}
+
+	wantMermaid := `graph TD
+	e1["Carol King<br/>CEO"]
+	e2["Alice Smith<br/>CTO"]
+	e1 --> e2
+	e3["John #quot;JD#quot; Doe<br/>Developer"]
+	e2 --> e3
+	e4["Bob Johnson<br/>R&D #lt;Lead#gt;"]
+	e1 --> e4
+`
+	if got := render(t, chart, Mermaid); got != wantMermaid {
+		t.Errorf("Render(Mermaid) = %q, want %q", got, wantMermaid)
+	}
+
+	if err := Render(io.Discard, chart, "png"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Render(png) error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	chart := labelChart()
+	out := render(t, chart, SVG)
+
+	// The image must be well-formed XML so browsers show it on its own.
+	decoder := xml.NewDecoder(strings.NewReader(out))
+	var texts []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SVG is not well-formed: %v\n%s", err, out)
+		}
+		if data, ok := token.(xml.CharData); ok && strings.TrimSpace(string(data)) != "" {
+			texts = append(texts, string(data))
+		}
+	}
+	want := []string{"Carol King", "CEO", "Alice Smith", "CTO", `John "JD" Doe`, "Developer", "Bob Johnson", "R&D <Lead>"}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("SVG labels = %q, want %q", texts, want)
+	}
+
+	// Managers sit centred over their reports, one row per level.
+	boxes, _, width, height := layout(chart)
+	ceo, cto, dev, lead := chart.Roots[0], chart.Roots[0].Reports[0], chart.Roots[0].Reports[0].Reports[0], chart.Roots[0].Reports[1]
+	if boxes[cto].x != boxes[dev].x || boxes[ceo].x != (boxes[cto].x+boxes[lead].x)/2 {
+		t.Errorf("layout() = %v, want managers centred over their reports", boxes)
+	}
+	if boxes[ceo].y >= boxes[cto].y || boxes[cto].y != boxes[lead].y || boxes[cto].y >= boxes[dev].y {
+		t.Errorf("layout() = %v, want one row per level", boxes)
+	}
+	if boxes[lead].x <= boxes[dev].x || width <= boxes[lead].x || height <= boxes[dev].y {
+		t.Errorf("layout() = %v in %dx%d, want separate columns inside the image", boxes, width, height)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for name, want := range map[string]Format{"svg": SVG, ".gv": DOT, "DOT": DOT, ".mmd": Mermaid, "mermaid": Mermaid} {
+		if got, ok := FormatOf(name); !ok || got != want {
+			t.Errorf("FormatOf(%q) = %q, %v, want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := FormatOf("png"); ok {
+		t.Error("FormatOf(png) ok, want unsupported")
+	}
+}
@@ -0,0 +1,95 @@
+package orgchart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"unicode/utf8"
+)
+
+// Layout of the SVG output, in pixels. Text is not measured; a label is
+// assumed to need charWidth per character.
+const (
+	padding     = 20
+	boxHeight   = 44
+	minBoxWidth = 120
+	charWidth   = 7
+	columnGap   = 16
+	rowGap      = 40
+	fontSize    = 12
+)
+
+// box is where a node is drawn. x is the centre of the box and y its top.
+type box struct {
+	x, y int
+}
+
+// layout places every node of c. Leaves take consecutive columns left to
+// right and each manager sits centred over their reports, so subtrees never
+// overlap. It returns the positions, the width of every box and the size of
+// the image.
+func layout(c *Chart) (boxes map[*Node]box, boxWidth, width, height int) {
+	boxWidth = minBoxWidth
+	c.walk(func(node, _ *Node) {
+		for _, label := range []string{node.Employee.Name, node.Employee.Position} {
+			boxWidth = max(boxWidth, utf8.RuneCountInString(label)*charWidth+2*charWidth)
+		}
+	})
+
+	boxes = make(map[*Node]box)
+	columns, depth := 0, 0
+	var place func(node *Node, level int) int
+	place = func(node *Node, level int) int {
+		depth = max(depth, level+1)
+		var x int
+		if len(node.Reports) == 0 {
+			x = padding + columns*(boxWidth+columnGap) + boxWidth/2
+			columns++
+		} else {
+			first := place(node.Reports[0], level+1)
+			last := first
+			for _, report := range node.Reports[1:] {
+				last = place(report, level+1)
+			}
+			x = (first + last) / 2
+		}
+		boxes[node] = box{x: x, y: padding + level*(boxHeight+rowGap)}
+		return x
+	}
+	for _, root := range c.Roots {
+		place(root, 0)
+	}
+
+	width = 2*padding + max(columns*(boxWidth+columnGap)-columnGap, 0)
+	height = 2*padding + max(depth*(boxHeight+rowGap)-rowGap, 0)
+	return boxes, boxWidth, width, height
+}
+
+func writeSVG(w *bufio.Writer, c *Chart) {
+	boxes, boxWidth, width, height := layout(c)
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%d">`+"\n",
+		width, height, width, height, fontSize)
+	fmt.Fprintf(w, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", width, height)
+
+	// Edges go first so the boxes are drawn over their ends.
+	c.walk(func(node, parent *Node) {
+		if parent == nil {
+			return
+		}
+		from, to := boxes[parent], boxes[node]
+		top := from.y + boxHeight
+		elbow := top + rowGap/2
+		fmt.Fprintf(w, `<path d="M%d %dV%dH%dV%d" fill="none" stroke="#666"/>`+"\n", from.x, top, elbow, to.x, to.y)
+	})
+	c.walk(func(node, _ *Node) {
+		b := boxes[node]
+		fmt.Fprintf(w, `<g><rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="#f4f6fa" stroke="#333"/>`,
+			b.x-boxWidth/2, b.y, boxWidth, boxHeight)
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="middle" font-weight="bold">%s</text>`,
+			b.x, b.y+boxHeight/2-3, html.EscapeString(node.Employee.Name))
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="middle">%s</text></g>`+"\n",
+			b.x, b.y+boxHeight/2+fontSize, html.EscapeString(node.Employee.Position))
+	})
+	w.WriteString("</svg>\n")
+}
@@ -0,0 +1,357 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type operation struct {
+	op    string
+	path  pointer
+	from  pointer
+	value any
+}
+
+type jsonPatch []operation
+
+// ParseJSONPatch parses an RFC 6902 patch: a JSON array of add, remove,
+// replace, move, copy and test operations.
+func ParseJSONPatch(data []byte) (Patch, error) {
+	var raw []struct {
+		Op    string          `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	ops := make(jsonPatch, 0, len(raw))
+	for i, r := range raw {
+		fail := func(format string, args ...any) error {
+			return &Error{Index: i, Op: r.Op, Err: fmt.Errorf(format, args...)}
+		}
+
+		switch r.Op {
+		case "add", "remove", "replace", "move", "copy", "test":
+		case "":
+			return nil, fail("missing op")
+		default:
+			return nil, fail("unknown op %q", r.Op)
+		}
+		if r.Path == nil {
+			return nil, fail("missing path")
+		}
+		op := operation{op: r.Op}
+		var err error
+		if op.path, err = parsePointer(*r.Path); err != nil {
+			return nil, fail("path: %v", err)
+		}
+
+		switch r.Op {
+		case "move", "copy":
+			if r.From == nil {
+				return nil, fail("missing from")
+			}
+			if op.from, err = parsePointer(*r.From); err != nil {
+				return nil, fail("from: %v", err)
+			}
+			if r.Op == "move" && op.from.isProperPrefixOf(op.path) {
+				return nil, fail("cannot move a value into one of its own children")
+			}
+		case "add", "replace", "test":
+			// A missing value and an explicit null are different things.
+			if r.Value == nil {
+				return nil, fail("missing value")
+			}
+			if op.value, err = decode(r.Value); err != nil {
+				return nil, fail("value: %v", err)
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func (p jsonPatch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range p {
+		if root, err = op.apply(root); err != nil {
+			return nil, &Error{Index: i, Op: op.op, Err: err}
+		}
+	}
+	return json.Marshal(root)
+}
+
+func (op operation) apply(root any) (any, error) {
+	switch op.op {
+	case "add":
+		return add(root, op.path, deepCopy(op.value))
+	case "remove":
+		root, _, err := remove(root, op.path)
+		return root, err
+	case "replace":
+		if len(op.path) == 0 {
+			return deepCopy(op.value), nil
+		}
+		root, _, err := remove(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, deepCopy(op.value))
+	case "move":
+		root, value, err := remove(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, value)
+	case "copy":
+		value, err := get(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, deepCopy(value))
+	case "test":
+		value, err := get(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.value) {
+			return nil, fmt.Errorf("%w: value at %s differs", ErrTestFailed, op.path)
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.op)
+}
+
+// pointer is a parsed JSON Pointer (RFC 6901); the empty pointer refers to
+// the whole document.
+type pointer []string
+
+func parsePointer(s string) (pointer, error) {
+	if s == "" {
+		return pointer{}, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("pointer %q must start with /", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		// ~1 must be decoded before ~0 so "~01" becomes "~1", not "/".
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func (p pointer) String() string {
+	var b strings.Builder
+	for _, token := range p {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	if b.Len() == 0 {
+		return `""`
+	}
+	return b.String()
+}
+
+func (p pointer) isProperPrefixOf(q pointer) bool {
+	if len(p) >= len(q) {
+		return false
+	}
+	for i := range p {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(root any, path pointer) (any, error) {
+	node := root
+	for i, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", path[:i+1])
+			}
+			node = child
+		case []any:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path[:i+1], err)
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("%s is not a container", path[:i])
+		}
+	}
+	return node, nil
+}
+
+// update replaces the container that holds the target of path with what fn
+// returns for it, and rebuilds the document around it. Arrays must be
+// rebuilt because inserting or removing changes the slice header.
+func update(root any, path pointer, fn func(parent any, token string) (any, error)) (any, error) {
+	parentPath, token := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(root, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fn(parent, token)
+	if err != nil {
+		return nil, err
+	}
+	if len(parentPath) == 0 {
+		return updated, nil
+	}
+	return update(root, parentPath, func(grandparent any, token string) (any, error) {
+		switch g := grandparent.(type) {
+		case map[string]any:
+			g[token] = updated
+		case []any:
+			index, _ := arrayIndex(token, len(g)-1)
+			g[index] = updated
+		}
+		return grandparent, nil
+	})
+}
+
+func add(root any, path pointer, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(root, path, func(parent any, token string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			p[token] = value
+			return p, nil
+		case []any:
+			if token == "-" {
+				return append(p, value), nil
+			}
+			index, err := arrayIndex(token, len(p))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			p = append(p, nil)
+			copy(p[index+1:], p[index:])
+			p[index] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("%s is not a container", path[:len(path)-1])
+		}
+	})
+}
+
+// remove deletes the value at path and returns the new document along with
+// the removed value.
+func remove(root any, path pointer) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	var removed any
+	root, err := update(root, path, func(parent any, token string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			value, ok := p[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", path)
+			}
+			removed = value
+			delete(p, token)
+			return p, nil
+		case []any:
+			index, err := arrayIndex(token, len(p)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			removed = p[index]
+			return append(p[:index], p[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%s is not a container", path[:len(path)-1])
+		}
+	})
+	return root, removed, err
+}
+
+// arrayIndex parses an array index token, which must be a plain decimal
+// number no greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.Trim(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, fmt.Errorf("array index %s out of range", token)
+	}
+	return index, nil
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for name, value := range v {
+			c[name] = deepCopy(value)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, value := range v {
+			c[i] = deepCopy(value)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// equal compares decoded JSON values as RFC 6902 section 4.6 requires:
+// numbers by value, objects regardless of member order.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okA := new(big.Float).SetString(a.String())
+		y, okB := new(big.Float).SetString(b.String())
+		return okA && okB && x.Cmp(y) == 0
+	default:
+		return a == b
+	}
+}
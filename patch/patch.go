@@ -0,0 +1,105 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+//
+// A patch is parsed once and can then be applied to any number of documents.
+// Applying works on a decoded copy of the document, so a patch that fails
+// part-way leaves nothing half-applied.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Media types of the supported patch formats.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// ErrTestFailed is wrapped by the Error returned when a JSON Patch test
+// operation does not match the document.
+var ErrTestFailed = errors.New("test failed")
+
+// Patch is a parsed patch document.
+type Patch interface {
+	// Apply returns doc with the patch applied.
+	Apply(doc []byte) ([]byte, error)
+}
+
+// Error describes a JSON Patch operation that is malformed or could not be
+// applied. Index is the operation's position in the patch.
+type Error struct {
+	Index int
+	Op    string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// decode parses data keeping numbers as json.Number, so values the patch does
+// not touch come back out exactly as they went in.
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// ParseMergePatch parses an RFC 7396 merge patch.
+func ParseMergePatch(data []byte) (Patch, error) {
+	v, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return mergePatch{v}, nil
+}
+
+type mergePatch struct {
+	value any
+}
+
+func (p mergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p.value))
+}
+
+// merge implements the MergePatch function of RFC 7396 section 2: objects are
+// merged member by member, null removes a member and anything else replaces
+// the target outright.
+func merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	result, ok := target.(map[string]any)
+	if !ok {
+		result = make(map[string]any)
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(result, name)
+		} else {
+			result[name] = merge(result[name], value)
+		}
+	}
+	return result
+}
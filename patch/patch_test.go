@@ -0,0 +1,170 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func jsonEqual(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want is not JSON: %v", err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func TestMergePatch(t *testing.T) {
+	// Cases from RFC 7396 appendix A.
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		p, err := ParseMergePatch([]byte(tt.patch))
+		if err != nil {
+			t.Fatalf("ParseMergePatch(%s): %v", tt.patch, err)
+		}
+		got, err := p.Apply([]byte(tt.doc))
+		if err != nil {
+			t.Fatalf("Apply(%s, %s): %v", tt.doc, tt.patch, err)
+		}
+		if !jsonEqual(t, got, tt.want) {
+			t.Errorf("Apply(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append", `{"foo":[1]}`, `[{"op":"add","path":"/foo/-","value":2}]`, `{"foo":[1,2]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"replace root", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{"move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{"test then replace", `{"salary":70000}`, `[{"op":"test","path":"/salary","value":7e4},{"op":"replace","path":"/salary","value":75000}]`, `{"salary":75000}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+		{"add null", `{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseJSONPatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ParseJSONPatch: %v", err)
+			}
+			got, err := p.Apply([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("Apply = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchAppliedTwice(t *testing.T) {
+	// Later operations change the values added earlier; they must not
+	// reach back into the parsed patch.
+	p, err := ParseJSONPatch([]byte(`[
+		{"op":"add","path":"/meta","value":{}},
+		{"op":"test","path":"/meta","value":{}},
+		{"op":"add","path":"/meta/x","value":1},
+		{"op":"replace","path":"/tags","value":[]},
+		{"op":"add","path":"/tags/-","value":"a"}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseJSONPatch: %v", err)
+	}
+	want := `{"meta":{"x":1},"tags":["a"]}`
+	for i := range 2 {
+		got, err := p.Apply([]byte(`{"tags":["b"]}`))
+		if err != nil {
+			t.Fatalf("Apply #%d: %v", i+1, err)
+		}
+		if !jsonEqual(t, got, want) {
+			t.Errorf("Apply #%d = %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	parseErrors := []string{
+		`{"op":"add"}`,
+		`[{"path":"/a","value":1}]`,
+		`[{"op":"frobnicate","path":"/a"}]`,
+		`[{"op":"add","value":1}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"add","path":"a","value":1}]`,
+		`[{"op":"copy","path":"/a"}]`,
+		`[{"op":"move","from":"/a","path":"/a/b"}]`,
+	}
+	for _, patch := range parseErrors {
+		if _, err := ParseJSONPatch([]byte(patch)); err == nil {
+			t.Errorf("ParseJSONPatch(%s) succeeded, want an error", patch)
+		}
+	}
+
+	applyErrors := []struct {
+		patch      string
+		testFailed bool
+	}{
+		{`[{"op":"remove","path":"/missing"}]`, false},
+		{`[{"op":"replace","path":"/missing","value":1}]`, false},
+		{`[{"op":"add","path":"/list/5","value":1}]`, false},
+		{`[{"op":"add","path":"/list/01","value":1}]`, false},
+		{`[{"op":"add","path":"/missing/child","value":1}]`, false},
+		{`[{"op":"test","path":"/name","value":"Bob"}]`, true},
+		{`[{"op":"replace","path":"/name","value":"Bob"},{"op":"test","path":"/list","value":[2,1]}]`, true},
+	}
+	doc := []byte(`{"name":"Alice","list":[1,2]}`)
+	for _, tt := range applyErrors {
+		p, err := ParseJSONPatch([]byte(tt.patch))
+		if err != nil {
+			t.Fatalf("ParseJSONPatch(%s): %v", tt.patch, err)
+		}
+		_, err = p.Apply(doc)
+		var patchErr *Error
+		if !errors.As(err, &patchErr) {
+			t.Errorf("Apply(%s) error = %v, want an *Error", tt.patch, err)
+			continue
+		}
+		if got := errors.Is(err, ErrTestFailed); got != tt.testFailed {
+			t.Errorf("Apply(%s) error = %v, test failed = %v, want %v", tt.patch, err, got, tt.testFailed)
+		}
+	}
+	if string(doc) != `{"name":"Alice","list":[1,2]}` {
+		t.Errorf("failed patches modified the document: %s", doc)
+	}
+}
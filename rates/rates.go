@@ -0,0 +1,390 @@
+// Package rates keeps a table of dated exchange rates, optionally backed by
+// a CSV file, and converts money between currencies with it.
+package rates
+
+import (
+	"ems/models"
+	"ems/validation"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrNoRate is returned when the table has no rate between two currencies
+// on or before the day asked about.
+var ErrNoRate = errors.New("no exchange rate")
+
+// csvHeader is the header of a rates file. Its columns may come in any
+// order.
+var csvHeader = []string{"date", "from", "to", "rate"}
+
+// derivedPlaces is how many decimal places inverted and crossed rates are
+// shown with. Conversions use them exactly.
+const derivedPlaces = 10
+
+type pair struct{ from, to string }
+
+// entry is one published rate of a pair.
+type entry struct {
+	date  string
+	rate  *big.Rat
+	value string
+}
+
+// Table is a set of dated exchange rates. It is safe for concurrent use.
+type Table struct {
+	mu    sync.RWMutex
+	pairs map[pair][]entry // by date
+	// path is the file the table is saved to, or empty to keep it in
+	// memory only.
+	path string
+}
+
+// New returns an empty table kept in memory.
+func New() *Table {
+	return &Table{pairs: map[pair][]entry{}}
+}
+
+// Open returns the table kept in the CSV file at path, which is rewritten
+// whenever rates are added. A missing file is an empty table.
+func Open(path string) (*Table, error) {
+	t := New()
+	t.path = path
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rates, err := ReadCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	rates = normalize(rates)
+	if err := validation.ExchangeRates(rates); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t.pairs = merge(t.pairs, rates)
+	return t, nil
+}
+
+// ReadCSV reads rates from a CSV file with a date, from, to and rate
+// column, named in its header row.
+func ReadCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("missing header row")
+	}
+	columns := make([]int, len(csvHeader))
+	for i, name := range csvHeader {
+		columns[i] = slices.IndexFunc(rows[0], func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), name)
+		})
+		if columns[i] < 0 {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+	rates := make([]models.ExchangeRate, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rates = append(rates, models.ExchangeRate{
+			Date: strings.TrimSpace(row[columns[0]]),
+			From: strings.TrimSpace(row[columns[1]]),
+			To:   strings.TrimSpace(row[columns[2]]),
+			Rate: strings.TrimSpace(row[columns[3]]),
+		})
+	}
+	return rates, nil
+}
+
+// WriteCSV writes rates in the format ReadCSV reads.
+func WriteCSV(w io.Writer, rates []models.ExchangeRate) error {
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	for _, r := range rates {
+		writer.Write([]string{r.Date, r.From, r.To, r.Rate})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Add records rates, replacing any published on the same day for the same
+// currencies. Currency codes are upper-cased first. Invalid rates are
+// reported as validation.Errors and none are added. When the table is
+// backed by a file it is saved before Add returns.
+func (t *Table) Add(rates []models.ExchangeRate) error {
+	rates = normalize(rates)
+	if err := validation.ExchangeRates(rates); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pairs := merge(t.pairs, rates)
+	if t.path != "" {
+		if err := save(t.path, list(pairs)); err != nil {
+			return err
+		}
+	}
+	t.pairs = pairs
+	return nil
+}
+
+// normalize returns a copy of rates with spaces trimmed and currency codes
+// upper-cased.
+func normalize(rates []models.ExchangeRate) []models.ExchangeRate {
+	rates = slices.Clone(rates)
+	for i := range rates {
+		rates[i].Date = strings.TrimSpace(rates[i].Date)
+		rates[i].From = strings.ToUpper(strings.TrimSpace(rates[i].From))
+		rates[i].To = strings.ToUpper(strings.TrimSpace(rates[i].To))
+		rates[i].Rate = strings.TrimSpace(rates[i].Rate)
+	}
+	return rates
+}
+
+// merge returns a copy of pairs with rates added, which must be valid.
+func merge(pairs map[pair][]entry, rates []models.ExchangeRate) map[pair][]entry {
+	merged := make(map[pair][]entry, len(pairs))
+	for p, entries := range pairs {
+		merged[p] = slices.Clone(entries)
+	}
+	for _, r := range rates {
+		rate, _ := new(big.Rat).SetString(r.Rate)
+		e := entry{date: r.Date, rate: rate, value: r.Rate}
+		p := pair{r.From, r.To}
+		entries := merged[p]
+		i, found := slices.BinarySearchFunc(entries, e.date, func(e entry, date string) int {
+			return strings.Compare(e.date, date)
+		})
+		if found {
+			entries[i] = e
+		} else {
+			entries = slices.Insert(entries, i, e)
+		}
+		merged[p] = entries
+	}
+	return merged
+}
+
+// save writes rates to a temporary file and renames it over path, so a
+// failed write leaves the previous file whole.
+func save(path string, rates []models.ExchangeRate) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := WriteCSV(f, rates); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Rates returns every rate in the table by date, then currencies.
+func (t *Table) Rates() []models.ExchangeRate {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return list(t.pairs)
+}
+
+func list(pairs map[pair][]entry) []models.ExchangeRate {
+	rates := []models.ExchangeRate{}
+	for p, entries := range pairs {
+		for _, e := range entries {
+			rates = append(rates, models.ExchangeRate{Date: e.date, From: p.from, To: p.to, Rate: e.value})
+		}
+	}
+	slices.SortFunc(rates, func(a, b models.ExchangeRate) int {
+		if c := strings.Compare(a.Date, b.Date); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.From, b.From); c != 0 {
+			return c
+		}
+		return strings.Compare(a.To, b.To)
+	})
+	return rates
+}
+
+// quote is a rate from one currency to another and the day it dates from.
+type quote struct {
+	rate *big.Rat
+	date string
+}
+
+// Rate returns the rate from one currency to another in effect on day, in
+// models.DateLayout. That is the most recently published rate on or before
+// day, whether for the pair itself, for the opposite pair inverted, or
+// crossed through a third currency, in which case it dates from the older
+// of the two rates it is made of. On equal dates a published rate is
+// preferred to an inverted one, and both to a crossed one.
+func (t *Table) Rate(from, to, day string) (models.ExchangeRate, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	q, err := t.quote(from, to, day)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	return models.ExchangeRate{Date: q.date, From: from, To: to, Rate: formatRate(q.rate)}, nil
+}
+
+// quote looks up the rate Rate describes. Callers must hold t.mu.
+func (t *Table) quote(from, to, day string) (quote, error) {
+	best, ok := t.published(from, to, day)
+	var pivots []string
+	for p := range t.pairs {
+		if p.from == from && p.to != to {
+			pivots = append(pivots, p.to)
+		}
+		if p.to == from && p.from != to {
+			pivots = append(pivots, p.from)
+		}
+	}
+	slices.Sort(pivots)
+	for _, pivot := range slices.Compact(pivots) {
+		first, ok1 := t.published(from, pivot, day)
+		second, ok2 := t.published(pivot, to, day)
+		if !ok1 || !ok2 {
+			continue
+		}
+		date := min(first.date, second.date)
+		if !ok || date > best.date {
+			best, ok = quote{rate: new(big.Rat).Mul(first.rate, second.rate), date: date}, true
+		}
+	}
+	if !ok {
+		return quote{}, fmt.Errorf("%w from %s to %s on or before %s", ErrNoRate, from, to, day)
+	}
+	return best, nil
+}
+
+// published returns the latest rate on or before day for the pair from,
+// to, or the opposite pair inverted.
+func (t *Table) published(from, to, day string) (quote, bool) {
+	direct, ok := latest(t.pairs[pair{from, to}], day)
+	if inverse, found := latest(t.pairs[pair{to, from}], day); found && (!ok || inverse.date > direct.date) {
+		return quote{rate: new(big.Rat).Inv(inverse.rate), date: inverse.date}, true
+	}
+	if !ok {
+		return quote{}, false
+	}
+	return quote{rate: direct.rate, date: direct.date}, true
+}
+
+// latest returns the last of entries dated on or before day.
+func latest(entries []entry, day string) (entry, bool) {
+	i, found := slices.BinarySearchFunc(entries, day, func(e entry, day string) int {
+		return strings.Compare(e.date, day)
+	})
+	if found {
+		return entries[i], true
+	}
+	if i == 0 {
+		return entry{}, false
+	}
+	return entries[i-1], true
+}
+
+// formatRate writes a rate exactly when it has few enough decimal places,
+// and rounded to derivedPlaces otherwise.
+func formatRate(rate *big.Rat) string {
+	s := rate.FloatString(derivedPlaces)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Converter converts amounts to one currency with the rates in effect on a
+// day, remembering the rates it used. It is not safe for concurrent use.
+type Converter struct {
+	table    *Table
+	currency string
+	day      string
+	used     map[string]quote
+}
+
+// Converter returns a converter to currency, an ISO 4217 code, with the
+// rates in effect on day.
+func (t *Table) Converter(currency, day string) *Converter {
+	return &Converter{table: t, currency: currency, day: day, used: map[string]quote{}}
+}
+
+// Currency returns the ISO 4217 code of the currency c converts to.
+func (c *Converter) Currency() string { return c.currency }
+
+// Convert returns m in the converter's currency, rounded half away from
+// zero to the currency's minor unit. Amounts already in that currency are
+// returned as they are.
+func (c *Converter) Convert(m models.Money) (models.Money, error) {
+	if m.Currency() == c.currency {
+		return m, nil
+	}
+	q, ok := c.used[m.Currency()]
+	if !ok {
+		c.table.mu.RLock()
+		var err error
+		q, err = c.table.quote(m.Currency(), c.currency, c.day)
+		c.table.mu.RUnlock()
+		if err != nil {
+			return models.Money{}, err
+		}
+		c.used[m.Currency()] = q
+	}
+
+	step := int64(1)
+	if digits, ok := models.CurrencyDigits(c.currency); ok {
+		for range models.MoneyScale - digits {
+			step *= 10
+		}
+	}
+	amount := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Units()), q.rate)
+	amount.Quo(amount, new(big.Rat).SetInt64(step))
+	units, rem := new(big.Int).QuoRem(amount.Num(), amount.Denom(), new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(amount.Denom()) >= 0 {
+		units.Add(units, big.NewInt(int64(amount.Sign())))
+	}
+	units.Mul(units, big.NewInt(step))
+	if !units.IsInt64() {
+		return models.Money{}, fmt.Errorf("%s is too large to convert to %s", m, c.currency)
+	}
+	return models.NewMoney(units.Int64(), c.currency), nil
+}
+
+// Conversion records the rates the converter has used so far, by the
+// currency converted from.
+func (c *Converter) Conversion() *models.Conversion {
+	conversion := &models.Conversion{Currency: c.currency, AsOf: c.day, Rates: []models.ExchangeRate{}}
+	for from, q := range c.used {
+		conversion.Rates = append(conversion.Rates, models.ExchangeRate{
+			Date: q.date,
+			From: from,
+			To:   c.currency,
+			Rate: formatRate(q.rate),
+		})
+	}
+	slices.SortFunc(conversion.Rates, func(a, b models.ExchangeRate) int {
+		return strings.Compare(a.From, b.From)
+	})
+	return conversion
+}
@@ -0,0 +1,204 @@
+package rates
+
+import (
+	"ems/models"
+	"ems/validation"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestTable(t *testing.T) *Table {
+	t.Helper()
+	table := New()
+	err := table.Add([]models.ExchangeRate{
+		{Date: "2026-01-02", From: "EUR", To: "USD", Rate: "1.10"},
+		{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.20"},
+		{Date: "2026-01-15", From: "usd", To: "jpy", Rate: "150"},
+		{Date: "2026-03-01", From: "GBP", To: "EUR", Rate: "1.25"},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	return table
+}
+
+func TestRate(t *testing.T) {
+	table := newTestTable(t)
+	tests := []struct {
+		name     string
+		from, to string
+		day      string
+		want     models.ExchangeRate
+		err      error
+	}{
+		{
+			name: "Published on the day",
+			from: "EUR", to: "USD", day: "2026-02-02",
+			want: models.ExchangeRate{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.2"},
+		},
+		{
+			name: "Latest before the day",
+			from: "EUR", to: "USD", day: "2026-02-01",
+			want: models.ExchangeRate{Date: "2026-01-02", From: "EUR", To: "USD", Rate: "1.1"},
+		},
+		{
+			name: "Inverted",
+			from: "USD", to: "EUR", day: "2026-03-01",
+			want: models.ExchangeRate{Date: "2026-02-02", From: "USD", To: "EUR", Rate: "0.8333333333"},
+		},
+		{
+			name: "Crossed dates from the older rate",
+			from: "GBP", to: "USD", day: "2026-04-01",
+			want: models.ExchangeRate{Date: "2026-02-02", From: "GBP", To: "USD", Rate: "1.5"},
+		},
+		{
+			name: "Crossed through an inverted rate",
+			from: "EUR", to: "JPY", day: "2026-02-02",
+			want: models.ExchangeRate{Date: "2026-01-15", From: "EUR", To: "JPY", Rate: "180"},
+		},
+		{name: "Before any rate", from: "EUR", to: "USD", day: "2025-12-31", err: ErrNoRate},
+		{name: "Unknown currency", from: "CHF", to: "USD", day: "2026-04-01", err: ErrNoRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table.Rate(tt.from, tt.to, tt.day)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("Rate(%s, %s, %s) = %+v, %v, want %+v, %v", tt.from, tt.to, tt.day, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestAddReplacesSameDay(t *testing.T) {
+	table := newTestTable(t)
+	if err := table.Add([]models.ExchangeRate{{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.25"}}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	got, err := table.Rate("EUR", "USD", "2026-02-02")
+	if err != nil || got.Rate != "1.25" {
+		t.Errorf("Rate() = %+v, %v, want the replacing rate 1.25", got, err)
+	}
+	if n := len(table.Rates()); n != 4 {
+		t.Errorf("len(Rates()) = %d, want 4", n)
+	}
+}
+
+func TestAddRejectsInvalidRates(t *testing.T) {
+	table := newTestTable(t)
+	err := table.Add([]models.ExchangeRate{
+		{Date: "2026-04-01", From: "EUR", To: "USD", Rate: "1.3"},
+		{Date: "01/04/2026", From: "EUR", To: "EUR", Rate: "-1"},
+	})
+	var violations validation.Errors
+	if !errors.As(err, &violations) {
+		t.Fatalf("Add() error = %v, want validation errors", err)
+	}
+	var fields []string
+	for _, v := range violations {
+		fields = append(fields, v.Field)
+	}
+	if want := []string{"rates[1].date", "rates[1].to", "rates[1].rate"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("violations = %v, want fields %v", violations, want)
+	}
+	if got, _ := table.Rate("EUR", "USD", "2026-04-01"); got.Rate != "1.2" {
+		t.Errorf("valid rate of a rejected Add() was added: %+v", got)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	table := newTestTable(t)
+	c := table.Converter("USD", "2026-03-15")
+	tests := []struct {
+		salary models.Money
+		want   models.Money
+	}{
+		{models.MustParseMoney("50000", "EUR"), models.MustParseMoney("60000", "USD")},
+		{models.MustParseMoney("0.01", "EUR"), models.MustParseMoney("0.01", "USD")},
+		{models.MustParseMoney("1000.5", "USD"), models.MustParseMoney("1000.5", "USD")},
+		// 1000 JPY is 6.666... USD.
+		{models.MustParseMoney("1000", "JPY"), models.MustParseMoney("6.67", "USD")},
+		{models.MustParseMoney("40000", "GBP"), models.MustParseMoney("60000", "USD")},
+	}
+	for _, tt := range tests {
+		got, err := c.Convert(tt.salary)
+		if err != nil || got != tt.want {
+			t.Errorf("Convert(%v) = %v, %v, want %v", tt.salary, got, err, tt.want)
+		}
+	}
+	if _, err := c.Convert(models.MustParseMoney("1", "CHF")); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Convert(1 CHF) error = %v, want ErrNoRate", err)
+	}
+
+	want := &models.Conversion{
+		Currency: "USD",
+		AsOf:     "2026-03-15",
+		Rates: []models.ExchangeRate{
+			{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.2"},
+			{Date: "2026-02-02", From: "GBP", To: "USD", Rate: "1.5"},
+			{Date: "2026-01-15", From: "JPY", To: "USD", Rate: "0.0066666667"},
+		},
+	}
+	if got := c.Conversion(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conversion() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConvertRoundsToMinorUnit(t *testing.T) {
+	c := newTestTable(t).Converter("JPY", "2026-03-15")
+	got, err := c.Convert(models.MustParseMoney("10.01", "USD"))
+	if want := models.MustParseMoney("1502", "JPY"); err != nil || got != want {
+		t.Errorf("Convert(10.01 USD) = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestOpenSavesAddedRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	table, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() of a missing file error = %v", err)
+	}
+	if err := table.Add([]models.ExchangeRate{
+		{Date: "2026-02-02", From: "EUR", To: "USD", Rate: "1.20"},
+		{Date: "2026-01-02", From: "eur", To: "usd", Rate: "1.10"},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := "date,from,to,rate\n2026-01-02,EUR,USD,1.10\n2026-02-02,EUR,USD,1.20\n"
+	if string(data) != want {
+		t.Errorf("rates file = %q, want %q", data, want)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !reflect.DeepEqual(reopened.Rates(), table.Rates()) {
+		t.Errorf("reopened Rates() = %v, want %v", reopened.Rates(), table.Rates())
+	}
+}
+
+func TestOpenRejectsInvalidFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"Missing column": "date,from,rate\n2026-01-02,EUR,1.1\n",
+		"Invalid rate":   "rate,to,from,date\nlots,USD,EUR,2026-01-02\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rates.csv")
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Open(path); err == nil || !strings.Contains(err.Error(), path) {
+				t.Errorf("Open() error = %v, want one naming the file", err)
+			}
+		})
+	}
+}
@@ -2,18 +2,48 @@ package router
 
 import (
 	"ems/handlers"
+	"ems/store"
+	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-func SetupRouter() *mux.Router {
+func SetupRouter(repo store.EmployeeRepository, opts ...handlers.Option) *mux.Router {
 	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(handlers.NotFoundHandler)
+	router.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowedHandler)
+	h := handlers.NewEmployeeHandler(repo, opts...)
 
-	router.HandleFunc("/employees", handlers.CreateEmployeeHandler).Methods("POST")
-	router.HandleFunc("/employees", handlers.ListEmployeesHandler).Methods("GET")
-	router.HandleFunc("/employees/{id}", handlers.GetEmployeeHandler).Methods("GET")
-	router.HandleFunc("/employees/{id}", handlers.UpdateEmployeeHandler).Methods("PUT")
-	router.HandleFunc("/employees/{id}", handlers.DeleteEmployeeHandler).Methods("DELETE")
+	router.HandleFunc("/employees", h.CreateEmployeeHandler).Methods("POST")
+	router.HandleFunc("/employees", h.ListEmployeesHandler).Methods("GET")
+	router.HandleFunc("/employees:batch", h.BatchEmployeesHandler).Methods("POST")
+	router.HandleFunc("/employees:import", h.ImportEmployeesHandler).Methods("POST")
+	router.HandleFunc("/employees:export", h.ExportEmployeesHandler).Methods("GET")
+	router.HandleFunc("/employees/search", h.SearchEmployeesHandler).Methods("GET")
+	router.HandleFunc("/employees/{id}", h.GetEmployeeHandler).Methods("GET")
+	router.HandleFunc("/employees/{id}", h.UpdateEmployeeHandler).Methods("PUT")
+	router.HandleFunc("/employees/{id}", h.PatchEmployeeHandler).Methods("PATCH")
+	router.HandleFunc("/employees/{id}", h.DeleteEmployeeHandler).Methods("DELETE")
+	router.HandleFunc("/employees/{id}/reports", h.DirectReportsHandler).Methods("GET")
+	router.HandleFunc("/employees/{id}/chain", h.ManagementChainHandler).Methods("GET")
+	router.HandleFunc("/employees/{id}/subtree", h.SubtreeHandler).Methods("GET")
+	router.HandleFunc("/employees/{id}/compensation", h.CompensationHistoryHandler).Methods("GET")
+	router.HandleFunc("/employees/{id}/compensation", h.AddCompensationHandler).Methods("POST")
+
+	router.HandleFunc("/departments", h.CreateDepartmentHandler).Methods("POST")
+	router.HandleFunc("/departments", h.ListDepartmentsHandler).Methods("GET")
+	router.HandleFunc("/departments/{id}", h.GetDepartmentHandler).Methods("GET")
+	router.HandleFunc("/departments/{id}", h.UpdateDepartmentHandler).Methods("PUT")
+	router.HandleFunc("/departments/{id}", h.DeleteDepartmentHandler).Methods("DELETE")
+	router.HandleFunc("/departments/{id}/employees", h.DepartmentEmployeesHandler).Methods("GET")
+
+	router.HandleFunc("/orgchart", h.OrgChartHandler).Methods("GET")
+	router.HandleFunc("/reports/salaries", h.SalaryReportHandler).Methods("GET")
+
+	router.HandleFunc("/admin/rates", h.ListRatesHandler).Methods("GET")
+	if h.RatesAdmin() {
+		router.HandleFunc("/admin/rates", h.AddRatesHandler).Methods("POST")
+	}
 
 	return router
 }
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"ems/models"
+	"errors"
+	"fmt"
+)
+
+// BatchAction is what a BatchOp does.
+type BatchAction string
+
+const (
+	BatchCreate BatchAction = "create"
+	BatchUpdate BatchAction = "update"
+	BatchDelete BatchAction = "delete"
+)
+
+// BatchOp is one operation of a batch. ID is ignored for creates, and the
+// employee fields are ignored for deletes. When Version is set, an update or
+// delete fails with a ConflictError unless the employee is still at that
+// version.
+type BatchOp struct {
+	Action       BatchAction
+	ID           int
+	Name         string
+	Position     string
+	Salary       models.Money
+	DepartmentID int
+	ManagerID    int
+	Version      int
+}
+
+// BatchResult is the outcome of the BatchOp at the same index. Employee is
+// the created, updated or deleted employee when Err is nil.
+type BatchResult struct {
+	Employee models.Employee
+	Err      error
+}
+
+// ErrBatchAborted is the error of every operation in an atomic batch that
+// would have succeeded but was rolled back because another one failed.
+var ErrBatchAborted = errors.New("not applied because another operation in the batch failed")
+
+// resolveBatchOp works out the employee op writes or deletes, given the
+// current employee and whether it exists, and checks it with validate.
+// Creates get their id from the caller.
+func resolveBatchOp(op BatchOp, current models.Employee, exists bool, validate func(models.Employee) error) (models.Employee, error) {
+	if op.Action == BatchCreate {
+		employee := models.Employee{
+			Name:         op.Name,
+			Position:     op.Position,
+			Salary:       op.Salary.DefaultTo(models.DefaultCurrency),
+			DepartmentID: op.DepartmentID,
+			ManagerID:    op.ManagerID,
+			Version:      1,
+		}
+		return employee, validate(employee)
+	}
+	if op.Action != BatchUpdate && op.Action != BatchDelete {
+		return models.Employee{}, fmt.Errorf("unknown batch action %q", op.Action)
+	}
+
+	if !exists {
+		return models.Employee{}, &NotFoundError{ID: op.ID}
+	}
+	if op.Version != 0 && op.Version != current.Version {
+		return models.Employee{}, &ConflictError{
+			Msg: fmt.Sprintf("employee %d is at version %d, not %d", op.ID, current.Version, op.Version),
+		}
+	}
+	if op.Action == BatchDelete {
+		return current, nil
+	}
+
+	employee := current
+	employee.Name = op.Name
+	employee.Position = op.Position
+	employee.Salary = op.Salary.DefaultTo(current.Salary.Currency())
+	employee.DepartmentID = op.DepartmentID
+	employee.ManagerID = op.ManagerID
+	employee.Version++
+	return employee, validate(employee)
+}
+
+// abortBatch marks every successful result as rolled back if any operation
+// failed, and reports whether it did.
+func abortBatch(results []BatchResult) bool {
+	failed := false
+	for _, result := range results {
+		if result.Err != nil {
+			failed = true
+			break
+		}
+	}
+	if !failed {
+		return false
+	}
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = BatchResult{Err: ErrBatchAborted}
+		}
+	}
+	return true
+}
+
+// batchChange is one write staged by a batch, with the salary entry it
+// records, if any.
+type batchChange struct {
+	action       BatchAction
+	employee     models.Employee
+	compensation *models.Compensation
+}
+
+// stageBatch runs ops against an overlay of the store's map, so later
+// operations see the effect of earlier ones, and returns the results, the
+// writes to make and the next free id. Failed operations stage nothing.
+// Callers must hold s.mu.
+func (s *MemoryStore) stageBatch(ops []BatchOp) ([]BatchResult, []batchChange, int) {
+	var (
+		results = make([]BatchResult, len(ops))
+		changes []batchChange
+		staged  = make(map[int]*models.Employee)
+		nextID  = s.nextID
+		// Entries are numbered as they are staged; record moves
+		// s.nextCompensationID past them when they are applied.
+		nextCompensationID = s.nextCompensationID
+	)
+	lookup := func(id int) (models.Employee, bool) {
+		if e, ok := staged[id]; ok {
+			if e == nil {
+				return models.Employee{}, false
+			}
+			return *e, true
+		}
+		e, ok := s.employees[id]
+		return e, ok
+	}
+
+	for i, op := range ops {
+		current, exists := lookup(op.ID)
+		employee, err := resolveBatchOp(op, current, exists, func(e models.Employee) error {
+			return s.validateWith(e, lookup)
+		})
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		switch op.Action {
+		case BatchCreate:
+			employee.ID = nextID
+			nextID++
+			staged[employee.ID] = &employee
+		case BatchUpdate:
+			staged[employee.ID] = &employee
+		case BatchDelete:
+			// Reports staged by this batch are not in the index yet.
+			var extra []int
+			for id, e := range staged {
+				if e != nil && e.ManagerID == employee.ID {
+					extra = append(extra, id)
+				}
+			}
+			for _, report := range s.reassignReports(employee, lookup, extra) {
+				staged[report.ID] = &report
+				changes = append(changes, batchChange{action: BatchUpdate, employee: report})
+			}
+			staged[employee.ID] = nil
+		}
+		results[i].Employee = employee
+		change := batchChange{action: op.Action, employee: employee}
+		if op.Action != BatchDelete {
+			var before *models.Employee
+			if op.Action == BatchUpdate {
+				before = &current
+			}
+			if entry, ok := s.salaryEntry(before, employee); ok {
+				entry.ID = nextCompensationID
+				nextCompensationID++
+				change.compensation = &entry
+			}
+		}
+		changes = append(changes, change)
+	}
+	return results, changes, nextID
+}
+
+// ApplyBatch runs ops in order. With atomic set, nothing is written unless
+// every operation succeeds; otherwise each operation stands on its own. The
+// error is for failures of the store itself, not of individual operations.
+func (s *MemoryStore) ApplyBatch(ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results, changes, nextID := s.stageBatch(ops)
+	if atomic && abortBatch(results) {
+		return results, nil
+	}
+	for _, c := range changes {
+		if c.action == BatchDelete {
+			s.remove(c.employee.ID)
+		} else {
+			s.put(c.employee)
+		}
+		if c.compensation != nil {
+			s.record(*c.compensation)
+		}
+	}
+	s.nextID = nextID
+	return results, nil
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"ems/models"
+	"errors"
+	"testing"
+)
+
+func TestApplyBatch(t *testing.T) {
+	newStores := func(t *testing.T) map[string]EmployeeRepository {
+		journal, err := NewJournalStore(t.TempDir(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { journal.Close() })
+		return map[string]EmployeeRepository{
+			"memory":  NewMemoryStore(),
+			"sqlite":  newTestSQLiteStore(t),
+			"journal": journal,
+		}
+	}
+	ops := []BatchOp{
+		{Action: BatchCreate, Name: "Alice Smith", Position: "Manager", Salary: usd("80000")},
+		{Action: BatchUpdate, ID: 1, Name: "John Doe", Position: "Senior Developer", Salary: usd("70000"), Version: 1},
+		{Action: BatchDelete, ID: 2},
+		{Action: BatchUpdate, ID: 9, Name: "Nobody", Position: "Developer", Salary: usd("1")},
+		{Action: BatchCreate, Name: "", Position: "Developer", Salary: usd("1")},
+		{Action: BatchUpdate, ID: 1, Name: "John Doe", Position: "Lead", Salary: usd("90000"), Version: 1},
+		{Action: BatchUpdate, ID: 3, Name: "Alice Smith", Position: "Director", Salary: usd("95000"), Version: 1},
+	}
+
+	t.Run("best effort", func(t *testing.T) {
+		for storeName, s := range newStores(t) {
+			t.Run(storeName, func(t *testing.T) {
+				s.CreateEmployee("John Doe", "Developer", usd("60000"))
+				s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+
+				results, err := s.ApplyBatch(ops, false)
+				if err != nil {
+					t.Fatalf("ApplyBatch() unexpected error: %v", err)
+				}
+				if len(results) != len(ops) {
+					t.Fatalf("ApplyBatch() returned %d results, want %d", len(results), len(ops))
+				}
+
+				var (
+					notFoundErr   *NotFoundError
+					validationErr *ValidationError
+					conflictErr   *ConflictError
+				)
+				if want := (models.Employee{ID: 3, Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), Version: 1}); results[0].Employee != want || results[0].Err != nil {
+					t.Errorf("create result = %+v, want %+v", results[0], want)
+				}
+				if results[1].Err != nil || results[1].Employee.Version != 2 {
+					t.Errorf("update result = %+v, want version 2", results[1])
+				}
+				if results[2].Err != nil {
+					t.Errorf("delete error = %v", results[2].Err)
+				}
+				if !errors.As(results[3].Err, &notFoundErr) {
+					t.Errorf("update of a missing employee error = %v, want a NotFoundError", results[3].Err)
+				}
+				if !errors.As(results[4].Err, &validationErr) {
+					t.Errorf("invalid create error = %v, want a ValidationError", results[4].Err)
+				}
+				if !errors.As(results[5].Err, &conflictErr) {
+					t.Errorf("update at a version an earlier op replaced error = %v, want a ConflictError", results[5].Err)
+				}
+				if results[6].Err != nil || results[6].Employee.Version != 2 {
+					t.Errorf("update of an employee created in the batch = %+v, want version 2", results[6])
+				}
+
+				if got, _ := s.GetEmployeeByID(1); got.Position != "Senior Developer" {
+					t.Errorf("employee 1 position = %q, want %q", got.Position, "Senior Developer")
+				}
+				if _, err := s.GetEmployeeByID(2); !errors.As(err, &notFoundErr) {
+					t.Errorf("deleted employee still found (error %v)", err)
+				}
+				if got, _ := s.GetEmployeeByID(3); got.Position != "Director" {
+					t.Errorf("employee 3 position = %q, want %q", got.Position, "Director")
+				}
+				if hits, _ := s.SearchEmployees("director", 10); len(hits) != 1 {
+					t.Errorf("SearchEmployees() after the batch got %d hits, want 1", len(hits))
+				}
+			})
+		}
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		for storeName, s := range newStores(t) {
+			t.Run(storeName, func(t *testing.T) {
+				s.CreateEmployee("John Doe", "Developer", usd("60000"))
+				s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+
+				results, err := s.ApplyBatch(ops, true)
+				if err != nil {
+					t.Fatalf("ApplyBatch() unexpected error: %v", err)
+				}
+				for _, i := range []int{0, 1, 2, 6} {
+					if !errors.Is(results[i].Err, ErrBatchAborted) {
+						t.Errorf("result %d error = %v, want ErrBatchAborted", i, results[i].Err)
+					}
+				}
+				if errors.Is(results[3].Err, ErrBatchAborted) {
+					t.Errorf("failing op reported as aborted")
+				}
+				if count, _ := s.CountEmployees(ListOptions{}); count != 2 {
+					t.Errorf("CountEmployees() after a rolled-back batch = %d, want 2", count)
+				}
+				if got, _ := s.GetEmployeeByID(1); got.Version != 1 {
+					t.Errorf("employee 1 version after a rolled-back batch = %d, want 1", got.Version)
+				}
+
+				results, err = s.ApplyBatch(ops[:3], true)
+				if err != nil {
+					t.Fatalf("ApplyBatch() unexpected error: %v", err)
+				}
+				for i, result := range results {
+					if result.Err != nil {
+						t.Errorf("result %d error = %v", i, result.Err)
+					}
+				}
+				if results[0].Employee.ID != 3 {
+					t.Errorf("created ID = %d, want 3; a rolled-back batch must not use up ids", results[0].Employee.ID)
+				}
+			})
+		}
+	})
+}
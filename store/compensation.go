@@ -0,0 +1,189 @@
+package store
+
+import (
+	"ems/models"
+	"slices"
+	"time"
+)
+
+// Reasons of the entries the stores record themselves when a salary is
+// written directly.
+const (
+	reasonHired   = "hired"
+	reasonUpdated = "salary updated"
+)
+
+// CompensationRepository is the set of operations on employees' salary
+// histories. Every write that sets an employee's salary records an entry
+// effective that day, so the history is never lost.
+type CompensationRepository interface {
+	// AddCompensation records c for employee id; any id or employee id in
+	// c is ignored. A salary without a currency is in the employee's
+	// current one and an empty effective date means today. If c is in
+	// effect today, the employee's salary becomes its salary and their
+	// version is bumped; a future-dated entry waits for
+	// ApplyDueCompensation.
+	AddCompensation(id int, c models.Compensation) (models.Compensation, error)
+	// CompensationHistory returns employee id's entries by effective date,
+	// entries of the same day in the order they were recorded.
+	CompensationHistory(id int) ([]models.Compensation, error)
+	// ApplyDueCompensation sets every salary to the salary of the entry in
+	// effect at now, bumping the version of each employee whose salary
+	// changes, and returns how many did. Employees without a history keep
+	// their salary.
+	ApplyDueCompensation(now time.Time) (int, error)
+}
+
+// dateOf returns the calendar day of t in UTC.
+func dateOf(t time.Time) string {
+	return t.UTC().Format(models.DateLayout)
+}
+
+// compareCompensation orders entries by effective date and then id.
+func compareCompensation(a, b models.Compensation) int {
+	if a.EffectiveDate != b.EffectiveDate {
+		if a.EffectiveDate < b.EffectiveDate {
+			return -1
+		}
+		return 1
+	}
+	return a.ID - b.ID
+}
+
+// inEffect returns the entry of history, which must be sorted, that applies
+// on day.
+func inEffect(history []models.Compensation, day string) (models.Compensation, bool) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].EffectiveDate <= day {
+			return history[i], true
+		}
+	}
+	return models.Compensation{}, false
+}
+
+func (s *MemoryStore) AddCompensation(id int, c models.Compensation) (models.Compensation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, updated, err := s.prepareCompensation(id, c)
+	if err != nil {
+		return models.Compensation{}, err
+	}
+	if updated != nil {
+		s.put(*updated)
+	}
+	s.record(entry)
+	return entry, nil
+}
+
+func (s *MemoryStore) CompensationHistory(id int) ([]models.Compensation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.employees[id]; !exists {
+		return nil, &NotFoundError{ID: id}
+	}
+	return append([]models.Compensation{}, s.compensation[id]...), nil
+}
+
+func (s *MemoryStore) ApplyDueCompensation(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := s.dueSalaries(dateOf(now))
+	for _, employee := range due {
+		s.put(employee)
+	}
+	return len(due), nil
+}
+
+// prepareCompensation checks c as a new entry for employee id and returns
+// it completed, along with the employee as the entry leaves them if it
+// changes their salary today. Nothing is stored. Callers must hold s.mu.
+func (s *MemoryStore) prepareCompensation(id int, c models.Compensation) (models.Compensation, *models.Employee, error) {
+	employee, exists := s.employees[id]
+	if !exists {
+		return models.Compensation{}, nil, &NotFoundError{ID: id}
+	}
+	today := dateOf(s.now())
+	c.ID = s.nextCompensationID
+	c.EmployeeID = id
+	c.Salary = c.Salary.DefaultTo(employee.Salary.Currency())
+	if c.EffectiveDate == "" {
+		c.EffectiveDate = today
+	}
+	if err := validateCompensation(c); err != nil {
+		return models.Compensation{}, nil, err
+	}
+
+	history := append(slices.Clone(s.compensation[id]), c)
+	slices.SortFunc(history, compareCompensation)
+	if current, ok := inEffect(history, today); ok && current.Salary != employee.Salary {
+		employee.Salary = current.Salary
+		employee.Version++
+		return c, &employee, nil
+	}
+	return c, nil, nil
+}
+
+// salaryEntry returns the entry recording after's salary from today, with
+// no id, unless before is the same employee with the same salary. before is
+// nil for a new employee. Callers must hold s.mu.
+func (s *MemoryStore) salaryEntry(before *models.Employee, after models.Employee) (models.Compensation, bool) {
+	reason := reasonHired
+	if before != nil {
+		if before.Salary == after.Salary {
+			return models.Compensation{}, false
+		}
+		reason = reasonUpdated
+	}
+	return models.Compensation{
+		EmployeeID:    after.ID,
+		Salary:        after.Salary,
+		EffectiveDate: dateOf(s.now()),
+		Reason:        reason,
+	}, true
+}
+
+// nextSalaryEntry is salaryEntry with the next free id, or nil when there
+// is nothing to record. Callers must hold s.mu.
+func (s *MemoryStore) nextSalaryEntry(before *models.Employee, after models.Employee) *models.Compensation {
+	entry, ok := s.salaryEntry(before, after)
+	if !ok {
+		return nil
+	}
+	entry.ID = s.nextCompensationID
+	return &entry
+}
+
+// dueSalaries returns, in id order, the employees whose salary differs from
+// the entry in effect on day, with that entry's salary and their version
+// bumped. Callers must hold s.mu.
+func (s *MemoryStore) dueSalaries(day string) []models.Employee {
+	var due []models.Employee
+	for id, history := range s.compensation {
+		employee, exists := s.employees[id]
+		current, ok := inEffect(history, day)
+		if !exists || !ok || current.Salary == employee.Salary {
+			continue
+		}
+		employee.Salary = current.Salary
+		employee.Version++
+		due = append(due, employee)
+	}
+	slices.SortFunc(due, func(a, b models.Employee) int { return a.ID - b.ID })
+	return due
+}
+
+// record stores c in its employee's history, replacing an entry with the
+// same id, so replaying a journal record twice is harmless. Callers must
+// hold s.mu.
+func (s *MemoryStore) record(c models.Compensation) {
+	history := slices.DeleteFunc(s.compensation[c.EmployeeID], func(e models.Compensation) bool { return e.ID == c.ID })
+	history = append(history, c)
+	slices.SortFunc(history, compareCompensation)
+	s.compensation[c.EmployeeID] = history
+	if c.ID >= s.nextCompensationID {
+		s.nextCompensationID = c.ID + 1
+	}
+}
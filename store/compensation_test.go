@@ -0,0 +1,316 @@
+package store
+
+import (
+	"ems/models"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompensation(t *testing.T) {
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return day }
+
+	memory := NewMemoryStore()
+	memory.now = clock
+	sqlite := newTestSQLiteStore(t)
+	sqlite.now = clock
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	journal.now = clock
+
+	stores := map[string]EmployeeRepository{
+		"memory":  memory,
+		"sqlite":  sqlite,
+		"journal": journal,
+	}
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			day = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+			// Direct salary writes are recorded from the day they are made.
+			john, _ := s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			john, _ = s.UpdateEmployee(john.ID, "John Doe", "Developer", usd("65000"))
+			john, _ = s.UpdateEmployee(john.ID, "John Doe", "Senior Developer", usd("65000"))
+			history, err := s.CompensationHistory(john.ID)
+			want := []models.Compensation{
+				{ID: 1, EmployeeID: john.ID, Salary: usd("60000"), EffectiveDate: "2026-03-01", Reason: "hired"},
+				{ID: 2, EmployeeID: john.ID, Salary: usd("65000"), EffectiveDate: "2026-03-01", Reason: "salary updated"},
+			}
+			if err != nil || !reflect.DeepEqual(history, want) {
+				t.Fatalf("CompensationHistory() = %v, %v, want %v", history, err, want)
+			}
+
+			// A future-dated raise waits for its day.
+			raise, err := s.AddCompensation(john.ID, models.Compensation{Salary: usd("70000"), EffectiveDate: "2026-04-01", Reason: "annual raise"})
+			if want := (models.Compensation{ID: 3, EmployeeID: john.ID, Salary: usd("70000"), EffectiveDate: "2026-04-01", Reason: "annual raise"}); err != nil || raise != want {
+				t.Errorf("AddCompensation() = %v, %v, want %v", raise, err, want)
+			}
+			if current, _ := s.GetEmployeeByID(john.ID); current.Salary != usd("65000") || current.Version != john.Version {
+				t.Errorf("GetEmployeeByID() before the raise = %v, want the salary and version unchanged", current)
+			}
+			// A back-dated entry older than the one in effect changes nothing.
+			if _, err := s.AddCompensation(john.ID, models.Compensation{Salary: models.MustParseMoney("1000", "EUR"), EffectiveDate: "2026-01-01"}); err != nil {
+				t.Errorf("AddCompensation() back-dated unexpected error: %v", err)
+			}
+
+			var (
+				invalid  *ValidationError
+				notFound *NotFoundError
+			)
+			if _, err := s.AddCompensation(john.ID, models.Compensation{Salary: models.MustParseMoney("1", "ABC"), EffectiveDate: "2026-02-30"}); !errors.As(err, &invalid) || len(invalid.Errors) != 2 {
+				t.Errorf("AddCompensation() with a bad currency and date error = %v, want both reported", err)
+			}
+			if _, err := s.AddCompensation(9, models.Compensation{Salary: usd("1")}); !errors.As(err, &notFound) {
+				t.Errorf("AddCompensation() for a missing employee error = %v, want a NotFoundError", err)
+			}
+			if _, err := s.CompensationHistory(9); !errors.As(err, &notFound) {
+				t.Errorf("CompensationHistory() of a missing employee error = %v, want a NotFoundError", err)
+			}
+
+			if applied, err := s.ApplyDueCompensation(time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)); err != nil || applied != 0 {
+				t.Errorf("ApplyDueCompensation() the day before = %d, %v, want 0", applied, err)
+			}
+			if applied, err := s.ApplyDueCompensation(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)); err != nil || applied != 1 {
+				t.Errorf("ApplyDueCompensation() on the day = %d, %v, want 1", applied, err)
+			}
+			if current, _ := s.GetEmployeeByID(john.ID); current.Salary != usd("70000") || current.Version != john.Version+1 {
+				t.Errorf("GetEmployeeByID() after the raise = %v, want 70000 at a new version", current)
+			}
+
+			// An entry effective today applies at once, in the employee's
+			// currency when it names none.
+			day = time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC)
+			if _, err := s.AddCompensation(john.ID, models.Compensation{Salary: models.MustParseMoney("72000", "")}); err != nil {
+				t.Fatal(err)
+			}
+			if current, _ := s.GetEmployeeByID(john.ID); current.Salary != usd("72000") {
+				t.Errorf("GetEmployeeByID() after a raise effective today = %v, want 72000", current)
+			}
+			history, _ = s.CompensationHistory(john.ID)
+			got := []string{}
+			for _, c := range history {
+				got = append(got, c.EffectiveDate+" "+c.Salary.Currency())
+			}
+			if want := []string{"2026-01-01 EUR", "2026-03-01 USD", "2026-03-01 USD", "2026-04-01 USD", "2026-04-02 USD"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("CompensationHistory() = %v, want %v", got, want)
+			}
+
+			// Batches record salary changes too, and deletes drop the history.
+			results, err := s.ApplyBatch([]BatchOp{
+				{Action: BatchCreate, Name: "Alice Smith", Position: "Manager", Salary: usd("80000")},
+				{Action: BatchUpdate, ID: john.ID, Name: "John Doe", Position: "Senior Developer", Salary: usd("75000")},
+			}, true)
+			if err != nil || results[0].Err != nil || results[1].Err != nil {
+				t.Fatalf("ApplyBatch() = %v, %v", results, err)
+			}
+			if history, _ := s.CompensationHistory(results[0].Employee.ID); len(history) != 1 || history[0].Salary != usd("80000") || history[0].Reason != "hired" {
+				t.Errorf("CompensationHistory() of a batch-created employee = %v, want the hiring salary", history)
+			}
+			if history, _ := s.CompensationHistory(john.ID); len(history) != 6 || history[5].Salary != usd("75000") {
+				t.Errorf("CompensationHistory() after a batch update = %v, want the new salary last", history)
+			}
+			if err := s.DeleteEmployee(john.ID); err != nil {
+				t.Fatal(err)
+			}
+			if applied, _ := s.ApplyDueCompensation(day); applied != 0 {
+				t.Errorf("ApplyDueCompensation() after the delete = %d, want 0", applied)
+			}
+		})
+	}
+}
+
+func TestSalaryWithoutCurrency(t *testing.T) {
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stores := map[string]EmployeeRepository{
+		"memory":  NewMemoryStore(),
+		"sqlite":  newTestSQLiteStore(t),
+		"journal": journal,
+	}
+	plain := func(amount string) models.Money { return models.MustParseMoney(amount, "") }
+	eur := func(amount string) models.Money { return models.MustParseMoney(amount, "EUR") }
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			// New employees get models.DefaultCurrency.
+			if john, err := s.CreateEmployee("John Doe", "Developer", plain("60000")); err != nil || john.Salary != usd("60000") {
+				t.Errorf("CreateEmployee() = %v, %v, want a salary of 60000 USD", john, err)
+			}
+
+			// Existing employees keep their own currency.
+			anna, err := s.CreateEmployee("Anna Weber", "Developer", eur("50000"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.UpdateEmployee(anna.ID, "Anna Weber", "Developer", plain("55000")); err != nil || got.Salary != eur("55000") {
+				t.Errorf("UpdateEmployee() = %v, %v, want a salary of 55000 EUR", got, err)
+			}
+			got, err := s.PatchEmployee(anna.ID, func(e models.Employee) (models.Employee, error) {
+				e.Salary = plain("57000")
+				return e, nil
+			})
+			if err != nil || got.Salary != eur("57000") {
+				t.Errorf("PatchEmployee() = %v, %v, want a salary of 57000 EUR", got, err)
+			}
+			results, err := s.ApplyBatch([]BatchOp{
+				{Action: BatchUpdate, ID: anna.ID, Name: "Anna Weber", Position: "Developer", Salary: plain("59000")},
+				{Action: BatchCreate, Name: "Alice Smith", Position: "Manager", Salary: plain("80000")},
+			}, true)
+			if err != nil || results[0].Employee.Salary != eur("59000") || results[1].Employee.Salary != usd("80000") {
+				t.Errorf("ApplyBatch() = %v, %v, want 59000 EUR and 80000 USD", results, err)
+			}
+			if _, err := s.AddCompensation(anna.ID, models.Compensation{Salary: plain("61000"), EffectiveDate: "2020-01-01"}); err != nil {
+				t.Fatal(err)
+			}
+			history, _ := s.CompensationHistory(anna.ID)
+			if len(history) == 0 || history[0].Salary != eur("61000") {
+				t.Errorf("CompensationHistory() = %v, want the back-dated entry in EUR", history)
+			}
+			if got, _ := s.GetEmployeeByID(anna.ID); got.Salary != eur("59000") {
+				t.Errorf("GetEmployeeByID() = %v, want a salary of 59000 EUR", got)
+			}
+		})
+	}
+}
+
+func TestJournalStoreReplayCompensation(t *testing.T) {
+	dir := t.TempDir()
+	for _, snapshotEvery := range []int{0, 1} {
+		s, err := NewJournalStore(dir, snapshotEvery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.now = func() time.Time { return time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC) }
+		s.CreateEmployee("John Doe", "Developer", usd("60000"))
+		s.AddCompensation(1, models.Compensation{Salary: models.MustParseMoney("70000.25", "EUR"), EffectiveDate: "2026-04-01"})
+		s.AddCompensation(1, models.Compensation{Salary: usd("65000")})
+		s.Close()
+
+		s, err = NewJournalStore(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		history, _ := s.CompensationHistory(1)
+		if len(history) != 3 || history[1].Salary != usd("65000") || history[2].Salary.Currency() != "EUR" {
+			t.Errorf("CompensationHistory() after replay = %v, want all three entries", history)
+		}
+		if employee, _ := s.GetEmployeeByID(1); employee.Salary != usd("65000") || employee.Version != 2 {
+			t.Errorf("GetEmployeeByID() after replay = %v, want 65000 at version 2", employee)
+		}
+		if applied, _ := s.ApplyDueCompensation(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)); applied != 1 {
+			t.Errorf("ApplyDueCompensation() after replay = %d, want 1", applied)
+		}
+		if employee, _ := s.GetEmployeeByID(1); employee.Salary != models.MustParseMoney("70000.25", "EUR") {
+			t.Errorf("GetEmployeeByID() after the raise = %v, want 70000.25 EUR", employee.Salary)
+		}
+		// Ids are not reused after a restart.
+		if entry, _ := s.AddCompensation(1, models.Compensation{Salary: usd("1"), EffectiveDate: "2030-01-01"}); entry.ID != 4 {
+			t.Errorf("AddCompensation() after replay got ID %d, want 4", entry.ID)
+		}
+		s.Close()
+		dir = t.TempDir()
+	}
+}
+
+func TestCompensationMigrationOpensHistories(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "ems.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.MigrateUp(5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO employees (name, position, salary) VALUES ('John Doe', 'Developer', 60000)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := s.CompensationHistory(1)
+	if err != nil || len(history) != 1 || history[0].Salary != usd("60000") || history[0].Reason != "opening balance" {
+		t.Errorf("CompensationHistory() after migrating = %v, %v, want the existing salary", history, err)
+	}
+}
+
+func TestMoneyMigrationKeepsSalaries(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "ems.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.MigrateUp(6); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.db.Exec(`INSERT INTO employees (name, position, salary) VALUES ('John Doe', 'Developer', 60000.1);
+		INSERT INTO compensation (employee_id, amount, currency, effective_date, reason)
+			VALUES (1, 60000.1, 'EUR', '2020-01-01', 'hired')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+
+	want := models.MustParseMoney("60000.1", "EUR")
+	if employee, err := s.GetEmployeeByID(1); err != nil || employee.Salary != want {
+		t.Errorf("GetEmployeeByID() after migrating = %v, %v, want a salary of %v", employee, err, want)
+	}
+	if history, err := s.CompensationHistory(1); err != nil || len(history) != 1 || history[0].Salary != want {
+		t.Errorf("CompensationHistory() after migrating = %v, %v, want %v", history, err, want)
+	}
+	if err := s.MigrateDown(6); err != nil {
+		t.Errorf("MigrateDown() unexpected error: %v", err)
+	}
+	var salary float64
+	if err := s.db.QueryRow(`SELECT salary FROM employees WHERE id = 1`).Scan(&salary); err != nil || salary != 60000.1 {
+		t.Errorf("salary after migrating down = %v, %v, want 60000.1", salary, err)
+	}
+}
+
+func TestJournalStoreReadsPlainSalaries(t *testing.T) {
+	dir := t.TempDir()
+	// Records as written before salaries carried a currency.
+	records := []string{
+		`{"op":"create","employee":{"id":1,"name":"John Doe","position":"Developer","salary":60000.5,"version":1},"compensation":{"id":1,"employee_id":1,"amount":60000.5,"currency":"USD","effective_date":"2026-03-01","reason":"hired"}}`,
+		`{"op":"add_compensation","compensation":{"id":2,"employee_id":1,"amount":70000,"currency":"EUR","effective_date":"2030-01-01"}}`,
+	}
+	var log []byte
+	for _, payload := range records {
+		header := make([]byte, recordHeaderSize)
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE([]byte(payload)))
+		log = append(append(log, header...), payload...)
+	}
+	if err := os.WriteFile(filepath.Join(dir, journalLogName), log, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJournalStore() unexpected error: %v", err)
+	}
+	defer s.Close()
+	if employee, _ := s.GetEmployeeByID(1); employee.Salary != usd("60000.5") {
+		t.Errorf("GetEmployeeByID() = %v, want a salary of 60000.5 USD", employee)
+	}
+	history, _ := s.CompensationHistory(1)
+	if len(history) != 2 || history[0].Salary != usd("60000.5") || history[1].Salary != models.MustParseMoney("70000", "EUR") {
+		t.Errorf("CompensationHistory() = %v, want both entries with their currencies", history)
+	}
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"ems/models"
+	"slices"
+)
+
+// DepartmentRepository is the set of operations on departments. Department
+// names are unique, and a department cannot be deleted while any employee
+// belongs to it.
+type DepartmentRepository interface {
+	CreateDepartment(name string) (models.Department, error)
+	GetDepartmentByID(id int) (models.Department, error)
+	UpdateDepartment(id int, name string) (models.Department, error)
+	// DeleteDepartment fails with a ConflictError while the department has
+	// employees.
+	DeleteDepartment(id int) error
+	// ListDepartments returns every department in id order.
+	ListDepartments() ([]models.Department, error)
+}
+
+func (s *MemoryStore) CreateDepartment(name string) (models.Department, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	department := models.Department{ID: s.nextDepartmentID, Name: name}
+	if err := s.validateDepartment(department); err != nil {
+		return models.Department{}, err
+	}
+	s.departments[department.ID] = department
+	s.nextDepartmentID++
+	return department, nil
+}
+
+func (s *MemoryStore) GetDepartmentByID(id int) (models.Department, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	department, exists := s.departments[id]
+	if !exists {
+		return models.Department{}, &NotFoundError{Resource: "department", ID: id}
+	}
+	return department, nil
+}
+
+func (s *MemoryStore) UpdateDepartment(id int, name string) (models.Department, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.departments[id]; !exists {
+		return models.Department{}, &NotFoundError{Resource: "department", ID: id}
+	}
+	department := models.Department{ID: id, Name: name}
+	if err := s.validateDepartment(department); err != nil {
+		return models.Department{}, err
+	}
+	s.departments[id] = department
+	return department, nil
+}
+
+func (s *MemoryStore) DeleteDepartment(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkDeleteDepartment(id); err != nil {
+		return err
+	}
+	delete(s.departments, id)
+	return nil
+}
+
+func (s *MemoryStore) ListDepartments() ([]models.Department, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	departments := make([]models.Department, 0, len(s.departments))
+	for _, department := range s.departments {
+		departments = append(departments, department)
+	}
+	slices.SortFunc(departments, func(a, b models.Department) int { return a.ID - b.ID })
+	return departments, nil
+}
+
+// validateDepartment checks d against the rules and that no other
+// department has its name. Callers must hold s.mu.
+func (s *MemoryStore) validateDepartment(d models.Department) error {
+	if err := validateDepartment(d); err != nil {
+		return err
+	}
+	for _, other := range s.departments {
+		if other.ID != d.ID && other.Name == d.Name {
+			return duplicateDepartment(d.Name)
+		}
+	}
+	return nil
+}
+
+// checkDeleteDepartment reports why department id cannot be deleted, if it
+// cannot. Callers must hold s.mu.
+func (s *MemoryStore) checkDeleteDepartment(id int) error {
+	if _, exists := s.departments[id]; !exists {
+		return &NotFoundError{Resource: "department", ID: id}
+	}
+	if members := len(s.indexes.byDepartment[id]); members > 0 {
+		return departmentNotEmpty(id, members)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"ems/filter"
+	"ems/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDepartments(t *testing.T) {
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stores := map[string]EmployeeRepository{
+		"memory":  NewMemoryStore(),
+		"sqlite":  newTestSQLiteStore(t),
+		"journal": journal,
+	}
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			sales, err := s.CreateDepartment("Sales")
+			if err != nil {
+				t.Fatalf("CreateDepartment() unexpected error: %v", err)
+			}
+			engineering, _ := s.CreateDepartment("Engineering")
+			if want := (models.Department{ID: 2, Name: "Engineering"}); engineering != want {
+				t.Errorf("CreateDepartment() = %v, want %v", engineering, want)
+			}
+
+			var (
+				conflict *ConflictError
+				invalid  *ValidationError
+				notFound *NotFoundError
+			)
+			if _, err := s.CreateDepartment("Sales"); !errors.As(err, &conflict) {
+				t.Errorf("CreateDepartment() with a taken name error = %v, want a ConflictError", err)
+			}
+			if _, err := s.UpdateDepartment(engineering.ID, "Sales"); !errors.As(err, &conflict) {
+				t.Errorf("UpdateDepartment() to a taken name error = %v, want a ConflictError", err)
+			}
+			if _, err := s.CreateDepartment(""); !errors.As(err, &invalid) {
+				t.Errorf("CreateDepartment() with no name error = %v, want a ValidationError", err)
+			}
+			if _, err := s.GetDepartmentByID(9); !errors.As(err, &notFound) || notFound.Error() != "department 9 not found" {
+				t.Errorf("GetDepartmentByID() error = %v, want department 9 not found", err)
+			}
+			if _, err := s.UpdateDepartment(9, "Legal"); !errors.As(err, &notFound) {
+				t.Errorf("UpdateDepartment() error = %v, want a NotFoundError", err)
+			}
+			renamed, err := s.UpdateDepartment(engineering.ID, "R&D")
+			if err != nil || renamed.Name != "R&D" {
+				t.Errorf("UpdateDepartment() = %v, %v, want R&D", renamed, err)
+			}
+
+			// Employees may only refer to departments that exist.
+			john, err := s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: sales.ID})
+			if err != nil || john.DepartmentID != sales.ID {
+				t.Fatalf("CreateEmployee() in a department = %v, %v", john, err)
+			}
+			if _, err := s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "Manager", Salary: usd("80000"), DepartmentID: 9}); !errors.As(err, &invalid) || invalid.Errors[0].Field != "department_id" {
+				t.Errorf("CreateEmployee() in a missing department error = %v, want a ValidationError on department_id", err)
+			}
+			if _, err := s.PatchEmployee(john.ID, func(e models.Employee) (models.Employee, error) {
+				e.DepartmentID = 9
+				return e, nil
+			}); !errors.As(err, &invalid) {
+				t.Errorf("PatchEmployee() into a missing department error = %v, want a ValidationError", err)
+			}
+			results, err := s.ApplyBatch([]BatchOp{
+				{Action: BatchCreate, Name: "Bob Johnson", Position: "Designer", Salary: usd("70000"), DepartmentID: 9},
+				{Action: BatchCreate, Name: "Eve Williams", Position: "Tester", Salary: usd("65000"), DepartmentID: engineering.ID},
+			}, false)
+			if err != nil || !errors.As(results[0].Err, &invalid) || results[1].Err != nil {
+				t.Errorf("ApplyBatch() = %v, %v, want the first op rejected and the second applied", results, err)
+			}
+			if updated, _ := s.UpdateEmployee(john.ID, "John Doe", "Senior Developer", usd("70000")); updated.DepartmentID != sales.ID {
+				t.Errorf("UpdateEmployee() = %v, want the department kept", updated)
+			}
+
+			where, _ := filter.Parse(`department_id eq 1`)
+			members, _ := s.ListEmployees(ListOptions{Page: 1, PerPage: 10, Filter: where})
+			if len(members) != 1 || members[0].ID != john.ID {
+				t.Errorf("ListEmployees(%v) = %v, want John Doe only", where, members)
+			}
+
+			// A department cannot be deleted while it has employees.
+			if err := s.DeleteDepartment(sales.ID); !errors.As(err, &conflict) {
+				t.Errorf("DeleteDepartment() of a non-empty department error = %v, want a ConflictError", err)
+			}
+			if err := s.DeleteEmployee(john.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.DeleteDepartment(sales.ID); err != nil {
+				t.Errorf("DeleteDepartment() of an empty department unexpected error: %v", err)
+			}
+			if err := s.DeleteDepartment(sales.ID); !errors.As(err, &notFound) {
+				t.Errorf("DeleteDepartment() twice error = %v, want a NotFoundError", err)
+			}
+
+			got, err := s.ListDepartments()
+			if want := []models.Department{{ID: 2, Name: "R&D"}}; err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("ListDepartments() = %v, %v, want %v", got, err, want)
+			}
+		})
+	}
+}
+
+func TestJournalStoreReplayDepartments(t *testing.T) {
+	dir := t.TempDir()
+	for _, snapshotEvery := range []int{0, 1} {
+		s, err := NewJournalStore(dir, snapshotEvery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.CreateDepartment("Sales")
+		s.CreateDepartment("Legal")
+		s.DeleteDepartment(2)
+		s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), DepartmentID: 1})
+		s.Close()
+
+		s, err = NewJournalStore(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		departments, _ := s.ListDepartments()
+		if want := []models.Department{{ID: 1, Name: "Sales"}}; !reflect.DeepEqual(departments, want) {
+			t.Errorf("ListDepartments() after replay = %v, want %v", departments, want)
+		}
+		if employee, _ := s.GetEmployeeByID(1); employee.DepartmentID != 1 {
+			t.Errorf("GetEmployeeByID() after replay = %v, want department 1", employee)
+		}
+		// Ids are not reused after a restart.
+		if created, _ := s.CreateDepartment("Marketing"); created.ID != 3 {
+			t.Errorf("CreateDepartment() after replay got ID %d, want 3", created.ID)
+		}
+		s.Close()
+		dir = t.TempDir()
+	}
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"ems/models"
+	"ems/validation"
+	"fmt"
+)
+
+// NotFoundError is returned when no employee, or no resource of the kind
+// named by Resource, has the requested id.
+type NotFoundError struct {
+	// Resource is "department" for departments and empty for employees.
+	Resource string
+	ID       int
+}
+
+func (e *NotFoundError) Error() string {
+	resource := e.Resource
+	if resource == "" {
+		resource = "employee"
+	}
+	return fmt.Sprintf("%s %d not found", resource, e.ID)
+}
+
+// ConflictError is returned when a write cannot be made because of the
+// current state of the data, as opposed to anything wrong with the request
+// itself.
+type ConflictError struct {
+	Msg string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Msg
+}
+
+// ValidationError is returned when a write would store an employee or a
+// department that breaks the validation rules, or an employee that refers to
+// a department that does not exist.
+type ValidationError struct {
+	Errors validation.Errors
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid data: " + e.Errors.Error()
+}
+
+// validate returns a ValidationError if e breaks the rules.
+func validate(e models.Employee) error {
+	if err := validation.Employee(e); err != nil {
+		return &ValidationError{Errors: err.(validation.Errors)}
+	}
+	return nil
+}
+
+// validateDepartment returns a ValidationError if d breaks the rules.
+func validateDepartment(d models.Department) error {
+	if err := validation.Department(d); err != nil {
+		return &ValidationError{Errors: err.(validation.Errors)}
+	}
+	return nil
+}
+
+// validateCompensation returns a ValidationError if c breaks the rules.
+func validateCompensation(c models.Compensation) error {
+	if err := validation.Compensation(c); err != nil {
+		return &ValidationError{Errors: err.(validation.Errors)}
+	}
+	return nil
+}
+
+// missingDepartment is the error for an employee whose department does not
+// exist.
+func missingDepartment(id int) error {
+	return &ValidationError{Errors: validation.Errors{{
+		Field:   "department_id",
+		Code:    validation.CodeNotFound,
+		Message: fmt.Sprintf("department %d does not exist", id),
+	}}}
+}
+
+// departmentNotEmpty is the error for deleting a department that still has
+// employees.
+func departmentNotEmpty(id, employees int) error {
+	return &ConflictError{Msg: fmt.Sprintf("department %d still has %d employees", id, employees)}
+}
+
+// missingManager is the error for an employee whose manager does not exist.
+func missingManager(id int) error {
+	return &ValidationError{Errors: validation.Errors{{
+		Field:   "manager_id",
+		Code:    validation.CodeNotFound,
+		Message: fmt.Sprintf("employee %d does not exist", id),
+	}}}
+}
+
+// managerCycle is the error for making employee id report to managerID
+// when managerID already reports to id, directly or not, or is id itself.
+func managerCycle(id, managerID int) error {
+	if id == managerID {
+		return &ConflictError{Msg: fmt.Sprintf("employee %d cannot report to themselves", id)}
+	}
+	return &ConflictError{Msg: fmt.Sprintf("employee %d cannot report to employee %d, who reports to them", id, managerID)}
+}
+
+// duplicateDepartment is the error for giving a department the name of
+// another one.
+func duplicateDepartment(name string) error {
+	return &ConflictError{Msg: fmt.Sprintf("a department named %q already exists", name)}
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"ems/filter"
+	"strings"
+)
+
+// filterWhere renders a parsed filter as a SQL condition. Field names come
+// from the parser's whitelist and are mapped through employeeColumns or
+// filterColumns; values are always bound as arguments.
+func filterWhere(expr filter.Expr) (string, []any) {
+	switch e := expr.(type) {
+	case filter.And:
+		left, leftArgs := filterWhere(e.Left)
+		right, rightArgs := filterWhere(e.Right)
+		return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...)
+	case filter.Or:
+		left, leftArgs := filterWhere(e.Left)
+		right, rightArgs := filterWhere(e.Right)
+		return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...)
+	case filter.Not:
+		inner, args := filterWhere(e.Expr)
+		return "(NOT " + inner + ")", args
+	case filter.Comparison:
+		return comparisonWhere(e)
+	}
+	return "1 = 1", nil
+}
+
+func comparisonWhere(c filter.Comparison) (string, []any) {
+	column, ok := employeeColumns[c.Field]
+	if !ok {
+		column = filterColumns[c.Field]
+	}
+	value := func(v filter.Value) any {
+		if c.Field == "salary" {
+			return v.Units()
+		}
+		if v.Kind == filter.Number {
+			return v.Number
+		}
+		return v.Text
+	}
+
+	switch c.Op {
+	case filter.OpStartsWith:
+		return "substr(lower(" + column + "), 1, length(?)) = lower(?)",
+			[]any{c.Values[0].Text, c.Values[0].Text}
+	case filter.OpContains:
+		return "instr(lower(" + column + "), lower(?)) > 0", []any{c.Values[0].Text}
+	case filter.OpIn:
+		args := make([]any, len(c.Values))
+		for i, v := range c.Values {
+			args[i] = value(v)
+		}
+		return column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ") + ")", args
+	}
+
+	ops := map[filter.Op]string{
+		filter.OpEq: "=",
+		filter.OpNe: "<>",
+		filter.OpGt: ">",
+		filter.OpGe: ">=",
+		filter.OpLt: "<",
+		filter.OpLe: "<=",
+	}
+	return column + " " + ops[c.Op] + " ?", []any{value(c.Values[0])}
+}
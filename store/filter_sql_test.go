@@ -0,0 +1,114 @@
+package store
+
+import (
+	"ems/filter"
+	"reflect"
+	"testing"
+)
+
+func TestListEmployeesFiltered(t *testing.T) {
+	stores := map[string]EmployeeRepository{
+		"memory": NewMemoryStore(),
+		"sqlite": newTestSQLiteStore(t),
+	}
+	for _, s := range stores {
+		s.CreateEmployee("John Doe", "Developer", usd("60000"))
+		s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+		s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+		s.CreateEmployee("Alan Turing", "Developer", usd("90000"))
+		s.CreateEmployee("Carol White", "Developer", usd("75000"))
+	}
+
+	tests := []struct {
+		name        string
+		filter      string
+		opts        ListOptions
+		expectedIDs []int
+	}{
+		{
+			name:        "Developers over 70k",
+			filter:      `position eq "Developer" and salary gt 70000`,
+			opts:        ListOptions{Page: 1, PerPage: 10},
+			expectedIDs: []int{4, 5},
+		},
+		{
+			name:        "Prefix, in and not",
+			filter:      `name sw "AL" or (position in ("Designer") and not salary lt 50000)`,
+			opts:        ListOptions{Page: 1, PerPage: 10},
+			expectedIDs: []int{2, 3, 4},
+		},
+		{
+			name:        "Composes with offset pages",
+			filter:      `position eq "Developer"`,
+			opts:        ListOptions{Page: 2, PerPage: 2},
+			expectedIDs: []int{5},
+		},
+		{
+			name:        "Composes with sort and keyset",
+			filter:      `salary ge 70000`,
+			opts:        ListOptions{Keyset: true, PerPage: 2, Sort: []SortKey{{Field: "salary", Desc: true}}},
+			expectedIDs: []int{4, 2},
+		},
+		{
+			name:        "Id bounds beyond the int range",
+			filter:      `id le 99999999999999999999 and id gt -99999999999999999999`,
+			opts:        ListOptions{Page: 1, PerPage: 10},
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:        "Id bound beyond the int range matching nothing",
+			filter:      `id ge 99999999999999999999`,
+			opts:        ListOptions{Page: 1, PerPage: 10},
+			expectedIDs: nil,
+		},
+		{
+			name:        "Currency",
+			filter:      `currency eq "USD" and salary gt 70000 and not currency in ("EUR", "JPY")`,
+			opts:        ListOptions{Page: 1, PerPage: 10},
+			expectedIDs: []int{2, 4, 5},
+		},
+		{
+			name:        "Salary bounds beyond the units range",
+			filter:      `salary le 1000000000000000 and salary gt -1000000000000000 and salary ne 999999999999999999999999999999`,
+			opts:        ListOptions{Page: 1, PerPage: 10},
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:        "Salary bound beyond the units range matching nothing",
+			filter:      `salary ge 1000000000000000 or salary in (999999999999999999999999999999, -999999999999999999999999999999)`,
+			opts:        ListOptions{Page: 1, PerPage: 10},
+			expectedIDs: nil,
+		},
+	}
+
+	for storeName, s := range stores {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				expr, err := filter.Parse(tt.filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tt.opts.Filter = expr
+
+				employees, err := s.ListEmployees(tt.opts)
+				if err != nil {
+					t.Fatalf("ListEmployees() unexpected error: %v", err)
+				}
+				var ids []int
+				for _, e := range employees {
+					ids = append(ids, e.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.expectedIDs) {
+					t.Errorf("ListEmployees() ids = %v, want %v", ids, tt.expectedIDs)
+				}
+			})
+		}
+
+		t.Run(storeName+"/Count", func(t *testing.T) {
+			expr, _ := filter.Parse(`position eq "Developer"`)
+			if count, err := s.CountEmployees(ListOptions{Filter: expr}); err != nil || count != 3 {
+				t.Errorf("CountEmployees() = %v, %v, want 3", count, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"ems/models"
+	"slices"
+)
+
+// reassignReports returns the direct reports of e, as lookup sees them,
+// handed over to e's own manager because e is being deleted. Each one's
+// version is bumped. Candidates are the ids the index files under e plus
+// extra, for reports a batch has staged. Callers must hold s.mu.
+func (s *MemoryStore) reassignReports(e models.Employee, lookup func(id int) (models.Employee, bool), extra []int) []models.Employee {
+	ids := extra
+	for id := range s.indexes.byManager[e.ID] {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	var reports []models.Employee
+	for _, id := range ids {
+		report, exists := lookup(id)
+		if !exists || report.ManagerID != e.ID {
+			continue
+		}
+		report.ManagerID = e.ManagerID
+		report.Version++
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func (s *MemoryStore) DirectReports(id int) ([]models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.employees[id]; !exists {
+		return nil, &NotFoundError{ID: id}
+	}
+	return s.reportsOf(id), nil
+}
+
+func (s *MemoryStore) ManagementChain(id int) ([]models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	employee, exists := s.employees[id]
+	if !exists {
+		return nil, &NotFoundError{ID: id}
+	}
+	chain := []models.Employee{}
+	for employee.ManagerID != 0 {
+		employee = s.employees[employee.ManagerID]
+		chain = append(chain, employee)
+	}
+	return chain, nil
+}
+
+func (s *MemoryStore) Subordinates(id int) ([]models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.employees[id]; !exists {
+		return nil, &NotFoundError{ID: id}
+	}
+	subordinates := []models.Employee{}
+	for level := s.reportsOf(id); len(level) > 0; {
+		subordinates = append(subordinates, level...)
+		var next []models.Employee
+		for _, e := range level {
+			next = append(next, s.reportsOf(e.ID)...)
+		}
+		level = next
+	}
+	return subordinates, nil
+}
+
+// reportsOf returns the direct reports of employee id in id order. Callers
+// must hold s.mu.
+func (s *MemoryStore) reportsOf(id int) []models.Employee {
+	reports := make([]models.Employee, 0, len(s.indexes.byManager[id]))
+	for reportID := range s.indexes.byManager[id] {
+		reports = append(reports, s.employees[reportID])
+	}
+	slices.SortFunc(reports, func(a, b models.Employee) int { return a.ID - b.ID })
+	return reports
+}
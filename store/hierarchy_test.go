@@ -0,0 +1,147 @@
+package store
+
+import (
+	"ems/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func employeeIDs(employees []models.Employee) []int {
+	out := []int{}
+	for _, e := range employees {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestManagerHierarchy(t *testing.T) {
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stores := map[string]EmployeeRepository{
+		"memory":  NewMemoryStore(),
+		"sqlite":  newTestSQLiteStore(t),
+		"journal": journal,
+	}
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			// 1 heads the company; 2 and 3 report to 1, 4 and 5 to 2.
+			ceo, _ := s.CreateEmployee("Carol King", "CEO", usd("200000"))
+			cto, _ := s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "CTO", Salary: usd("150000"), ManagerID: ceo.ID})
+			s.InsertEmployee(models.Employee{Name: "Dan Brown", Position: "CFO", Salary: usd("150000"), ManagerID: ceo.ID})
+			john, _ := s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), ManagerID: cto.ID})
+			eve, err := s.InsertEmployee(models.Employee{Name: "Eve Williams", Position: "Tester", Salary: usd("65000"), ManagerID: cto.ID})
+			if err != nil || eve.ManagerID != cto.ID {
+				t.Fatalf("CreateEmployee() with a manager = %v, %v", eve, err)
+			}
+
+			var (
+				conflict *ConflictError
+				invalid  *ValidationError
+				notFound *NotFoundError
+			)
+			if _, err := s.InsertEmployee(models.Employee{Name: "Bob Johnson", Position: "Designer", Salary: usd("70000"), ManagerID: 9}); !errors.As(err, &invalid) || invalid.Errors[0].Field != "manager_id" {
+				t.Errorf("CreateEmployee() with a missing manager error = %v, want a ValidationError on manager_id", err)
+			}
+			if _, err := s.PatchEmployee(ceo.ID, func(e models.Employee) (models.Employee, error) {
+				e.ManagerID = john.ID
+				return e, nil
+			}); !errors.As(err, &conflict) || conflict.Msg != "employee 1 cannot report to employee 4, who reports to them" {
+				t.Errorf("PatchEmployee() into a cycle error = %v, want a ConflictError", err)
+			}
+			if _, err := s.PatchEmployee(john.ID, func(e models.Employee) (models.Employee, error) {
+				e.ManagerID = john.ID
+				return e, nil
+			}); !errors.As(err, &conflict) || conflict.Msg != "employee 4 cannot report to themselves" {
+				t.Errorf("PatchEmployee() to report to self error = %v, want a ConflictError", err)
+			}
+			results, err := s.ApplyBatch([]BatchOp{
+				{Action: BatchUpdate, ID: cto.ID, Name: "Alice Smith", Position: "CTO", Salary: usd("150000"), ManagerID: eve.ID},
+				{Action: BatchCreate, Name: "Bob Johnson", Position: "Designer", Salary: usd("70000"), ManagerID: john.ID},
+			}, false)
+			if err != nil || !errors.As(results[0].Err, &conflict) || results[1].Err != nil {
+				t.Fatalf("ApplyBatch() = %v, %v, want the cycle rejected and the create applied", results, err)
+			}
+			bob := results[1].Employee
+			if updated, _ := s.UpdateEmployee(john.ID, "John Doe", "Senior Developer", usd("70000")); updated.ManagerID != cto.ID {
+				t.Errorf("UpdateEmployee() = %v, want the manager kept", updated)
+			}
+
+			if reports, err := s.DirectReports(cto.ID); err != nil || !reflect.DeepEqual(employeeIDs(reports), []int{john.ID, eve.ID}) {
+				t.Errorf("DirectReports() = %v, %v, want John Doe and Eve Williams", reports, err)
+			}
+			if chain, err := s.ManagementChain(bob.ID); err != nil || !reflect.DeepEqual(employeeIDs(chain), []int{john.ID, cto.ID, ceo.ID}) {
+				t.Errorf("ManagementChain() = %v, %v, want John Doe, Alice Smith, Carol King", chain, err)
+			}
+			if chain, err := s.ManagementChain(ceo.ID); err != nil || chain == nil || len(chain) != 0 {
+				t.Errorf("ManagementChain() of the root = %v, %v, want an empty chain", chain, err)
+			}
+			if subtree, err := s.Subordinates(ceo.ID); err != nil || !reflect.DeepEqual(employeeIDs(subtree), []int{2, 3, 4, 5, 6}) {
+				t.Errorf("Subordinates() = %v, %v, want every other employee by level", subtree, err)
+			}
+			if _, err := s.Subordinates(9); !errors.As(err, &notFound) {
+				t.Errorf("Subordinates() of a missing employee error = %v, want a NotFoundError", err)
+			}
+
+			// Deleting a manager hands their reports to their own manager.
+			if err := s.DeleteEmployee(cto.ID); err != nil {
+				t.Fatal(err)
+			}
+			moved, _ := s.GetEmployeeByID(eve.ID)
+			if moved.ManagerID != ceo.ID || moved.Version != eve.Version+1 {
+				t.Errorf("GetEmployeeByID() after the manager was deleted = %v, want manager %d and a new version", moved, ceo.ID)
+			}
+			if reports, _ := s.DirectReports(ceo.ID); !reflect.DeepEqual(employeeIDs(reports), []int{3, john.ID, eve.ID}) {
+				t.Errorf("DirectReports() after the delete = %v, want 3, 4 and 5", employeeIDs(reports))
+			}
+
+			// A root's reports become roots themselves, even inside a batch.
+			results, err = s.ApplyBatch([]BatchOp{
+				{Action: BatchCreate, Name: "Frank Green", Position: "Intern", Salary: usd("30000"), ManagerID: ceo.ID},
+				{Action: BatchDelete, ID: ceo.ID},
+			}, true)
+			if err != nil || results[0].Err != nil || results[1].Err != nil {
+				t.Fatalf("ApplyBatch() = %v, %v", results, err)
+			}
+			if chain, _ := s.ManagementChain(results[0].Employee.ID); len(chain) != 0 {
+				t.Errorf("ManagementChain() after the root was deleted = %v, want none", chain)
+			}
+			if chain, _ := s.ManagementChain(bob.ID); !reflect.DeepEqual(employeeIDs(chain), []int{john.ID}) {
+				t.Errorf("ManagementChain() after the root was deleted = %v, want John Doe only", employeeIDs(chain))
+			}
+		})
+	}
+}
+
+func TestJournalStoreReplayHierarchy(t *testing.T) {
+	dir := t.TempDir()
+	for _, snapshotEvery := range []int{0, 1} {
+		s, err := NewJournalStore(dir, snapshotEvery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.CreateEmployee("Carol King", "CEO", usd("200000"))
+		s.InsertEmployee(models.Employee{Name: "Alice Smith", Position: "CTO", Salary: usd("150000"), ManagerID: 1})
+		s.InsertEmployee(models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000"), ManagerID: 2})
+		s.DeleteEmployee(2)
+		s.Close()
+
+		s, err = NewJournalStore(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if employee, _ := s.GetEmployeeByID(3); employee.ManagerID != 1 || employee.Version != 2 {
+			t.Errorf("GetEmployeeByID() after replay = %v, want manager 1 at version 2", employee)
+		}
+		if reports, _ := s.DirectReports(1); !reflect.DeepEqual(employeeIDs(reports), []int{3}) {
+			t.Errorf("DirectReports() after replay = %v, want [3]", employeeIDs(reports))
+		}
+		s.Close()
+		dir = t.TempDir()
+	}
+}
@@ -0,0 +1,467 @@
+package store
+
+import (
+	"cmp"
+	"ems/filter"
+	"ems/models"
+	"math"
+
+	"github.com/google/btree"
+)
+
+// indexEntry is one (key, employee id) pair in an orderedIndex.
+type indexEntry[K cmp.Ordered] struct {
+	key K
+	id  int
+}
+
+// orderedIndex keeps (key, id) pairs in a B-tree ordered by key and then id,
+// so it can be walked in any sort order that uses id as the tiebreaker.
+type orderedIndex[K cmp.Ordered] struct {
+	tree *btree.BTreeG[indexEntry[K]]
+}
+
+func newOrderedIndex[K cmp.Ordered]() orderedIndex[K] {
+	return orderedIndex[K]{tree: btree.NewG(32, func(a, b indexEntry[K]) bool {
+		if c := cmp.Compare(a.key, b.key); c != 0 {
+			return c < 0
+		}
+		return a.id < b.id
+	})}
+}
+
+func (x orderedIndex[K]) insert(key K, id int) {
+	x.tree.ReplaceOrInsert(indexEntry[K]{key, id})
+}
+
+func (x orderedIndex[K]) delete(key K, id int) {
+	x.tree.Delete(indexEntry[K]{key, id})
+}
+
+// scan calls fn with ids ordered by key (descending if keyDesc) and then by
+// id (descending if idDesc), until fn returns false. With after set, the scan
+// starts strictly after that position in the same order, whether or not it is
+// in the index.
+//
+// The walk goes one run of equal keys at a time, so mixed directions such as
+// salary descending with id ascending cost one extra tree descent per
+// distinct key.
+func (x orderedIndex[K]) scan(keyDesc, idDesc bool, after *indexEntry[K], fn func(id int) bool) {
+	var (
+		key K
+		ok  bool
+	)
+	if after != nil {
+		if !x.run(after.key, idDesc, &after.id, fn) {
+			return
+		}
+		key, ok = x.nextKey(after.key, keyDesc)
+	} else if keyDesc {
+		var max indexEntry[K]
+		max, ok = x.tree.Max()
+		key = max.key
+	} else {
+		var min indexEntry[K]
+		min, ok = x.tree.Min()
+		key = min.key
+	}
+
+	for ok {
+		if !x.run(key, idDesc, nil, fn) {
+			return
+		}
+		key, ok = x.nextKey(key, keyDesc)
+	}
+}
+
+// run emits the ids stored under key in id order, skipping ids up to and
+// including afterID when it is set. It returns false if fn asked to stop.
+func (x orderedIndex[K]) run(key K, idDesc bool, afterID *int, fn func(id int) bool) bool {
+	more := true
+	visit := func(e indexEntry[K]) bool {
+		if e.key != key {
+			return false
+		}
+		more = fn(e.id)
+		return more
+	}
+
+	if !idDesc {
+		start := indexEntry[K]{key, math.MinInt}
+		if afterID != nil {
+			start.id = *afterID + 1
+		}
+		x.tree.AscendGreaterOrEqual(start, visit)
+	} else {
+		start := indexEntry[K]{key, math.MaxInt}
+		if afterID != nil {
+			start.id = *afterID - 1
+		}
+		x.tree.DescendLessOrEqual(start, visit)
+	}
+	return more
+}
+
+// nextKey returns the closest key after key in the given direction.
+func (x orderedIndex[K]) nextKey(key K, desc bool) (K, bool) {
+	var (
+		next  K
+		found bool
+	)
+	if desc {
+		x.tree.DescendLessOrEqual(indexEntry[K]{key, math.MinInt}, func(e indexEntry[K]) bool {
+			if e.key == key {
+				return true
+			}
+			next, found = e.key, true
+			return false
+		})
+	} else {
+		x.tree.AscendGreaterOrEqual(indexEntry[K]{key, math.MaxInt}, func(e indexEntry[K]) bool {
+			if e.key == key {
+				return true
+			}
+			next, found = e.key, true
+			return false
+		})
+	}
+	return next, found
+}
+
+// between returns the ids whose key lies in [lo, hi]; a nil bound is open.
+func (x orderedIndex[K]) between(lo, hi *K) []int {
+	var ids []int
+	x.ascendRange(lo, hi, func(id int) bool {
+		ids = append(ids, id)
+		return true
+	})
+	return ids
+}
+
+// count returns how many keys lie in [lo, hi], but stops counting at limit.
+func (x orderedIndex[K]) count(lo, hi *K, limit int) int {
+	n := 0
+	x.ascendRange(lo, hi, func(int) bool {
+		n++
+		return n < limit
+	})
+	return n
+}
+
+func (x orderedIndex[K]) ascendRange(lo, hi *K, fn func(id int) bool) {
+	visit := func(e indexEntry[K]) bool {
+		if hi != nil && e.key > *hi {
+			return false
+		}
+		return fn(e.id)
+	}
+	if lo != nil {
+		x.tree.AscendGreaterOrEqual(indexEntry[K]{*lo, math.MinInt}, visit)
+	} else {
+		x.tree.Ascend(visit)
+	}
+}
+
+// secondaryIndexes are the MemoryStore's lookup structures besides the
+// primary map. They are updated under the store's lock together with the map,
+// so readers never see one without the other.
+type secondaryIndexes struct {
+	// rows holds whole employees by id. Exports clone it, which is cheap
+	// because the clone shares nodes copy-on-write, and then read the clone
+	// without the lock.
+	rows       *btree.BTreeG[models.Employee]
+	byPosition map[string]map[int]struct{}
+	// byDepartment and byManager include 0, for the employees without a
+	// department or a manager.
+	byDepartment map[int]map[int]struct{}
+	byManager    map[int]map[int]struct{}
+	byID         orderedIndex[int]
+	byName       orderedIndex[string]
+	bySalary     orderedIndex[int64]
+}
+
+func newSecondaryIndexes() *secondaryIndexes {
+	return &secondaryIndexes{
+		rows:         btree.NewG(32, func(a, b models.Employee) bool { return a.ID < b.ID }),
+		byPosition:   make(map[string]map[int]struct{}),
+		byDepartment: make(map[int]map[int]struct{}),
+		byManager:    make(map[int]map[int]struct{}),
+		byID:         newOrderedIndex[int](),
+		byName:       newOrderedIndex[string](),
+		bySalary:     newOrderedIndex[int64](),
+	}
+}
+
+func (x *secondaryIndexes) add(e models.Employee) {
+	if x.byPosition[e.Position] == nil {
+		x.byPosition[e.Position] = make(map[int]struct{})
+	}
+	x.byPosition[e.Position][e.ID] = struct{}{}
+	addToSet(x.byDepartment, e.DepartmentID, e.ID)
+	addToSet(x.byManager, e.ManagerID, e.ID)
+	x.rows.ReplaceOrInsert(e)
+	x.byID.insert(e.ID, e.ID)
+	x.byName.insert(e.Name, e.ID)
+	x.bySalary.insert(e.Salary.Units(), e.ID)
+}
+
+func (x *secondaryIndexes) remove(e models.Employee) {
+	delete(x.byPosition[e.Position], e.ID)
+	if len(x.byPosition[e.Position]) == 0 {
+		delete(x.byPosition, e.Position)
+	}
+	removeFromSet(x.byDepartment, e.DepartmentID, e.ID)
+	removeFromSet(x.byManager, e.ManagerID, e.ID)
+	x.rows.Delete(e)
+	x.byID.delete(e.ID, e.ID)
+	x.byName.delete(e.Name, e.ID)
+	x.bySalary.delete(e.Salary.Units(), e.ID)
+}
+
+// scanSorted walks employee ids in the order given by keys, if an ordered
+// index covers it: a single id, name or salary key followed by the id
+// tiebreaker. before reverses the walk so it moves backwards from boundary.
+// It reports false when no index applies.
+func (x *secondaryIndexes) scanSorted(keys []SortKey, boundary *models.Employee, before bool, fn func(id int) bool) bool {
+	if !x.sortable(keys) {
+		return false
+	}
+	keys = withTiebreaker(keys)
+	idDesc := len(keys) == 2 && keys[1].Desc
+	keyDesc := keys[0].Desc
+	if before {
+		keyDesc, idDesc = !keyDesc, !idDesc
+	}
+
+	switch keys[0].Field {
+	case "id":
+		var after *indexEntry[int]
+		if boundary != nil {
+			after = &indexEntry[int]{boundary.ID, boundary.ID}
+		}
+		x.byID.scan(keyDesc, keyDesc, after, fn)
+	case "name":
+		var after *indexEntry[string]
+		if boundary != nil {
+			after = &indexEntry[string]{boundary.Name, boundary.ID}
+		}
+		x.byName.scan(keyDesc, idDesc, after, fn)
+	case "salary":
+		var after *indexEntry[int64]
+		if boundary != nil {
+			after = &indexEntry[int64]{boundary.Salary.Units(), boundary.ID}
+		}
+		x.bySalary.scan(keyDesc, idDesc, after, fn)
+	}
+	return true
+}
+
+// sortable reports whether an ordered index can produce keys' order.
+func (x *secondaryIndexes) sortable(keys []SortKey) bool {
+	keys = withTiebreaker(keys)
+	if len(keys) > 2 || (len(keys) == 2 && keys[1].Field != "id") {
+		return false
+	}
+	switch keys[0].Field {
+	case "id", "name", "salary":
+		return true
+	}
+	return false
+}
+
+// candidates narrows expr to the ids that may match it, using the most
+// selective index among the top-level AND terms. Sizes are counted only up
+// to budget, and it reports false when no index gets under budget so the
+// caller should use another plan. The caller must still evaluate expr against
+// each candidate.
+func (x *secondaryIndexes) candidates(expr filter.Expr, budget int) ([]int, bool) {
+	var (
+		bestSize = budget + 1
+		best     func() []int
+		ranges   = make(map[string]*valueRange)
+	)
+	consider := func(size int, ids func() []int) {
+		if size < bestSize {
+			bestSize, best = size, ids
+		}
+	}
+
+	for _, term := range conjuncts(expr) {
+		c, ok := term.(filter.Comparison)
+		if !ok {
+			continue
+		}
+		if c.Field == "position" && (c.Op == filter.OpEq || c.Op == filter.OpIn) {
+			size := 0
+			for _, v := range c.Values {
+				size += len(x.byPosition[v.Text])
+			}
+			consider(size, func() []int {
+				ids := make([]int, 0, size)
+				for _, v := range c.Values {
+					for id := range x.byPosition[v.Text] {
+						ids = append(ids, id)
+					}
+				}
+				return ids
+			})
+			continue
+		}
+		if sets, ok := x.idSets(c.Field); ok {
+			if c.Op == filter.OpEq || c.Op == filter.OpIn {
+				size := 0
+				for _, v := range c.Values {
+					size += len(sets[int(v.Number)])
+				}
+				consider(size, func() []int {
+					ids := make([]int, 0, size)
+					for _, v := range c.Values {
+						for id := range sets[int(v.Number)] {
+							ids = append(ids, id)
+						}
+					}
+					return ids
+				})
+			}
+			continue
+		}
+		if c.Field == "position" {
+			continue
+		}
+
+		r := ranges[c.Field]
+		if r == nil {
+			r = &valueRange{}
+			ranges[c.Field] = r
+		}
+		// Strict and inclusive bounds are both treated as inclusive; the
+		// extra candidates are dropped by the filter re-check.
+		v := c.Values[0]
+		switch c.Op {
+		case filter.OpEq:
+			r.lo, r.hi = tighterLower(r.lo, &v), tighterUpper(r.hi, &v)
+		case filter.OpGt, filter.OpGe:
+			r.lo = tighterLower(r.lo, &v)
+		case filter.OpLt, filter.OpLe:
+			r.hi = tighterUpper(r.hi, &v)
+		}
+	}
+
+	for field, r := range ranges {
+		if r.lo == nil && r.hi == nil {
+			continue
+		}
+		switch field {
+		case "id":
+			lo, hi := intBound(r.lo), intBound(r.hi)
+			consider(x.byID.count(lo, hi, bestSize), func() []int { return x.byID.between(lo, hi) })
+		case "salary":
+			lo, hi := unitsBound(r.lo), unitsBound(r.hi)
+			consider(x.bySalary.count(lo, hi, bestSize), func() []int { return x.bySalary.between(lo, hi) })
+		case "name":
+			lo, hi := textBound(r.lo), textBound(r.hi)
+			consider(x.byName.count(lo, hi, bestSize), func() []int { return x.byName.between(lo, hi) })
+		}
+	}
+
+	if best == nil {
+		return nil, false
+	}
+	return best(), true
+}
+
+// idSets returns the index from the values of a reference field, such as
+// department_id, to the employees that have them.
+func (x *secondaryIndexes) idSets(field string) (map[int]map[int]struct{}, bool) {
+	switch field {
+	case "department_id":
+		return x.byDepartment, true
+	case "manager_id":
+		return x.byManager, true
+	}
+	return nil, false
+}
+
+func addToSet(sets map[int]map[int]struct{}, key, id int) {
+	if sets[key] == nil {
+		sets[key] = make(map[int]struct{})
+	}
+	sets[key][id] = struct{}{}
+}
+
+func removeFromSet(sets map[int]map[int]struct{}, key, id int) {
+	delete(sets[key], id)
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
+
+// valueRange is an inclusive range of filter values; a nil end is open.
+type valueRange struct {
+	lo, hi *filter.Value
+}
+
+// conjuncts flattens nested ANDs into their terms.
+func conjuncts(expr filter.Expr) []filter.Expr {
+	if and, ok := expr.(filter.And); ok {
+		return append(conjuncts(and.Left), conjuncts(and.Right)...)
+	}
+	return []filter.Expr{expr}
+}
+
+func valueLess(a, b *filter.Value) bool {
+	if a.Kind == filter.Number {
+		return a.Number < b.Number
+	}
+	return a.Text < b.Text
+}
+
+func tighterLower(current, v *filter.Value) *filter.Value {
+	if current == nil || valueLess(current, v) {
+		return v
+	}
+	return current
+}
+
+func tighterUpper(current, v *filter.Value) *filter.Value {
+	if current == nil || valueLess(v, current) {
+		return v
+	}
+	return current
+}
+
+// intBound and unitsBound convert an inclusive filter bound to an index key.
+// A fractional id bound is rounded down, which can only widen a lower bound;
+// the filter re-check trims any extra candidates. Bounds beyond the range
+// of int are clamped to it rather than wrapping around, as Value.Units
+// clamps salary bounds.
+func intBound(v *filter.Value) *int {
+	if v == nil {
+		return nil
+	}
+	var k int
+	switch f := math.Floor(v.Number); {
+	case f >= math.MaxInt:
+		k = math.MaxInt
+	case f <= math.MinInt:
+		k = math.MinInt
+	default:
+		k = int(f)
+	}
+	return &k
+}
+
+func unitsBound(v *filter.Value) *int64 {
+	if v == nil {
+		return nil
+	}
+	units := v.Units()
+	return &units
+}
+
+func textBound(v *filter.Value) *string {
+	if v == nil {
+		return nil
+	}
+	return &v.Text
+}
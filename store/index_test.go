@@ -0,0 +1,153 @@
+package store
+
+import (
+	"ems/filter"
+	"ems/models"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var (
+	testPositions = []string{"Developer", "Manager", "Designer", "Tester", "Analyst"}
+	testNames     = []string{"Alice", "Bob", "Carol", "Dan", "Eve", "Frank", "Grace", "Heidi"}
+)
+
+func fillRandom(s EmployeeRepository, r *rand.Rand, n int) {
+	for i := 0; i < n; i++ {
+		s.InsertEmployee(models.Employee{
+			Name:     testNames[r.Intn(len(testNames))],
+			Position: testPositions[r.Intn(len(testPositions))],
+			Salary:   usd(strconv.Itoa(40000 + r.Intn(20)*5000)),
+		})
+	}
+}
+
+// referenceList computes a listing the slow way, to check the index plans.
+func referenceList(s *MemoryStore, opts ListOptions) []models.Employee {
+	var employees []models.Employee
+	for _, e := range s.employees {
+		if opts.Filter == nil || opts.Filter.Match(e) {
+			employees = append(employees, e)
+		}
+	}
+	sortEmployees(employees, opts.Sort)
+	if opts.Keyset {
+		return keysetPage(employees, opts)
+	}
+	return paginate(employees, opts.Page, opts.PerPage)
+}
+
+func TestListEmployeesUsesIndexesCorrectly(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	s := NewMemoryStore()
+	fillRandom(s, r, 500)
+
+	// Churn so the indexes have seen updates and deletes, not just inserts.
+	for i := 0; i < 200; i++ {
+		id := 1 + r.Intn(500)
+		if r.Intn(2) == 0 {
+			s.DeleteEmployee(id)
+		} else {
+			s.UpdateEmployee(id, testNames[r.Intn(len(testNames))], testPositions[r.Intn(len(testPositions))], usd(strconv.Itoa(40000+r.Intn(20)*5000)))
+		}
+	}
+
+	filters := []string{
+		"",
+		`position eq "Developer"`,
+		`position in ("Manager", "Tester") and salary ge 60000`,
+		`salary gt 50000 and salary le 70000`,
+		`name ge "Carol" and name lt "Frank"`,
+		`id ge 100.5 and id lt 200`,
+		`salary eq 65000 or position eq "Analyst"`,
+	}
+	sorts := [][]SortKey{
+		nil,
+		{{Field: "id", Desc: true}},
+		{{Field: "salary"}},
+		{{Field: "salary", Desc: true}},
+		{{Field: "name"}, {Field: "id", Desc: true}},
+		{{Field: "position"}, {Field: "salary"}},
+	}
+	boundary := s.employees[250]
+	if boundary.ID == 0 {
+		boundary = models.Employee{ID: 250, Name: "Dan", Salary: usd("60000")}
+	}
+
+	for _, f := range filters {
+		expr, err := filter.Parse(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sort := range sorts {
+			for _, opts := range []ListOptions{
+				{Page: 1, PerPage: 20},
+				{Page: 3, PerPage: 7},
+				{Keyset: true, PerPage: 15},
+				{Keyset: true, PerPage: 15, After: &boundary},
+				{Keyset: true, PerPage: 15, Before: &boundary},
+			} {
+				opts.Filter, opts.Sort = expr, sort
+				name := fmt.Sprintf("%s/%s/page=%d,keyset=%v,after=%v,before=%v",
+					f, FormatSort(sort), opts.Page, opts.Keyset, opts.After != nil, opts.Before != nil)
+				t.Run(name, func(t *testing.T) {
+					got, _ := s.ListEmployees(opts)
+					want := referenceList(s, opts)
+					if len(got) != len(want) || (len(got) > 0 && !reflect.DeepEqual(got, want)) {
+						t.Errorf("ListEmployees() = %v, want %v", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func benchmarkStore(b *testing.B, n int) *MemoryStore {
+	b.Helper()
+	s := NewMemoryStore()
+	fillRandom(s, rand.New(rand.NewSource(1)), n)
+	b.ResetTimer()
+	return s
+}
+
+func BenchmarkListEmployees(b *testing.B) {
+	const n = 200000
+	developers, _ := filter.Parse(`position eq "Developer" and salary gt 70000`)
+	salaryRange, _ := filter.Parse(`salary ge 60000 and salary le 61000`)
+	s := benchmarkStore(b, n)
+
+	benchmarks := []struct {
+		name string
+		opts ListOptions
+	}{
+		// Sorted by an indexed field: streams the ordered index.
+		{"SortedBySalary", ListOptions{Page: 1, PerPage: 20, Sort: []SortKey{{Field: "salary", Desc: true}}}},
+		{"SortedByNameDeepPage", ListOptions{Page: 500, PerPage: 20, Sort: []SortKey{{Field: "name"}}}},
+		{"KeysetById", ListOptions{Keyset: true, PerPage: 20, After: &models.Employee{ID: n / 2}}},
+		// Filtered: narrows through the position hash or salary range.
+		{"FilteredByPosition", ListOptions{Page: 1, PerPage: 20, Filter: developers, Sort: []SortKey{{Field: "name"}}}},
+		{"FilteredBySalaryRange", ListOptions{Page: 1, PerPage: 20, Filter: salaryRange, Sort: []SortKey{{Field: "position"}}}},
+		// Baseline: sorted by an unindexed field, so every employee is sorted.
+		{"FullScanSortedByPosition", ListOptions{Page: 1, PerPage: 20, Sort: []SortKey{{Field: "position"}}}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.ListEmployees(bm.opts)
+			}
+		})
+	}
+}
+
+func BenchmarkUpdateEmployeeIndexed(b *testing.B) {
+	const n = 200000
+	s := benchmarkStore(b, n)
+	for i := 0; i < b.N; i++ {
+		id := 1 + i%n
+		s.UpdateEmployee(id, testNames[i%len(testNames)], testPositions[i%len(testPositions)], usd(strconv.Itoa(40000+i%20*5000)))
+	}
+}
@@ -0,0 +1,271 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournalStoreReplay(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	s.UpdateEmployee(1, "Updated John Doe", "Senior Developer", usd("70000"))
+	s.DeleteEmployee(2)
+	want := s.employees
+	s.Close()
+
+	s, err = NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJournalStore() reopen unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	if !reflect.DeepEqual(s.employees, want) {
+		t.Errorf("replayed employees = %v, want %v", s.employees, want)
+	}
+	created, _ := s.CreateEmployee("Eve Williams", "Tester", usd("65000"))
+	if created.ID != 4 {
+		t.Errorf("CreateEmployee() after replay got ID %d, want 4", created.ID)
+	}
+}
+
+func TestJournalStoreReplayBatch(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.ApplyBatch([]BatchOp{
+		{Action: BatchCreate, Name: "Alice Smith", Position: "Manager", Salary: usd("80000")},
+		{Action: BatchUpdate, ID: 1, Name: "John Doe", Position: "Senior Developer", Salary: usd("70000")},
+		{Action: BatchDelete, ID: 2},
+		{Action: BatchCreate, Name: "Bob Johnson", Position: "Designer", Salary: usd("70000")},
+	}, true)
+	want := s.employees
+	s.Close()
+
+	s, err = NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJournalStore() reopen unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	if !reflect.DeepEqual(s.employees, want) {
+		t.Errorf("replayed employees = %v, want %v", s.employees, want)
+	}
+	if s.records != 2 {
+		t.Errorf("replayed %d records, want 2", s.records)
+	}
+}
+
+func TestJournalStoreSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	s.DeleteEmployee(3)
+	s.Close()
+
+	info, err := os.Stat(filepath.Join(dir, journalLogName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("log size after snapshot = %d, want 0", info.Size())
+	}
+
+	s, err = NewJournalStore(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if len(s.employees) != 2 || s.nextID != 4 {
+		t.Errorf("after reopen got %d employees and nextID %d, want 2 and 4", len(s.employees), s.nextID)
+	}
+}
+
+func TestJournalStoreTornTail(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.Close()
+
+	path := filepath.Join(dir, journalLogName)
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJournalStore() with torn tail unexpected error: %v", err)
+	}
+	if len(s.employees) != 1 {
+		t.Errorf("got %d employees after torn tail, want 1", len(s.employees))
+	}
+	created, err := s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	if err != nil || created.ID != 2 {
+		t.Errorf("CreateEmployee() after torn tail = %v, %v, want ID 2", created, err)
+	}
+	s.Close()
+
+	s, err = NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJournalStore() after repair unexpected error: %v", err)
+	}
+	defer s.Close()
+	if employee, _ := s.GetEmployeeByID(2); employee.Name != "Bob Johnson" {
+		t.Errorf("GetEmployeeByID(2) after repair = %v, want Bob Johnson", employee)
+	}
+}
+
+func TestJournalStoreCorruptMiddle(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.Close()
+
+	path := filepath.Join(dir, journalLogName)
+	data, _ := os.ReadFile(path)
+	data[recordHeaderSize+2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewJournalStore(dir, 0); !errors.Is(err, ErrJournalCorrupt) {
+		t.Errorf("NewJournalStore() with corrupt record = %v, want ErrJournalCorrupt", err)
+	}
+}
+
+func TestJournalStoreReplayLargeRecord(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := make([]BatchOp, 5000)
+	for i := range ops {
+		ops[i] = BatchOp{Action: BatchCreate, Name: "John Doe", Position: fmt.Sprintf("Developer %d", i+1), Salary: usd("60000")}
+	}
+	if results, err := s.ApplyBatch(ops, true); err != nil || abortBatch(results) {
+		t.Fatalf("ApplyBatch() = %v, %v, want every create applied", results[0], err)
+	}
+	s.Close()
+
+	info, err := os.Stat(filepath.Join(dir, journalLogName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() <= 1<<20 {
+		t.Fatalf("log size = %d, want a record over 1 MiB", info.Size())
+	}
+
+	s, err = NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJournalStore() reopen unexpected error: %v", err)
+	}
+	defer s.Close()
+	if len(s.employees) != len(ops) {
+		t.Errorf("replayed %d employees, want %d", len(s.employees), len(ops))
+	}
+}
+
+func TestJournalStoreRecordTooLarge(t *testing.T) {
+	defer func(size int) { maxRecordSize = size }(maxRecordSize)
+	maxRecordSize = 1 << 10
+
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	ops := make([]BatchOp, 20)
+	for i := range ops {
+		ops[i] = BatchOp{Action: BatchCreate, Name: "John Doe", Position: fmt.Sprintf("Developer %d", i+1), Salary: usd("60000")}
+	}
+	if _, err := s.ApplyBatch(ops, true); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("ApplyBatch() error = %v, want ErrRecordTooLarge", err)
+	}
+	if len(s.employees) != 1 {
+		t.Errorf("got %d employees after a rejected batch, want 1", len(s.employees))
+	}
+	created, err := s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	if err != nil || created.ID != 2 {
+		t.Errorf("CreateEmployee() after a rejected batch = %v, %v, want ID 2", created, err)
+	}
+	s.Close()
+
+	s, err = NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJournalStore() reopen unexpected error: %v", err)
+	}
+	defer s.Close()
+	if len(s.employees) != 2 {
+		t.Errorf("replayed %d employees, want 2", len(s.employees))
+	}
+}
+
+func TestJournalStoreFailedAppend(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+
+	// A read-only handle fails the write and the truncate that would roll
+	// it back, leaving the log unusable.
+	logFile := s.logFile
+	readOnly, err := os.Open(logFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readOnly.Seek(0, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	s.logFile = readOnly
+	if _, err := s.CreateEmployee("Alice Smith", "Manager", usd("80000")); err == nil {
+		t.Fatal("CreateEmployee() on a read-only log succeeded")
+	}
+	s.logFile = logFile
+	readOnly.Close()
+
+	if len(s.employees) != 1 {
+		t.Errorf("got %d employees after a failed append, want 1", len(s.employees))
+	}
+	if _, err := s.CreateEmployee("Bob Johnson", "Designer", usd("70000")); err == nil {
+		t.Error("CreateEmployee() after an append that could not be rolled back succeeded")
+	}
+	if err := s.Snapshot(); err != nil {
+		t.Fatalf("Snapshot() unexpected error: %v", err)
+	}
+	if created, err := s.CreateEmployee("Bob Johnson", "Designer", usd("70000")); err != nil || created.ID != 2 {
+		t.Errorf("CreateEmployee() after a snapshot = %v, %v, want ID 2", created, err)
+	}
+	s.Close()
+}
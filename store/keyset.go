@@ -0,0 +1,90 @@
+package store
+
+import (
+	"ems/models"
+	"slices"
+	"strings"
+)
+
+// keysetPage returns the employees that follow opts.After (or precede
+// opts.Before) in an already sorted slice.
+func keysetPage(employees []models.Employee, opts ListOptions) []models.Employee {
+	if opts.PerPage < 1 {
+		return nil
+	}
+	keys := withTiebreaker(opts.Sort)
+
+	if opts.Before != nil {
+		end, _ := slices.BinarySearchFunc(employees, *opts.Before, func(e, target models.Employee) int {
+			return compareEmployees(e, target, keys)
+		})
+		start := max(end-opts.PerPage, 0)
+		return employees[start:end]
+	}
+
+	start := 0
+	if opts.After != nil {
+		start, _ = slices.BinarySearchFunc(employees, *opts.After, func(e, target models.Employee) int {
+			return compareEmployees(e, target, keys)
+		})
+		if start < len(employees) && compareEmployees(employees[start], *opts.After, keys) == 0 {
+			start++
+		}
+	}
+	end := min(start+opts.PerPage, len(employees))
+	if start >= end {
+		return nil
+	}
+	return employees[start:end]
+}
+
+// keysetWhere renders the SQL condition selecting rows strictly after (or,
+// with before set, strictly before) boundary in the order given by keys.
+// Mixed sort directions rule out a row-value comparison, so the condition is
+// expanded into (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+func keysetWhere(keys []SortKey, boundary models.Employee, before bool) (string, []any) {
+	var (
+		clauses []string
+		args    []any
+	)
+	for i, key := range keys {
+		var parts []string
+		for _, prev := range keys[:i] {
+			parts = append(parts, employeeColumns[prev.Field]+" = ?")
+			args = append(args, sortValue(boundary, prev.Field))
+		}
+		op := ">"
+		if key.Desc != before {
+			op = "<"
+		}
+		parts = append(parts, employeeColumns[key.Field]+" "+op+" ?")
+		args = append(args, sortValue(boundary, key.Field))
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+func reverseKeys(keys []SortKey) []SortKey {
+	reversed := make([]SortKey, len(keys))
+	for i, key := range keys {
+		reversed[i] = SortKey{Field: key.Field, Desc: !key.Desc}
+	}
+	return reversed
+}
+
+func sortValue(e models.Employee, field string) any {
+	switch field {
+	case "name":
+		return e.Name
+	case "position":
+		return e.Position
+	case "salary":
+		return e.Salary.Units()
+	case "department_id":
+		return e.DepartmentID
+	case "manager_id":
+		return e.ManagerID
+	default:
+		return e.ID
+	}
+}
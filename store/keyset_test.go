@@ -0,0 +1,96 @@
+package store
+
+import (
+	"ems/models"
+	"reflect"
+	"testing"
+)
+
+func TestListEmployeesKeyset(t *testing.T) {
+	stores := map[string]EmployeeRepository{
+		"memory": NewMemoryStore(),
+		"sqlite": newTestSQLiteStore(t),
+	}
+	for _, s := range stores {
+		s.CreateEmployee("John Doe", "Developer", usd("60000"))
+		s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+		s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+		s.CreateEmployee("Eve Williams", "Tester", usd("65000"))
+		s.CreateEmployee("Carol White", "Developer", usd("70000"))
+	}
+
+	bySalaryDesc := []SortKey{{Field: "salary", Desc: true}}
+	tests := []struct {
+		name        string
+		opts        ListOptions
+		expectedIDs []int
+	}{
+		{
+			name:        "First page",
+			opts:        ListOptions{Keyset: true, PerPage: 2, Sort: bySalaryDesc},
+			expectedIDs: []int{2, 3},
+		},
+		{
+			name:        "After tied salary uses id tiebreaker",
+			opts:        ListOptions{Keyset: true, PerPage: 2, Sort: bySalaryDesc, After: &models.Employee{ID: 3, Salary: usd("70000")}},
+			expectedIDs: []int{5, 4},
+		},
+		{
+			name:        "Before",
+			opts:        ListOptions{Keyset: true, PerPage: 2, Sort: bySalaryDesc, Before: &models.Employee{ID: 4, Salary: usd("65000")}},
+			expectedIDs: []int{3, 5},
+		},
+		{
+			name:        "Before the first page",
+			opts:        ListOptions{Keyset: true, PerPage: 2, Sort: bySalaryDesc, Before: &models.Employee{ID: 2, Salary: usd("80000")}},
+			expectedIDs: nil,
+		},
+		{
+			name:        "After a deleted boundary",
+			opts:        ListOptions{Keyset: true, PerPage: 10, After: &models.Employee{ID: 3}},
+			expectedIDs: []int{4, 5},
+		},
+	}
+
+	for storeName, s := range stores {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				employees, err := s.ListEmployees(tt.opts)
+				if err != nil {
+					t.Fatalf("ListEmployees() unexpected error: %v", err)
+				}
+				var ids []int
+				for _, e := range employees {
+					ids = append(ids, e.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.expectedIDs) {
+					t.Errorf("ListEmployees() ids = %v, want %v", ids, tt.expectedIDs)
+				}
+			})
+		}
+	}
+}
+
+func TestListEmployeesKeysetStableUnderWrites(t *testing.T) {
+	s := NewMemoryStore()
+	for i := 0; i < 10; i++ {
+		s.CreateEmployee("Employee", "Developer", usd("60000"))
+	}
+
+	first, _ := s.ListEmployees(ListOptions{Keyset: true, PerPage: 4})
+	last := first[len(first)-1]
+
+	// Writes on both sides of the boundary must not shift the next page.
+	s.DeleteEmployee(1)
+	s.DeleteEmployee(last.ID)
+	s.CreateEmployee("Employee", "Developer", usd("60000"))
+
+	second, _ := s.ListEmployees(ListOptions{Keyset: true, PerPage: 4, After: &last})
+	var ids []int
+	for _, e := range second {
+		ids = append(ids, e.ID)
+	}
+	if want := []int{5, 6, 7, 8}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("second page ids = %v, want %v", ids, want)
+	}
+}
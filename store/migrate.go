@@ -0,0 +1,235 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Migration is one numbered step of the SQLite schema. Up moves the schema
+// from Version-1 to Version and Down reverses it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// migrations must stay sorted by Version with no gaps. Never edit a migration
+// that has shipped; add a new one instead.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_employees",
+		Up: `CREATE TABLE IF NOT EXISTS employees (
+			id       INTEGER PRIMARY KEY AUTOINCREMENT,
+			name     TEXT    NOT NULL,
+			position TEXT    NOT NULL,
+			salary   REAL    NOT NULL
+		)`,
+		Down: `DROP TABLE employees`,
+	},
+	{
+		Version: 2,
+		Name:    "index_employees_position_salary_name",
+		Up: `CREATE INDEX idx_employees_position ON employees (position);
+			CREATE INDEX idx_employees_salary ON employees (salary, id);
+			CREATE INDEX idx_employees_name ON employees (name, id)`,
+		Down: `DROP INDEX idx_employees_position;
+			DROP INDEX idx_employees_salary;
+			DROP INDEX idx_employees_name`,
+	},
+	{
+		Version: 3,
+		Name:    "add_employees_version",
+		Up:      `ALTER TABLE employees ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		Down:    `ALTER TABLE employees DROP COLUMN version`,
+	},
+	{
+		Version: 4,
+		Name:    "create_departments",
+		Up: `CREATE TABLE departments (
+				id   INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT    NOT NULL UNIQUE
+			);
+			ALTER TABLE employees ADD COLUMN department_id INTEGER NOT NULL DEFAULT 0;
+			CREATE INDEX idx_employees_department ON employees (department_id, id)`,
+		Down: `DROP INDEX idx_employees_department;
+			ALTER TABLE employees DROP COLUMN department_id;
+			DROP TABLE departments`,
+	},
+	{
+		Version: 5,
+		Name:    "add_employees_manager",
+		Up: `ALTER TABLE employees ADD COLUMN manager_id INTEGER NOT NULL DEFAULT 0;
+			CREATE INDEX idx_employees_manager ON employees (manager_id, id)`,
+		Down: `DROP INDEX idx_employees_manager;
+			ALTER TABLE employees DROP COLUMN manager_id`,
+	},
+	{
+		// Existing salaries open each history, effective from the day of
+		// the migration.
+		Version: 6,
+		Name:    "create_compensation",
+		Up: `CREATE TABLE compensation (
+				id             INTEGER PRIMARY KEY AUTOINCREMENT,
+				employee_id    INTEGER NOT NULL,
+				amount         REAL    NOT NULL,
+				currency       TEXT    NOT NULL,
+				effective_date TEXT    NOT NULL,
+				reason         TEXT    NOT NULL DEFAULT ''
+			);
+			CREATE INDEX idx_compensation_employee ON compensation (employee_id, effective_date, id);
+			INSERT INTO compensation (employee_id, amount, currency, effective_date, reason)
+				SELECT id, salary, 'USD', date('now'), 'opening balance' FROM employees ORDER BY id`,
+		Down: `DROP TABLE compensation`,
+	},
+	{
+		// Amounts become whole numbers of models.Money units so they are
+		// exact. Employees take the currency of the entry in effect.
+		Version: 7,
+		Name:    "store_salaries_as_money",
+		Up: `ALTER TABLE employees ADD COLUMN salary_units INTEGER NOT NULL DEFAULT 0;
+			UPDATE employees SET salary_units = CAST(round(salary * 10000) AS INTEGER);
+			DROP INDEX idx_employees_salary;
+			ALTER TABLE employees DROP COLUMN salary;
+			ALTER TABLE employees RENAME COLUMN salary_units TO salary;
+			CREATE INDEX idx_employees_salary ON employees (salary, id);
+			ALTER TABLE employees ADD COLUMN salary_currency TEXT NOT NULL DEFAULT 'USD';
+			UPDATE employees SET salary_currency = COALESCE((SELECT currency FROM compensation
+				WHERE employee_id = employees.id AND effective_date <= date('now')
+				ORDER BY effective_date DESC, id DESC LIMIT 1), 'USD');
+			ALTER TABLE compensation ADD COLUMN amount_units INTEGER NOT NULL DEFAULT 0;
+			UPDATE compensation SET amount_units = CAST(round(amount * 10000) AS INTEGER);
+			ALTER TABLE compensation DROP COLUMN amount;
+			ALTER TABLE compensation RENAME COLUMN amount_units TO amount`,
+		Down: `ALTER TABLE compensation ADD COLUMN amount_real REAL NOT NULL DEFAULT 0;
+			UPDATE compensation SET amount_real = amount / 10000.0;
+			ALTER TABLE compensation DROP COLUMN amount;
+			ALTER TABLE compensation RENAME COLUMN amount_real TO amount;
+			ALTER TABLE employees DROP COLUMN salary_currency;
+			ALTER TABLE employees ADD COLUMN salary_real REAL NOT NULL DEFAULT 0;
+			UPDATE employees SET salary_real = salary / 10000.0;
+			DROP INDEX idx_employees_salary;
+			ALTER TABLE employees DROP COLUMN salary;
+			ALTER TABLE employees RENAME COLUMN salary_real TO salary;
+			CREATE INDEX idx_employees_salary ON employees (salary, id)`,
+	},
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT    NOT NULL,
+	applied_at TEXT    NOT NULL
+)`
+
+// ErrSchemaBehind is returned by CheckSchema when migrations are pending.
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// Migrations returns the known migrations in order.
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// LatestSchemaVersion is the version the code expects the database to be at.
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the highest migration version applied to the database.
+func (s *SQLiteStore) SchemaVersion() (int, error) {
+	if _, err := s.db.Exec(createMigrationsTable); err != nil {
+		return 0, err
+	}
+	var version int
+	err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// CheckSchema reports ErrSchemaBehind if the database needs migrating before
+// the server can use it.
+func (s *SQLiteStore) CheckSchema() error {
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if latest := LatestSchemaVersion(); version < latest {
+		return fmt.Errorf("%w: at version %d, latest is %d", ErrSchemaBehind, version, latest)
+	}
+	return nil
+}
+
+// MigrateUp applies pending migrations up to and including target. A target
+// of 0 or less means the latest version.
+func (s *SQLiteStore) MigrateUp(target int) error {
+	if target <= 0 {
+		target = LatestSchemaVersion()
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+		err := s.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.Version, m.Name, time.Now().UTC().Format(time.RFC3339))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) up: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts applied migrations until the schema is at target.
+func (s *SQLiteStore) MigrateDown(target int) error {
+	if target < 0 {
+		target = 0
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		err := s.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) down: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "ems.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.CheckSchema(); !errors.Is(err, ErrSchemaBehind) {
+		t.Errorf("CheckSchema() on empty database = %v, want ErrSchemaBehind", err)
+	}
+
+	if err := s.MigrateUp(0); err != nil {
+		t.Fatalf("MigrateUp() unexpected error: %v", err)
+	}
+	if version, _ := s.SchemaVersion(); version != LatestSchemaVersion() {
+		t.Errorf("SchemaVersion() after up = %d, want %d", version, LatestSchemaVersion())
+	}
+	if err := s.CheckSchema(); err != nil {
+		t.Errorf("CheckSchema() after up unexpected error: %v", err)
+	}
+	var indexes int
+	s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name LIKE 'idx_employees_%'`).Scan(&indexes)
+	if indexes != 5 {
+		t.Errorf("found %d employee indexes after up, want 5", indexes)
+	}
+
+	// Running up again is a no-op.
+	if err := s.MigrateUp(0); err != nil {
+		t.Errorf("second MigrateUp() unexpected error: %v", err)
+	}
+
+	if err := s.MigrateDown(0); err != nil {
+		t.Fatalf("MigrateDown() unexpected error: %v", err)
+	}
+	if version, _ := s.SchemaVersion(); version != 0 {
+		t.Errorf("SchemaVersion() after down = %d, want 0", version)
+	}
+	if _, err := s.CreateEmployee("John Doe", "Developer", usd("60000")); err == nil {
+		t.Errorf("CreateEmployee() after down returned no error")
+	}
+}
+
+func TestMigrationsAreSequential(t *testing.T) {
+	for i, m := range Migrations() {
+		if m.Version != i+1 {
+			t.Errorf("migration %q has version %d, want %d", m.Name, m.Version, i+1)
+		}
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("migration %d (%s) is missing an up or down step", m.Version, m.Name)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"cmp"
+	"ems/models"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Relevance weights. A term found in the name counts for more than one found
+// in the position, and an exact term beats a prefix, which beats a typo.
+const (
+	nameWeight     = 2.0
+	positionWeight = 1.0
+
+	exactScore  = 1.0
+	prefixScore = 0.8
+	fuzzyScore  = 0.6
+)
+
+// searchIndex is an inverted index from lower-cased terms to the employees
+// whose name or position contains them. It is not safe for concurrent use;
+// the owning store guards it with its own lock.
+type searchIndex struct {
+	// postings[term][id] is the summed field weight of term in employee id.
+	postings map[string]map[int]float64
+	// terms[id] remembers what was indexed for id so it can be removed.
+	terms map[int][]string
+	docs  map[int]models.Employee
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[int]float64),
+		terms:    make(map[int][]string),
+		docs:     make(map[int]models.Employee),
+	}
+}
+
+// tokenize splits s into lower-cased runs of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (idx *searchIndex) add(e models.Employee) {
+	idx.remove(e.ID)
+
+	weights := make(map[string]float64)
+	for _, term := range tokenize(e.Name) {
+		weights[term] += nameWeight
+	}
+	for _, term := range tokenize(e.Position) {
+		weights[term] += positionWeight
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[int]float64)
+		}
+		idx.postings[term][e.ID] = weight
+		terms = append(terms, term)
+	}
+	idx.terms[e.ID] = terms
+	idx.docs[e.ID] = e
+}
+
+func (idx *searchIndex) remove(id int) {
+	for _, term := range idx.terms[id] {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.terms, id)
+	delete(idx.docs, id)
+}
+
+// search ranks employees against query and returns at most limit results,
+// best first. Each query term is matched against every indexed term exactly,
+// as a prefix, or within a small edit distance; an employee's score is the
+// sum of its best match per query term, scaled by the fraction of query terms
+// it matched.
+func (idx *searchIndex) search(query string, limit int) []models.EmployeeSearchResult {
+	queryTerms := tokenize(query)
+	if len(queryTerms) == 0 || limit < 1 {
+		return nil
+	}
+
+	scores := make(map[int]float64)
+	matched := make(map[int]int)
+	for _, q := range queryTerms {
+		best := make(map[int]float64)
+		for term, postings := range idx.postings {
+			score := termScore(q, term)
+			if score == 0 {
+				continue
+			}
+			for id, weight := range postings {
+				best[id] = max(best[id], score*weight)
+			}
+		}
+		for id, score := range best {
+			scores[id] += score
+			matched[id]++
+		}
+	}
+
+	results := make([]models.EmployeeSearchResult, 0, len(scores))
+	for id, score := range scores {
+		coverage := float64(matched[id]) / float64(len(queryTerms))
+		results = append(results, models.EmployeeSearchResult{Employee: idx.docs[id], Score: score * coverage})
+	}
+	slices.SortFunc(results, func(a, b models.EmployeeSearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// termScore rates how well the indexed term matches query term q, or 0 if it
+// does not match at all.
+func termScore(q, term string) float64 {
+	if term == q {
+		return exactScore
+	}
+	if strings.HasPrefix(term, q) {
+		return prefixScore
+	}
+
+	maxEdits := allowedEdits(q)
+	if maxEdits == 0 {
+		return 0
+	}
+	qr, tr := []rune(q), []rune(term)
+	if diff := len(qr) - len(tr); diff > maxEdits || -diff > maxEdits {
+		return 0
+	}
+	if d := editDistance(qr, tr); d <= maxEdits {
+		return fuzzyScore / float64(d)
+	}
+	return 0
+}
+
+// allowedEdits scales typo tolerance with the length of the query term, so
+// short terms such as "al" do not match half the index.
+func allowedEdits(q string) int {
+	switch n := len([]rune(q)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and transpositions of adjacent
+// characters each cost one.
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"alice", "alice", 0},
+		{"alice", "alise", 1},
+		{"alice", "alcie", 1},
+		{"johnson", "jonson", 1},
+		{"smith", "smyth", 1},
+		{"", "abc", 3},
+		{"developer", "devloper", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSearchEmployees(t *testing.T) {
+	stores := map[string]EmployeeRepository{
+		"memory": NewMemoryStore(),
+		"sqlite": newTestSQLiteStore(t),
+	}
+	for _, s := range stores {
+		s.CreateEmployee("John Doe", "Developer", usd("60000"))
+		s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+		s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+		s.CreateEmployee("Alicia Keys", "Developer", usd("90000"))
+		s.CreateEmployee("Johnny Developer", "Tester", usd("65000"))
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		expectedIDs []int
+	}{
+		{name: "Exact term", query: "smith", expectedIDs: []int{2}},
+		{name: "Exact beats prefix", query: "john", expectedIDs: []int{1, 3, 5}},
+		{name: "Beyond typo tolerance", query: "alice", expectedIDs: []int{2}},
+		{name: "Prefix", query: "ali", expectedIDs: []int{2, 4}},
+		{name: "Typo", query: "jonson", expectedIDs: []int{3}},
+		{name: "Transposed letters", query: "Smtih", expectedIDs: []int{2}},
+		{name: "Name outranks position", query: "developer", expectedIDs: []int{5, 1, 4}},
+		{name: "All terms beat some terms", query: "john developer", expectedIDs: []int{5, 1, 3, 4}},
+		{name: "Short terms are not fuzzy", query: "bib", expectedIDs: nil},
+		{name: "No match", query: "zzzzzz", expectedIDs: nil},
+	}
+
+	for storeName, s := range stores {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				results, err := s.SearchEmployees(tt.query, 10)
+				if err != nil {
+					t.Fatalf("SearchEmployees() unexpected error: %v", err)
+				}
+				var ids []int
+				for _, r := range results {
+					ids = append(ids, r.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.expectedIDs) {
+					t.Errorf("SearchEmployees(%q) ids = %v, want %v", tt.query, ids, tt.expectedIDs)
+				}
+			})
+		}
+	}
+}
+
+func TestSearchIndexFollowsWrites(t *testing.T) {
+	stores := map[string]EmployeeRepository{
+		"memory": NewMemoryStore(),
+		"sqlite": newTestSQLiteStore(t),
+	}
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+			// Build the index before writing so SQLite has to maintain it.
+			s.SearchEmployees("john", 10)
+
+			s.UpdateEmployee(1, "Jane Doe", "Developer", usd("60000"))
+			s.DeleteEmployee(2)
+			s.CreateEmployee("Johanna Smith", "Manager", usd("80000"))
+
+			search := func(q string) []int {
+				results, _ := s.SearchEmployees(q, 10)
+				var ids []int
+				for _, r := range results {
+					ids = append(ids, r.ID)
+				}
+				return ids
+			}
+			if ids := search("jane"); !reflect.DeepEqual(ids, []int{1}) {
+				t.Errorf("search for updated name = %v, want [1]", ids)
+			}
+			if ids := search("alice"); ids != nil {
+				t.Errorf("search for deleted employee = %v, want none", ids)
+			}
+			if ids := search("smith"); !reflect.DeepEqual(ids, []int{3}) {
+				t.Errorf("search for new employee = %v, want [3]", ids)
+			}
+		})
+	}
+}
+
+func TestSQLiteSearchIndexFollowsConcurrentWrites(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.SearchEmployees("john", 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				s.UpdateEmployee(1, "John Doe", fmt.Sprintf("Developer %d", i*25+j), usd("60000"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := s.GetEmployeeByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexed := s.fullText.docs[1]; indexed != stored {
+		t.Errorf("indexed employee = %+v, want the stored %+v", indexed, stored)
+	}
+}
+
+func TestJournalStoreSearchAfterReplay(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.DeleteEmployee(1)
+	s.Close()
+
+	s, err = NewJournalStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	results, _ := s.SearchEmployees("alice john", 10)
+	if len(results) != 1 || results[0].ID != 2 {
+		t.Errorf("SearchEmployees() after replay = %v, want only employee 2", results)
+	}
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"cmp"
+	"ems/filter"
+	"ems/models"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortKey orders employees by one field. Field is the JSON name of an
+// employee attribute.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions controls which employees ListEmployees returns and in what
+// order. Sort always ends with an ascending id tiebreaker so pages never
+// overlap.
+//
+// By default results are offset-paginated by Page. With Keyset set, Page is
+// ignored and the listing returns up to PerPage employees strictly after
+// After, or strictly before Before, in sort order. Only the sorted fields and
+// ID of the boundary employee are consulted, so it need not still exist.
+type ListOptions struct {
+	Page    int
+	PerPage int
+	Sort    []SortKey
+	Keyset  bool
+	After   *models.Employee
+	Before  *models.Employee
+	// Filter, when non-nil, restricts the listing (and the count) to
+	// matching employees before sorting and pagination.
+	Filter filter.Expr
+}
+
+// employeeColumns maps sortable and filterable JSON field names to their SQL
+// column.
+var employeeColumns = map[string]string{
+	"id":            "id",
+	"name":          "name",
+	"position":      "position",
+	"salary":        "salary",
+	"department_id": "department_id",
+	"manager_id":    "manager_id",
+}
+
+// filterColumns maps the fields that can be filtered on but not sorted by
+// to their SQL column.
+var filterColumns = map[string]string{
+	"currency": "salary_currency",
+}
+
+// ParseSort parses a comma separated list of fields such as "position,-salary".
+// A leading "-" sorts that field descending and a leading "+" is accepted for
+// ascending.
+func ParseSort(s string) ([]SortKey, error) {
+	var keys []SortKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key := SortKey{Field: part}
+		switch part[0] {
+		case '-':
+			key = SortKey{Field: part[1:], Desc: true}
+		case '+':
+			key = SortKey{Field: part[1:]}
+		}
+		if _, ok := employeeColumns[key.Field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q", key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// withTiebreaker appends an ascending id key unless id is already sorted on.
+func withTiebreaker(keys []SortKey) []SortKey {
+	for _, key := range keys {
+		if key.Field == "id" {
+			return keys
+		}
+	}
+	return append(append([]SortKey(nil), keys...), SortKey{Field: "id"})
+}
+
+func compareEmployees(a, b models.Employee, keys []SortKey) int {
+	for _, key := range keys {
+		var c int
+		switch key.Field {
+		case "id":
+			c = cmp.Compare(a.ID, b.ID)
+		case "name":
+			c = cmp.Compare(a.Name, b.Name)
+		case "position":
+			c = cmp.Compare(a.Position, b.Position)
+		case "salary":
+			c = a.Salary.Cmp(b.Salary)
+		case "department_id":
+			c = cmp.Compare(a.DepartmentID, b.DepartmentID)
+		case "manager_id":
+			c = cmp.Compare(a.ManagerID, b.ManagerID)
+		}
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func sortEmployees(employees []models.Employee, keys []SortKey) {
+	keys = withTiebreaker(keys)
+	slices.SortFunc(employees, func(a, b models.Employee) int {
+		return compareEmployees(a, b, keys)
+	})
+}
+
+// orderByClause renders keys as a SQL ORDER BY clause. Fields are looked up
+// in employeeColumns so user input never reaches the query text.
+func orderByClause(keys []SortKey) string {
+	var parts []string
+	for _, key := range withTiebreaker(keys) {
+		part := employeeColumns[key.Field]
+		if key.Desc {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// paginate returns the slice of employees that falls on the requested page.
+func paginate(employees []models.Employee, page, perPage int) []models.Employee {
+	if page < 1 || perPage < 1 {
+		return nil
+	}
+	start := (page - 1) * perPage
+	if start >= len(employees) {
+		return nil
+	}
+	end := min(start+perPage, len(employees))
+	return employees[start:end]
+}
+
+// FormatSort is the inverse of ParseSort.
+func FormatSort(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Field
+		if key.Desc {
+			parts[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
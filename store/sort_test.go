@@ -0,0 +1,105 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []SortKey
+		wantErr bool
+	}{
+		{name: "Empty", input: "", want: nil},
+		{name: "Single ascending", input: "name", want: []SortKey{{Field: "name"}}},
+		{name: "Explicit ascending", input: "+salary", want: []SortKey{{Field: "salary"}}},
+		{
+			name:  "Multi-key",
+			input: "position, -salary",
+			want:  []SortKey{{Field: "position"}, {Field: "salary", Desc: true}},
+		},
+		{name: "Unknown field", input: "age", wantErr: true},
+		{name: "Duplicate field", input: "name,-name", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSort(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListEmployeesSorted(t *testing.T) {
+	tests := []struct {
+		name        string
+		sort        []SortKey
+		expectedIDs []int
+	}{
+		{name: "Default is id", sort: nil, expectedIDs: []int{1, 2, 3, 4}},
+		{name: "Name ascending", sort: []SortKey{{Field: "name"}}, expectedIDs: []int{2, 3, 1, 4}},
+		{name: "Salary descending", sort: []SortKey{{Field: "salary", Desc: true}}, expectedIDs: []int{2, 3, 1, 4}},
+		{
+			name:        "Position then salary descending with id tiebreaker",
+			sort:        []SortKey{{Field: "position"}, {Field: "salary", Desc: true}},
+			expectedIDs: []int{3, 1, 4, 2},
+		},
+	}
+
+	stores := map[string]EmployeeRepository{
+		"memory": NewMemoryStore(),
+		"sqlite": newTestSQLiteStore(t),
+	}
+	for _, s := range stores {
+		s.CreateEmployee("John Doe", "Developer", usd("60000"))
+		s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+		s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+		s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	}
+
+	for storeName, s := range stores {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				employees, err := s.ListEmployees(ListOptions{Page: 1, PerPage: 10, Sort: tt.sort})
+				if err != nil {
+					t.Fatalf("ListEmployees() unexpected error: %v", err)
+				}
+				var ids []int
+				for _, e := range employees {
+					ids = append(ids, e.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.expectedIDs) {
+					t.Errorf("ListEmployees() ids = %v, want %v", ids, tt.expectedIDs)
+				}
+			})
+		}
+	}
+}
+
+func TestListEmployeesPagesDoNotOverlap(t *testing.T) {
+	s := NewMemoryStore()
+	for i := 0; i < 50; i++ {
+		s.CreateEmployee("Employee", "Developer", usd("60000"))
+	}
+
+	seen := make(map[int]bool)
+	for page := 1; page <= 10; page++ {
+		employees, _ := s.ListEmployees(ListOptions{Page: page, PerPage: 5, Sort: []SortKey{{Field: "name"}}})
+		for _, e := range employees {
+			if seen[e.ID] {
+				t.Fatalf("employee %d returned on more than one page", e.ID)
+			}
+			seen[e.ID] = true
+		}
+	}
+	if len(seen) != 50 {
+		t.Errorf("pages returned %d distinct employees, want 50", len(seen))
+	}
+}
@@ -0,0 +1,923 @@
+package store
+
+import (
+	"database/sql"
+	"ems/filter"
+	"ems/models"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore keeps employees in a SQLite database file so they survive
+// restarts.
+type SQLiteStore struct {
+	db *sql.DB
+	// exportDB is a separate pool for exports. The database is in WAL mode,
+	// so an export's query reads one snapshot for as long as it runs
+	// without taking db's only connection or blocking its writes. It is nil
+	// for in-memory databases, which a second pool could not see.
+	exportDB *sql.DB
+
+	// fullText is built from the table on the first search and then kept in
+	// step by this store's writes. Writes made by other processes sharing
+	// the file are not seen until restart.
+	fullTextMu sync.Mutex
+	fullText   *searchIndex
+	// writeMu is held by every write that changes employees from before its
+	// transaction begins until it has updated fullText, so the index sees
+	// writes in the order they committed.
+	writeMu sync.Mutex
+
+	// now dates the salary entries the store records itself.
+	now func() time.Time
+}
+
+// NewSQLiteStore opens (or creates) the database at path. The schema is not
+// touched; call MigrateUp or CheckSchema before serving requests.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids "database is locked".
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`PRAGMA journal_mode = WAL`); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLiteStore{db: db, now: time.Now}
+	if inMemory(path) {
+		return s, nil
+	}
+	dsn, err := exportDSN(path)
+	if err == nil {
+		s.exportDB, err = sql.Open("sqlite", dsn)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// inMemory reports whether path names an in-memory database, which every
+// pool opens afresh.
+func inMemory(path string) bool {
+	name, rawQuery, _ := strings.Cut(path, "?")
+	query, _ := url.ParseQuery(rawQuery)
+	return name == "" || name == ":memory:" || strings.HasPrefix(name, "file::memory:") || query.Get("mode") == "memory"
+}
+
+// exportDSN adds a busy timeout to the query string of path, which may
+// already have one, so exports wait out a checkpoint instead of failing.
+func exportDSN(path string) (string, error) {
+	name, rawQuery, _ := strings.Cut(path, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", err
+	}
+	query.Add("_pragma", "busy_timeout(5000)")
+	return name + "?" + query.Encode(), nil
+}
+
+// Salaries are stored as a whole number of models.Money units in salary,
+// with the currency in salary_currency, and compensation amounts likewise.
+const (
+	employeeColumnList = `id, name, position, salary, salary_currency, department_id, manager_id, version`
+	employeeSelect     = `SELECT ` + employeeColumnList + ` FROM employees`
+)
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEmployee(row rowScanner) (models.Employee, error) {
+	var (
+		employee models.Employee
+		units    int64
+		currency string
+	)
+	err := row.Scan(&employee.ID, &employee.Name, &employee.Position, &units, &currency, &employee.DepartmentID, &employee.ManagerID, &employee.Version)
+	employee.Salary = models.NewMoney(units, currency)
+	return employee, err
+}
+
+// scanEmployees reads every row and closes rows. It never returns a nil
+// slice without an error.
+func scanEmployees(rows *sql.Rows) ([]models.Employee, error) {
+	defer rows.Close()
+	employees := []models.Employee{}
+	for rows.Next() {
+		employee, err := scanEmployee(rows)
+		if err != nil {
+			return nil, err
+		}
+		employees = append(employees, employee)
+	}
+	return employees, rows.Err()
+}
+
+func (s *SQLiteStore) Close() error {
+	if s.exportDB == nil {
+		return s.db.Close()
+	}
+	return errors.Join(s.exportDB.Close(), s.db.Close())
+}
+
+func (s *SQLiteStore) CreateEmployee(name, position string, salary models.Money) (models.Employee, error) {
+	return s.InsertEmployee(models.Employee{Name: name, Position: position, Salary: salary})
+}
+
+func (s *SQLiteStore) InsertEmployee(e models.Employee) (models.Employee, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	employee := e
+	employee.Version = 1
+	employee.Salary = employee.Salary.DefaultTo(models.DefaultCurrency)
+	err := s.inTx(func(tx *sql.Tx) error {
+		if err := validateIn(tx, employee); err != nil {
+			return err
+		}
+		err := tx.QueryRow(`INSERT INTO employees (name, position, salary, salary_currency, department_id, manager_id) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+			employee.Name, employee.Position, employee.Salary.Units(), employee.Salary.Currency(), employee.DepartmentID, employee.ManagerID).Scan(&employee.ID)
+		if err != nil {
+			return err
+		}
+		return recordSalary(tx, nil, employee, dateOf(s.now()))
+	})
+	if err != nil {
+		return models.Employee{}, err
+	}
+
+	s.indexed(func(idx *searchIndex) { idx.add(employee) })
+	return employee, nil
+}
+
+func (s *SQLiteStore) GetEmployeeByID(id int) (models.Employee, error) {
+	employee, err := scanEmployee(s.db.QueryRow(employeeSelect+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Employee{}, &NotFoundError{ID: id}
+	}
+	if err != nil {
+		return models.Employee{}, err
+	}
+	return employee, nil
+}
+
+func (s *SQLiteStore) UpdateEmployee(id int, name, position string, salary models.Money) (models.Employee, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var employee models.Employee
+	err := s.inTx(func(tx *sql.Tx) error {
+		current, err := scanEmployee(tx.QueryRow(employeeSelect+` WHERE id = ?`, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return &NotFoundError{ID: id}
+		}
+		if err != nil {
+			return err
+		}
+
+		employee = current
+		employee.Name = name
+		employee.Position = position
+		employee.Salary = salary.DefaultTo(current.Salary.Currency())
+		employee.Version++
+		if err := validate(employee); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE employees SET name = ?, position = ?, salary = ?, salary_currency = ?, version = ? WHERE id = ?`,
+			name, position, employee.Salary.Units(), employee.Salary.Currency(), employee.Version, id)
+		if err != nil {
+			return err
+		}
+		return recordSalary(tx, &current, employee, dateOf(s.now()))
+	})
+	if err != nil {
+		return models.Employee{}, err
+	}
+
+	s.indexed(func(idx *searchIndex) { idx.add(employee) })
+	return employee, nil
+}
+
+// PatchEmployee reads and rewrites the row inside one transaction, so no
+// other write can land in between.
+func (s *SQLiteStore) PatchEmployee(id int, patch func(models.Employee) (models.Employee, error)) (models.Employee, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var patched models.Employee
+	err := s.inTx(func(tx *sql.Tx) error {
+		employee, err := scanEmployee(tx.QueryRow(employeeSelect+` WHERE id = ?`, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return &NotFoundError{ID: id}
+		}
+		if err != nil {
+			return err
+		}
+
+		patched, err = patch(employee)
+		if err != nil {
+			return err
+		}
+		patched.ID = id
+		patched.Version = employee.Version + 1
+		patched.Salary = patched.Salary.DefaultTo(employee.Salary.Currency())
+		if err := validateIn(tx, patched); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE employees SET name = ?, position = ?, salary = ?, salary_currency = ?, department_id = ?, manager_id = ?, version = ? WHERE id = ?`,
+			patched.Name, patched.Position, patched.Salary.Units(), patched.Salary.Currency(), patched.DepartmentID, patched.ManagerID, patched.Version, id)
+		if err != nil {
+			return err
+		}
+		return recordSalary(tx, &employee, patched, dateOf(s.now()))
+	})
+	if err != nil {
+		return models.Employee{}, err
+	}
+
+	s.indexed(func(idx *searchIndex) { idx.add(patched) })
+	return patched, nil
+}
+
+func (s *SQLiteStore) DeleteEmployee(id int) error {
+	return s.DeleteEmployeeIf(id, nil)
+}
+
+func (s *SQLiteStore) DeleteEmployeeIf(id int, check func(models.Employee) error) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var reassigned []models.Employee
+	err := s.inTx(func(tx *sql.Tx) error {
+		employee, err := scanEmployee(tx.QueryRow(employeeSelect+` WHERE id = ?`, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return &NotFoundError{ID: id}
+		}
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(employee); err != nil {
+				return err
+			}
+		}
+		reassigned, err = deleteEmployee(tx, employee)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.indexed(func(idx *searchIndex) {
+		for _, report := range reassigned {
+			idx.add(report)
+		}
+		idx.remove(id)
+	})
+	return nil
+}
+
+// deleteEmployee deletes e and its salary history in tx and hands its
+// direct reports to e's own manager, returning them as they now are.
+func deleteEmployee(tx *sql.Tx, e models.Employee) ([]models.Employee, error) {
+	rows, err := tx.Query(`UPDATE employees SET manager_id = ?, version = version + 1
+		WHERE manager_id = ? RETURNING `+employeeColumnList, e.ManagerID, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := scanEmployees(rows)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM compensation WHERE employee_id = ?`, e.ID); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`DELETE FROM employees WHERE id = ?`, e.ID)
+	return reports, err
+}
+
+// errRollback makes inTx roll back a batch whose results are already known.
+var errRollback = errors.New("rollback")
+
+// ApplyBatch runs ops in a single transaction. Operations fail before they
+// write anything, so best-effort batches can commit the rest, and atomic
+// ones roll back if any failed.
+func (s *SQLiteStore) ApplyBatch(ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	results := make([]BatchResult, len(ops))
+	reassigned := make([][]models.Employee, len(ops))
+	today := dateOf(s.now())
+	err := s.inTx(func(tx *sql.Tx) error {
+		for i, op := range ops {
+			if err := applyBatchOp(tx, op, today, &results[i], &reassigned[i]); err != nil {
+				return err
+			}
+		}
+		if atomic && abortBatch(results) {
+			return errRollback
+		}
+		return nil
+	})
+	if errors.Is(err, errRollback) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.indexed(func(idx *searchIndex) {
+		for i, result := range results {
+			switch {
+			case result.Err != nil:
+			case ops[i].Action == BatchDelete:
+				for _, report := range reassigned[i] {
+					idx.add(report)
+				}
+				idx.remove(result.Employee.ID)
+			default:
+				idx.add(result.Employee)
+			}
+		}
+	})
+	return results, nil
+}
+
+// applyBatchOp runs op in tx, dating any salary it records today, and
+// records its outcome in result, adding any reports a delete hands to
+// another manager to reassigned. The returned error is a database failure,
+// which aborts the whole batch.
+func applyBatchOp(tx *sql.Tx, op BatchOp, today string, result *BatchResult, reassigned *[]models.Employee) error {
+	var (
+		current models.Employee
+		exists  bool
+	)
+	if op.Action != BatchCreate {
+		var err error
+		current, err = scanEmployee(tx.QueryRow(employeeSelect+` WHERE id = ?`, op.ID))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		exists = err == nil
+	}
+
+	// A failed department or manager lookup is a database failure, not the
+	// op's.
+	var dbErr error
+	employee, err := resolveBatchOp(op, current, exists, func(e models.Employee) error {
+		err := validateIn(tx, e)
+		var (
+			validationErr *ValidationError
+			conflictErr   *ConflictError
+		)
+		if err != nil && !errors.As(err, &validationErr) && !errors.As(err, &conflictErr) {
+			dbErr = err
+		}
+		return err
+	})
+	if dbErr != nil {
+		return dbErr
+	}
+	if err != nil {
+		result.Err = err
+		return nil
+	}
+	switch op.Action {
+	case BatchCreate:
+		err = tx.QueryRow(`INSERT INTO employees (name, position, salary, salary_currency, department_id, manager_id) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+			employee.Name, employee.Position, employee.Salary.Units(), employee.Salary.Currency(), employee.DepartmentID, employee.ManagerID).Scan(&employee.ID)
+		if err == nil {
+			err = recordSalary(tx, nil, employee, today)
+		}
+	case BatchUpdate:
+		_, err = tx.Exec(`UPDATE employees SET name = ?, position = ?, salary = ?, salary_currency = ?, department_id = ?, manager_id = ?, version = ? WHERE id = ?`,
+			employee.Name, employee.Position, employee.Salary.Units(), employee.Salary.Currency(), employee.DepartmentID, employee.ManagerID, employee.Version, employee.ID)
+		if err == nil {
+			err = recordSalary(tx, &current, employee, today)
+		}
+	case BatchDelete:
+		*reassigned, err = deleteEmployee(tx, employee)
+	}
+	result.Employee = employee
+	return err
+}
+
+func (s *SQLiteStore) ListEmployees(opts ListOptions) ([]models.Employee, error) {
+	if opts.PerPage < 1 || (!opts.Keyset && opts.Page < 1) {
+		return nil, nil
+	}
+
+	var conditions []string
+	var args []any
+	if opts.Filter != nil {
+		where, whereArgs := filterWhere(opts.Filter)
+		conditions = append(conditions, where)
+		args = append(args, whereArgs...)
+	}
+
+	keys := withTiebreaker(opts.Sort)
+	order := orderByClause(keys)
+	var limit string
+	switch {
+	case !opts.Keyset:
+		limit = ` LIMIT ? OFFSET ?`
+	case opts.Before != nil:
+		// Walk backwards from the boundary and flip the rows afterwards.
+		where, whereArgs := keysetWhere(keys, *opts.Before, true)
+		conditions = append(conditions, where)
+		args = append(args, whereArgs...)
+		order = orderByClause(reverseKeys(keys))
+		limit = ` LIMIT ?`
+	case opts.After != nil:
+		where, whereArgs := keysetWhere(keys, *opts.After, false)
+		conditions = append(conditions, where)
+		args = append(args, whereArgs...)
+		limit = ` LIMIT ?`
+	default:
+		limit = ` LIMIT ?`
+	}
+	args = append(args, opts.PerPage)
+	if !opts.Keyset {
+		args = append(args, (opts.Page-1)*opts.PerPage)
+	}
+
+	query := employeeSelect + whereClause(conditions) + ` ` + order + limit
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var employees []models.Employee
+	for rows.Next() {
+		employee, err := scanEmployee(rows)
+		if err != nil {
+			return nil, err
+		}
+		employees = append(employees, employee)
+	}
+	if opts.Keyset && opts.Before != nil {
+		slices.Reverse(employees)
+	}
+	return employees, rows.Err()
+}
+
+func (s *SQLiteStore) CountEmployees(opts ListOptions) (int, error) {
+	var conditions []string
+	var args []any
+	if opts.Filter != nil {
+		where, whereArgs := filterWhere(opts.Filter)
+		conditions = append(conditions, where)
+		args = append(args, whereArgs...)
+	}
+
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM employees`+whereClause(conditions), args...).Scan(&count)
+	return count, err
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func (s *SQLiteStore) SearchEmployees(query string, limit int) ([]models.EmployeeSearchResult, error) {
+	s.fullTextMu.Lock()
+	defer s.fullTextMu.Unlock()
+
+	if s.fullText == nil {
+		idx := newSearchIndex()
+		rows, err := s.db.Query(employeeSelect)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			employee, err := scanEmployee(rows)
+			if err != nil {
+				return nil, err
+			}
+			idx.add(employee)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		s.fullText = idx
+	}
+	return s.fullText.search(query, limit), nil
+}
+
+func (s *SQLiteStore) ExportEmployees(where filter.Expr, fn func(models.Employee) error) error {
+	query, args := employeeSelect, []any(nil)
+	if where != nil {
+		var condition string
+		condition, args = filterWhere(where)
+		query += whereClause([]string{condition})
+	}
+	if s.exportDB == nil {
+		// An in-memory database has only db's one connection, so read the
+		// whole snapshot before handing it out rather than hold it.
+		rows, err := s.db.Query(query+` ORDER BY id`, args...)
+		if err != nil {
+			return err
+		}
+		employees, err := scanEmployees(rows)
+		if err != nil {
+			return err
+		}
+		for _, employee := range employees {
+			if err := fn(employee); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rows, err := s.exportDB.Query(query+` ORDER BY id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		employee, err := scanEmployee(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(employee); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *SQLiteStore) CreateDepartment(name string) (models.Department, error) {
+	department := models.Department{Name: name}
+	if err := validateDepartment(department); err != nil {
+		return models.Department{}, err
+	}
+	err := s.inTx(func(tx *sql.Tx) error {
+		if err := checkDepartmentName(tx, department); err != nil {
+			return err
+		}
+		return tx.QueryRow(`INSERT INTO departments (name) VALUES (?) RETURNING id`, name).Scan(&department.ID)
+	})
+	if err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+func (s *SQLiteStore) GetDepartmentByID(id int) (models.Department, error) {
+	var department models.Department
+	err := s.db.QueryRow(`SELECT id, name FROM departments WHERE id = ?`, id).Scan(&department.ID, &department.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Department{}, &NotFoundError{Resource: "department", ID: id}
+	}
+	if err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+func (s *SQLiteStore) UpdateDepartment(id int, name string) (models.Department, error) {
+	department := models.Department{ID: id, Name: name}
+	if err := validateDepartment(department); err != nil {
+		return models.Department{}, err
+	}
+	err := s.inTx(func(tx *sql.Tx) error {
+		if err := checkDepartmentName(tx, department); err != nil {
+			return err
+		}
+		res, err := tx.Exec(`UPDATE departments SET name = ? WHERE id = ?`, name, id)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err == nil && n == 0 {
+			err = &NotFoundError{Resource: "department", ID: id}
+		}
+		return err
+	})
+	if err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+// DeleteDepartment counts the members and deletes inside one transaction,
+// so no employee can join in between.
+func (s *SQLiteStore) DeleteDepartment(id int) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM departments WHERE id = ?)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return &NotFoundError{Resource: "department", ID: id}
+		}
+		var members int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM employees WHERE department_id = ?`, id).Scan(&members); err != nil {
+			return err
+		}
+		if members > 0 {
+			return departmentNotEmpty(id, members)
+		}
+		_, err := tx.Exec(`DELETE FROM departments WHERE id = ?`, id)
+		return err
+	})
+}
+
+func (s *SQLiteStore) ListDepartments() ([]models.Department, error) {
+	rows, err := s.db.Query(`SELECT id, name FROM departments ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	departments := []models.Department{}
+	for rows.Next() {
+		var department models.Department
+		if err := rows.Scan(&department.ID, &department.Name); err != nil {
+			return nil, err
+		}
+		departments = append(departments, department)
+	}
+	return departments, rows.Err()
+}
+
+// checkDepartmentName returns a ConflictError if a department other than d
+// already has d's name.
+func checkDepartmentName(tx *sql.Tx, d models.Department) error {
+	var taken bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM departments WHERE name = ? AND id <> ?)`, d.Name, d.ID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return duplicateDepartment(d.Name)
+	}
+	return nil
+}
+
+// validateIn checks e against the rules and, in tx, that its department
+// and manager exist and that its manager does not report to it.
+func validateIn(tx *sql.Tx, e models.Employee) error {
+	if err := validate(e); err != nil {
+		return err
+	}
+	if e.DepartmentID != 0 {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM departments WHERE id = ?)`, e.DepartmentID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return missingDepartment(e.DepartmentID)
+		}
+	}
+	if e.ManagerID == 0 {
+		return nil
+	}
+	var exists, cycle bool
+	err := tx.QueryRow(`WITH RECURSIVE chain (id) AS (
+			SELECT id FROM employees WHERE id = ?
+			UNION
+			SELECT manager_id FROM employees JOIN chain USING (id) WHERE manager_id <> 0
+		)
+		SELECT EXISTS (SELECT 1 FROM chain), EXISTS (SELECT 1 FROM chain WHERE id = ?)`, e.ManagerID, e.ID).Scan(&exists, &cycle)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return missingManager(e.ManagerID)
+	}
+	if cycle {
+		return managerCycle(e.ID, e.ManagerID)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DirectReports(id int) ([]models.Employee, error) {
+	return s.queryHierarchy(id, employeeSelect+` WHERE manager_id = ? ORDER BY id`, id)
+}
+
+func (s *SQLiteStore) ManagementChain(id int) ([]models.Employee, error) {
+	return s.queryHierarchy(id, `WITH RECURSIVE chain (id, depth) AS (
+			SELECT manager_id, 1 FROM employees WHERE id = ? AND manager_id <> 0
+			UNION ALL
+			SELECT manager_id, depth + 1 FROM employees JOIN chain USING (id) WHERE manager_id <> 0
+		)
+		SELECT `+qualified("e")+` FROM chain JOIN employees e USING (id) ORDER BY depth`, id)
+}
+
+func (s *SQLiteStore) Subordinates(id int) ([]models.Employee, error) {
+	return s.queryHierarchy(id, `WITH RECURSIVE tree (id, depth) AS (
+			SELECT id, 1 FROM employees WHERE manager_id = ?
+			UNION ALL
+			SELECT e.id, depth + 1 FROM employees e JOIN tree ON e.manager_id = tree.id
+		)
+		SELECT `+qualified("e")+` FROM tree JOIN employees e USING (id) ORDER BY depth, id`, id)
+}
+
+// queryHierarchy runs query in one read transaction after checking that
+// employee id exists, so the two agree.
+func (s *SQLiteStore) queryHierarchy(id int, query string, args ...any) ([]models.Employee, error) {
+	var employees []models.Employee
+	err := s.inTx(func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM employees WHERE id = ?)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return &NotFoundError{ID: id}
+		}
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		employees, err = scanEmployees(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return employees, nil
+}
+
+// qualified returns employeeColumnList with each column prefixed by alias.
+func qualified(alias string) string {
+	return alias + "." + strings.ReplaceAll(employeeColumnList, ", ", ", "+alias+".")
+}
+
+// indexed applies fn to the search index if it has been built. Callers
+// must hold s.writeMu.
+func (s *SQLiteStore) indexed(fn func(idx *searchIndex)) {
+	s.fullTextMu.Lock()
+	defer s.fullTextMu.Unlock()
+	if s.fullText != nil {
+		fn(s.fullText)
+	}
+}
+
+// compensationColumnList are the columns scanCompensation reads.
+const compensationColumnList = `id, employee_id, amount, currency, effective_date, reason`
+
+func scanCompensation(row rowScanner) (models.Compensation, error) {
+	var (
+		c        models.Compensation
+		units    int64
+		currency string
+	)
+	err := row.Scan(&c.ID, &c.EmployeeID, &units, &currency, &c.EffectiveDate, &c.Reason)
+	c.Salary = models.NewMoney(units, currency)
+	return c, err
+}
+
+// recordSalary records e's salary in tx, effective today, unless before has
+// the same salary. before is nil for a new employee.
+func recordSalary(tx *sql.Tx, before *models.Employee, e models.Employee, today string) error {
+	reason := reasonHired
+	if before != nil {
+		if before.Salary == e.Salary {
+			return nil
+		}
+		reason = reasonUpdated
+	}
+	_, err := tx.Exec(`INSERT INTO compensation (employee_id, amount, currency, effective_date, reason)
+		VALUES (?, ?, ?, ?, ?)`, e.ID, e.Salary.Units(), e.Salary.Currency(), today, reason)
+	return err
+}
+
+func (s *SQLiteStore) AddCompensation(id int, c models.Compensation) (models.Compensation, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	today := dateOf(s.now())
+	var updated *models.Employee
+	err := s.inTx(func(tx *sql.Tx) error {
+		employee, err := scanEmployee(tx.QueryRow(employeeSelect+` WHERE id = ?`, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return &NotFoundError{ID: id}
+		}
+		if err != nil {
+			return err
+		}
+
+		c.EmployeeID = id
+		c.Salary = c.Salary.DefaultTo(employee.Salary.Currency())
+		if c.EffectiveDate == "" {
+			c.EffectiveDate = today
+		}
+		if err := validateCompensation(c); err != nil {
+			return err
+		}
+		err = tx.QueryRow(`INSERT INTO compensation (employee_id, amount, currency, effective_date, reason)
+			VALUES (?, ?, ?, ?, ?) RETURNING id`, id, c.Salary.Units(), c.Salary.Currency(), c.EffectiveDate, c.Reason).Scan(&c.ID)
+		if err != nil {
+			return err
+		}
+
+		current, err := scanCompensation(tx.QueryRow(`SELECT `+compensationColumnList+` FROM compensation
+			WHERE employee_id = ? AND effective_date <= ?
+			ORDER BY effective_date DESC, id DESC LIMIT 1`, id, today))
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && current.Salary == employee.Salary) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		employee.Salary = current.Salary
+		employee.Version++
+		_, err = tx.Exec(`UPDATE employees SET salary = ?, salary_currency = ?, version = ? WHERE id = ?`,
+			employee.Salary.Units(), employee.Salary.Currency(), employee.Version, id)
+		if err != nil {
+			return err
+		}
+		updated = &employee
+		return nil
+	})
+	if err != nil {
+		return models.Compensation{}, err
+	}
+	if updated != nil {
+		s.indexed(func(idx *searchIndex) { idx.add(*updated) })
+	}
+	return c, nil
+}
+
+func (s *SQLiteStore) CompensationHistory(id int) ([]models.Compensation, error) {
+	history := []models.Compensation{}
+	err := s.inTx(func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM employees WHERE id = ?)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return &NotFoundError{ID: id}
+		}
+		rows, err := tx.Query(`SELECT `+compensationColumnList+` FROM compensation
+			WHERE employee_id = ? ORDER BY effective_date, id`, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			c, err := scanCompensation(rows)
+			if err != nil {
+				return err
+			}
+			history = append(history, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (s *SQLiteStore) ApplyDueCompensation(now time.Time) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var due []models.Employee
+	err := s.inTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(`UPDATE employees SET salary = due.amount, salary_currency = due.currency, version = version + 1
+			FROM (
+				SELECT c.employee_id, c.amount, c.currency FROM compensation c
+				WHERE c.id = (SELECT id FROM compensation
+					WHERE employee_id = c.employee_id AND effective_date <= ?
+					ORDER BY effective_date DESC, id DESC LIMIT 1)
+			) AS due
+			WHERE employees.id = due.employee_id
+				AND (employees.salary <> due.amount OR employees.salary_currency <> due.currency)
+			RETURNING `+employeeColumnList, dateOf(now))
+		if err != nil {
+			return err
+		}
+		due, err = scanEmployees(rows)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.indexed(func(idx *searchIndex) {
+		for _, employee := range due {
+			idx.add(employee)
+		}
+	})
+	return len(due), nil
+}
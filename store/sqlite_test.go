@@ -0,0 +1,169 @@
+package store
+
+import (
+	"ems/models"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "ems.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() unexpected error: %v", err)
+	}
+	if err := s.MigrateUp(0); err != nil {
+		t.Fatalf("MigrateUp() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteStoreCRUD(t *testing.T) {
+	s := newTestSQLiteStore(t)
+
+	created, err := s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	if err != nil {
+		t.Fatalf("CreateEmployee() unexpected error: %v", err)
+	}
+	want := models.Employee{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000"), Version: 1}
+	if !reflect.DeepEqual(created, want) {
+		t.Errorf("CreateEmployee() = %v, want %v", created, want)
+	}
+
+	got, err := s.GetEmployeeByID(1)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEmployeeByID() = %v, %v, want %v", got, err, want)
+	}
+
+	updated, err := s.UpdateEmployee(1, "Updated John Doe", "Senior Developer", usd("70000"))
+	if err != nil {
+		t.Fatalf("UpdateEmployee() unexpected error: %v", err)
+	}
+	if updated.Name != "Updated John Doe" {
+		t.Errorf("UpdateEmployee() = %v, want name %q", updated, "Updated John Doe")
+	}
+
+	if _, err := s.UpdateEmployee(19, "New Employee", "Tester", usd("50000")); err == nil {
+		t.Errorf("UpdateEmployee() on missing employee returned no error")
+	}
+
+	if err := s.DeleteEmployee(1); err != nil {
+		t.Errorf("DeleteEmployee() unexpected error: %v", err)
+	}
+	if err := s.DeleteEmployee(1); err == nil {
+		t.Errorf("DeleteEmployee() on missing employee returned no error")
+	}
+	if _, err := s.GetEmployeeByID(1); err == nil {
+		t.Errorf("GetEmployeeByID() after delete returned no error")
+	}
+}
+
+func TestSQLiteStoreListEmployees(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	s.CreateEmployee("Eve Williams", "Tester", usd("65000"))
+
+	if count, err := s.CountEmployees(ListOptions{}); err != nil || count != 4 {
+		t.Errorf("CountEmployees() = %v, %v, want 4", count, err)
+	}
+
+	tests := []struct {
+		name        string
+		page        int
+		perPage     int
+		expectedIDs []int
+	}{
+		{name: "First page", page: 1, perPage: 2, expectedIDs: []int{1, 2}},
+		{name: "Second page", page: 2, perPage: 3, expectedIDs: []int{4}},
+		{name: "Past the end", page: 3, perPage: 10, expectedIDs: nil},
+		{name: "Invalid page", page: 0, perPage: 2, expectedIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			employees, err := s.ListEmployees(ListOptions{Page: tt.page, PerPage: tt.perPage})
+			if err != nil {
+				t.Fatalf("ListEmployees() unexpected error: %v", err)
+			}
+			var ids []int
+			for _, e := range employees {
+				ids = append(ids, e.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("ListEmployees() ids = %v, want %v", ids, tt.expectedIDs)
+			}
+		})
+	}
+}
+
+func TestSQLiteStorePersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ems.db")
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.Close()
+
+	s, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.GetEmployeeByID(1); err != nil {
+		t.Errorf("GetEmployeeByID() after reopen unexpected error: %v", err)
+	}
+}
+
+func TestSQLiteStoreExportsFromAnyPath(t *testing.T) {
+	for name, path := range map[string]string{
+		"In memory":        ":memory:",
+		"With a query":     filepath.Join(t.TempDir(), "ems.db") + "?_pragma=foreign_keys(1)",
+		"Shared in memory": "file::memory:?cache=shared",
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, err := NewSQLiteStore(path)
+			if err != nil {
+				t.Fatalf("NewSQLiteStore(%q) error = %v", path, err)
+			}
+			defer s.Close()
+			if err := s.MigrateUp(0); err != nil {
+				t.Fatal(err)
+			}
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			s.CreateEmployee("Jane Roe", "Designer", usd("65000"))
+
+			var ids []int
+			err = s.ExportEmployees(nil, func(e models.Employee) error {
+				ids = append(ids, e.ID)
+				return nil
+			})
+			if err != nil || !reflect.DeepEqual(ids, []int{1, 2}) {
+				t.Errorf("ExportEmployees() ids = %v, %v, want [1 2]", ids, err)
+			}
+		})
+	}
+}
+
+func TestExportDSN(t *testing.T) {
+	tests := map[string]string{
+		"ems.db":                              "ems.db?_pragma=busy_timeout%285000%29",
+		"file:ems.db?_pragma=foreign_keys(1)": "file:ems.db?_pragma=foreign_keys%281%29&_pragma=busy_timeout%285000%29",
+	}
+	for path, want := range tests {
+		if got, err := exportDSN(path); err != nil || got != want {
+			t.Errorf("exportDSN(%q) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+	if _, err := exportDSN("ems.db?%zz"); err == nil {
+		t.Errorf("exportDSN() accepted a malformed query")
+	}
+}
@@ -1,87 +1,414 @@
 package store
 
 import (
+	"ems/filter"
 	"ems/models"
-	"errors"
+	"math"
+	"slices"
 	"sync"
+	"time"
 )
 
-var (
-	employees = make(map[int]models.Employee)
-	nextID    = 1
-	mu        sync.Mutex
-)
+// EmployeeRepository is the set of operations the handlers need from a
+// backend that stores employees. Departments and salary histories live in
+// the same backend, so it can keep employees' references to them intact.
+type EmployeeRepository interface {
+	DepartmentRepository
+	CompensationRepository
+
+	// CreateEmployee stores a new employee in no department and reporting
+	// to no one.
+	CreateEmployee(name, position string, salary models.Money) (models.Employee, error)
+	// InsertEmployee stores e as a new employee, department and manager
+	// included, and returns it with its id and version set; any id or
+	// version in e is ignored.
+	InsertEmployee(e models.Employee) (models.Employee, error)
+	GetEmployeeByID(id int) (models.Employee, error)
+	UpdateEmployee(id int, name, position string, salary models.Money) (models.Employee, error)
+	// PatchEmployee reads employee id, passes it to patch and stores the
+	// result, all as one atomic step. An error from patch is returned as is
+	// and nothing is written. The employee's id cannot be changed, and its
+	// version is bumped regardless of what patch sets.
+	PatchEmployee(id int, patch func(models.Employee) (models.Employee, error)) (models.Employee, error)
+	// DeleteEmployee deletes employee id. Its direct reports are handed to
+	// its own manager, or left without one, and their versions bumped.
+	DeleteEmployee(id int) error
+	// DeleteEmployeeIf deletes employee id unless check, given the current
+	// employee, returns an error; that error is returned as is. A nil check
+	// always deletes.
+	DeleteEmployeeIf(id int, check func(models.Employee) error) error
+	// ApplyBatch runs ops in order and returns one result per op. With
+	// atomic set, either every op is applied or none is, and the ops that
+	// would have succeeded fail with ErrBatchAborted. The error is only for
+	// failures of the store itself.
+	ApplyBatch(ops []BatchOp, atomic bool) ([]BatchResult, error)
+	ListEmployees(opts ListOptions) ([]models.Employee, error)
+	// CountEmployees returns how many employees a listing with opts would
+	// cover across all pages.
+	CountEmployees(opts ListOptions) (int, error)
+	// DirectReports returns the employees who report to employee id, in id
+	// order.
+	DirectReports(id int) ([]models.Employee, error)
+	// ManagementChain returns employee id's manager, that manager's manager
+	// and so on up to someone with no manager.
+	ManagementChain(id int) ([]models.Employee, error)
+	// Subordinates returns everyone who reports to employee id, directly or
+	// not, level by level and in id order within each level.
+	Subordinates(id int) ([]models.Employee, error)
+	// SearchEmployees ranks employees by how well their name and position
+	// match query, tolerating prefixes and typos, and returns the best limit.
+	SearchEmployees(query string, limit int) ([]models.EmployeeSearchResult, error)
+	// ExportEmployees calls fn with every employee matching where (all of
+	// them if nil) in id order. The employees are those of a single moment:
+	// writes made during the export are neither seen nor held up by it. It
+	// stops at the first error from fn and returns it.
+	ExportEmployees(where filter.Expr, fn func(models.Employee) error) error
+}
 
-func CreateEmployee(name, position string, salary float64) models.Employee {
-	mu.Lock()
-	defer mu.Unlock()
+// MemoryStore keeps employees in a map guarded by a mutex, along with
+// secondary indexes for listing and a full-text index for search.
+// Departments and salary histories are kept in further maps under the same
+// mutex.
+type MemoryStore struct {
+	employees          map[int]models.Employee
+	indexes            *secondaryIndexes
+	fullText           *searchIndex
+	nextID             int
+	departments        map[int]models.Department
+	nextDepartmentID   int
+	compensation       map[int][]models.Compensation
+	nextCompensationID int
+	// now dates the salary entries the store records itself.
+	now func() time.Time
+	mu  sync.Mutex
+}
 
-	employee := models.Employee{
-		ID:       nextID,
-		Name:     name,
-		Position: position,
-		Salary:   salary,
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		employees:          make(map[int]models.Employee),
+		indexes:            newSecondaryIndexes(),
+		fullText:           newSearchIndex(),
+		nextID:             1,
+		departments:        make(map[int]models.Department),
+		nextDepartmentID:   1,
+		compensation:       make(map[int][]models.Compensation),
+		nextCompensationID: 1,
+		now:                time.Now,
 	}
-	employees[nextID] = employee
-	nextID++
+}
 
-	return employee
+func (s *MemoryStore) CreateEmployee(name, position string, salary models.Money) (models.Employee, error) {
+	return s.InsertEmployee(models.Employee{Name: name, Position: position, Salary: salary})
+}
+
+func (s *MemoryStore) InsertEmployee(e models.Employee) (models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	employee := e
+	employee.ID = s.nextID
+	employee.Version = 1
+	employee.Salary = employee.Salary.DefaultTo(models.DefaultCurrency)
+	if err := s.validate(employee); err != nil {
+		return models.Employee{}, err
+	}
+	s.put(employee)
+	s.record(*s.nextSalaryEntry(nil, employee))
+	s.nextID++
+
+	return employee, nil
 }
 
-func GetEmployeeByID(id int) (models.Employee, error) {
-	mu.Lock()
-	defer mu.Unlock()
+func (s *MemoryStore) GetEmployeeByID(id int) (models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	employee, exists := employees[id]
+	employee, exists := s.employees[id]
 	if !exists {
-		return models.Employee{}, errors.New("employee not found")
+		return models.Employee{}, &NotFoundError{ID: id}
 	}
 	return employee, nil
 }
 
-func UpdateEmployee(id int, name, position string, salary float64) (models.Employee, error) {
-	mu.Lock()
-	defer mu.Unlock()
+func (s *MemoryStore) UpdateEmployee(id int, name, position string, salary models.Money) (models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	employee, exists := employees[id]
+	current, exists := s.employees[id]
 	if !exists {
-		return models.Employee{}, errors.New("Employee not found")
+		return models.Employee{}, &NotFoundError{ID: id}
 	}
 
+	employee := current
 	employee.Name = name
 	employee.Position = position
-	employee.Salary = salary
-	employees[id] = employee
+	employee.Salary = salary.DefaultTo(current.Salary.Currency())
+	employee.Version++
+	if err := s.validate(employee); err != nil {
+		return models.Employee{}, err
+	}
+	s.put(employee)
+	if entry := s.nextSalaryEntry(&current, employee); entry != nil {
+		s.record(*entry)
+	}
 
 	return employee, nil
 }
 
-func DeleteEmployee(id int) error {
-	mu.Lock()
-	defer mu.Unlock()
+func (s *MemoryStore) PatchEmployee(id int, patch func(models.Employee) (models.Employee, error)) (models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	employee, exists := s.employees[id]
+	if !exists {
+		return models.Employee{}, &NotFoundError{ID: id}
+	}
 
-	if _, exists := employees[id]; !exists {
-		return errors.New("Employee not found")
+	patched, err := patch(employee)
+	if err != nil {
+		return models.Employee{}, err
+	}
+	patched.ID = id
+	patched.Version = employee.Version + 1
+	patched.Salary = patched.Salary.DefaultTo(employee.Salary.Currency())
+	if err := s.validate(patched); err != nil {
+		return models.Employee{}, err
 	}
-	delete(employees, id)
+	s.put(patched)
+	if entry := s.nextSalaryEntry(&employee, patched); entry != nil {
+		s.record(*entry)
+	}
+
+	return patched, nil
+}
+
+func (s *MemoryStore) DeleteEmployee(id int) error {
+	return s.DeleteEmployeeIf(id, nil)
+}
+
+func (s *MemoryStore) DeleteEmployeeIf(id int, check func(models.Employee) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	employee, exists := s.employees[id]
+	if !exists {
+		return &NotFoundError{ID: id}
+	}
+	if check != nil {
+		if err := check(employee); err != nil {
+			return err
+		}
+	}
+	for _, report := range s.reassignReports(employee, s.lookup, nil) {
+		s.put(report)
+	}
+	s.remove(id)
 	return nil
 }
 
-func ListEmployees(page, perPage int) []models.Employee {
-	mu.Lock()
-	defer mu.Unlock()
+// ListEmployees picks the cheapest of three plans: narrow by a filter index
+// and sort the few candidates, stream an ordered index that already matches
+// the requested sort and stop once the page is full, or sort everything.
+func (s *MemoryStore) ListEmployees(opts ListOptions) ([]models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	var paginatedEmployees []models.Employee
-	start := (page - 1) * perPage
-	end := start + perPage
+	if opts.PerPage < 1 || (!opts.Keyset && opts.Page < 1) {
+		return nil, nil
+	}
 
-	count := 0
-	for _, employee := range employees {
-		if count >= start && count < end {
-			paginatedEmployees = append(paginatedEmployees, employee)
+	// Streaming visits about window / selectivity employees before the page
+	// is full, while the candidate plan visits every candidate once, so it
+	// only wins below sqrt(window * total) candidates.
+	budget := len(s.employees)
+	if s.indexes.sortable(opts.Sort) {
+		window := opts.PerPage
+		if !opts.Keyset {
+			window *= opts.Page
 		}
-		count++
+		budget = int(math.Sqrt(float64(window) * float64(len(s.employees))))
 	}
 
-	return paginatedEmployees
+	var employees []models.Employee
+	if ids, ok := s.candidates(opts.Filter, budget); ok {
+		employees = s.matchingIDs(ids, opts.Filter)
+	} else if page, ok := s.scanPage(opts); ok {
+		return page, nil
+	} else {
+		employees = s.matching(opts.Filter)
+	}
+	sortEmployees(employees, opts.Sort)
+
+	if opts.Keyset {
+		return keysetPage(employees, opts), nil
+	}
+	return paginate(employees, opts.Page, opts.PerPage), nil
+}
+
+// scanPage walks an ordered index in the requested sort order, filtering as
+// it goes, and stops as soon as the page is filled. It reports false when no
+// index matches the sort. Callers must hold s.mu.
+func (s *MemoryStore) scanPage(opts ListOptions) ([]models.Employee, bool) {
+	var (
+		boundary *models.Employee
+		before   bool
+		skip     int
+	)
+	if opts.Keyset {
+		boundary = opts.After
+		if opts.Before != nil {
+			boundary, before = opts.Before, true
+		}
+	} else {
+		skip = (opts.Page - 1) * opts.PerPage
+	}
+
+	var employees []models.Employee
+	ok := s.indexes.scanSorted(opts.Sort, boundary, before, func(id int) bool {
+		employee := s.employees[id]
+		if opts.Filter != nil && !opts.Filter.Match(employee) {
+			return true
+		}
+		if skip > 0 {
+			skip--
+			return true
+		}
+		employees = append(employees, employee)
+		return len(employees) < opts.PerPage
+	})
+	if !ok {
+		return nil, false
+	}
+	if before {
+		slices.Reverse(employees)
+	}
+	return employees, true
+}
+
+func (s *MemoryStore) CountEmployees(opts ListOptions) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if opts.Filter == nil {
+		return len(s.employees), nil
+	}
+	return len(s.matching(opts.Filter)), nil
+}
+
+// candidates returns the ids a secondary index narrows expr down to, or
+// false if no index gets it under budget ids. Callers must hold s.mu.
+func (s *MemoryStore) candidates(expr filter.Expr, budget int) ([]int, bool) {
+	if expr == nil {
+		return nil, false
+	}
+	return s.indexes.candidates(expr, budget)
+}
+
+// matching returns the employees that satisfy expr, in no particular order.
+// Callers must hold s.mu.
+func (s *MemoryStore) matching(expr filter.Expr) []models.Employee {
+	if ids, ok := s.candidates(expr, len(s.employees)); ok {
+		return s.matchingIDs(ids, expr)
+	}
+
+	employees := make([]models.Employee, 0, len(s.employees))
+	for _, employee := range s.employees {
+		if expr == nil || expr.Match(employee) {
+			employees = append(employees, employee)
+		}
+	}
+	return employees
+}
+
+// matchingIDs returns the employees among ids that satisfy expr. Callers
+// must hold s.mu.
+func (s *MemoryStore) matchingIDs(ids []int, expr filter.Expr) []models.Employee {
+	employees := make([]models.Employee, 0, len(ids))
+	for _, id := range ids {
+		if employee := s.employees[id]; expr.Match(employee) {
+			employees = append(employees, employee)
+		}
+	}
+	return employees
+}
+
+func (s *MemoryStore) SearchEmployees(query string, limit int) ([]models.EmployeeSearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fullText.search(query, limit), nil
+}
+
+func (s *MemoryStore) ExportEmployees(where filter.Expr, fn func(models.Employee) error) error {
+	s.mu.Lock()
+	rows := s.indexes.rows.Clone()
+	s.mu.Unlock()
+
+	var err error
+	rows.Ascend(func(employee models.Employee) bool {
+		if where != nil && !where.Match(employee) {
+			return true
+		}
+		err = fn(employee)
+		return err == nil
+	})
+	return err
+}
+
+// validate checks e against the rules and its references against the
+// stored employees and departments. Callers must hold s.mu.
+func (s *MemoryStore) validate(e models.Employee) error {
+	return s.validateWith(e, s.lookup)
+}
+
+// validateWith is validate with lookup finding the employees e may refer
+// to, so a batch can check against the state it has staged. Callers must
+// hold s.mu.
+func (s *MemoryStore) validateWith(e models.Employee, lookup func(id int) (models.Employee, bool)) error {
+	if err := validate(e); err != nil {
+		return err
+	}
+	if _, exists := s.departments[e.DepartmentID]; e.DepartmentID != 0 && !exists {
+		return missingDepartment(e.DepartmentID)
+	}
+	if e.ManagerID == 0 {
+		return nil
+	}
+	if _, exists := lookup(e.ManagerID); !exists {
+		return missingManager(e.ManagerID)
+	}
+	for id := e.ManagerID; id != 0; {
+		if id == e.ID {
+			return managerCycle(e.ID, e.ManagerID)
+		}
+		manager, _ := lookup(id)
+		id = manager.ManagerID
+	}
+	return nil
+}
+
+// lookup returns employee id. Callers must hold s.mu.
+func (s *MemoryStore) lookup(id int) (models.Employee, bool) {
+	e, ok := s.employees[id]
+	return e, ok
+}
+
+// put stores e and keeps every index in step. Callers must hold s.mu.
+func (s *MemoryStore) put(e models.Employee) {
+	if old, exists := s.employees[e.ID]; exists {
+		s.indexes.remove(old)
+	}
+	s.employees[e.ID] = e
+	s.indexes.add(e)
+	s.fullText.add(e)
+}
+
+// remove deletes employee id and its index entries. Callers must hold s.mu.
+func (s *MemoryStore) remove(id int) {
+	if old, exists := s.employees[id]; exists {
+		s.indexes.remove(old)
+	}
+	delete(s.employees, id)
+	delete(s.compensation, id)
+	s.fullText.remove(id)
 }
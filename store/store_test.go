@@ -1,6 +1,7 @@
 package store
 
 import (
+	"ems/filter"
 	"ems/models"
 	"errors"
 	"reflect"
@@ -8,40 +9,52 @@ import (
 	"testing"
 )
 
+// usd is amount in US dollars.
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
 func TestCreateEmployee(t *testing.T) {
 	tests := []struct {
 		name     string
 		position string
-		salary   float64
+		salary   models.Money
 		want     models.Employee
 	}{
 		{
 			name:     "John Doe",
 			position: "Developer",
-			salary:   60000.0,
+			salary:   usd("60000"),
 			want: models.Employee{
 				ID:       1,
 				Name:     "John Doe",
 				Position: "Developer",
-				Salary:   60000.0,
+				Salary:   usd("60000"),
+				Version:  1,
 			},
 		},
 		{
 			name:     "Alice Smith",
 			position: "Manager",
-			salary:   80000.0,
+			salary:   usd("80000"),
 			want: models.Employee{
 				ID:       2,
 				Name:     "Alice Smith",
 				Position: "Manager",
-				Salary:   80000.0,
+				Salary:   usd("80000"),
+				Version:  1,
 			},
 		},
 	}
 
+	s := NewMemoryStore()
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CreateEmployee(tt.name, tt.position, tt.salary)
+			got, err := s.CreateEmployee(tt.name, tt.position, tt.salary)
+			if err != nil {
+				t.Fatalf("CreateEmployee() unexpected error: %v", err)
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("CreateEmployee() = %v, want %v", got, tt.want)
 			}
@@ -51,8 +64,9 @@ func TestCreateEmployee(t *testing.T) {
 
 func TestGetEmployeeByID(t *testing.T) {
 	// Initialize some employees for testing
-	employees[1] = models.Employee{ID: 1, Name: "John Doe", Position: "Developer", Salary: 60000.0}
-	employees[2] = models.Employee{ID: 2, Name: "Alice Smith", Position: "Manager", Salary: 80000.0}
+	s := NewMemoryStore()
+	s.employees[1] = models.Employee{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000")}
+	s.employees[2] = models.Employee{ID: 2, Name: "Alice Smith", Position: "Manager", Salary: usd("80000")}
 
 	tests := []struct {
 		name        string
@@ -64,7 +78,7 @@ func TestGetEmployeeByID(t *testing.T) {
 		{
 			name:        "Valid employee",
 			id:          1,
-			expected:    models.Employee{ID: 1, Name: "John Doe", Position: "Developer", Salary: 60000.0},
+			expected:    models.Employee{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("60000")},
 			expectedErr: nil,
 		},
 		// Employee does not exist
@@ -72,13 +86,13 @@ func TestGetEmployeeByID(t *testing.T) {
 			name:        "Non-existent employee",
 			id:          3,
 			expected:    models.Employee{},
-			expectedErr: errors.New("employee not found"),
+			expectedErr: &NotFoundError{ID: 3},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			employee, err := GetEmployeeByID(tt.id)
+			employee, err := s.GetEmployeeByID(tt.id)
 
 			if tt.expectedErr != nil {
 				if err == nil || err.Error() != tt.expectedErr.Error() {
@@ -101,15 +115,16 @@ func TestGetEmployeeByID(t *testing.T) {
 
 func TestUpdateEmployee(t *testing.T) {
 	// Initialize some employees for testing
-	CreateEmployee("John Doe", "Developer", 60000.0)
-	CreateEmployee("Alice Smith", "Manager", 80000.0)
+	s := NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
 
 	tests := []struct {
 		name           string
 		id             int
 		nameToUpdate   string
 		positionUpdate string
-		salaryUpdate   float64
+		salaryUpdate   models.Money
 		expectedName   string
 		expectedError  error
 	}{
@@ -119,7 +134,7 @@ func TestUpdateEmployee(t *testing.T) {
 			id:             1,
 			nameToUpdate:   "Updated John Doe",
 			positionUpdate: "Senior Developer",
-			salaryUpdate:   70000.0,
+			salaryUpdate:   usd("70000"),
 			expectedName:   "Updated John Doe",
 			expectedError:  nil,
 		},
@@ -129,15 +144,15 @@ func TestUpdateEmployee(t *testing.T) {
 			id:             19,
 			nameToUpdate:   "New Employee",
 			positionUpdate: "Tester",
-			salaryUpdate:   50000.0,
+			salaryUpdate:   usd("50000"),
 			expectedName:   "",
-			expectedError:  errors.New("Employee not found"),
+			expectedError:  &NotFoundError{ID: 19},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			updatedEmployee, err := UpdateEmployee(tt.id, tt.nameToUpdate, tt.positionUpdate, tt.salaryUpdate)
+			updatedEmployee, err := s.UpdateEmployee(tt.id, tt.nameToUpdate, tt.positionUpdate, tt.salaryUpdate)
 
 			if tt.expectedError != nil {
 				if err == nil || err.Error() != tt.expectedError.Error() {
@@ -157,10 +172,11 @@ func TestUpdateEmployee(t *testing.T) {
 
 func TestListEmployees(t *testing.T) {
 	// Initialize some employees for testing
-	CreateEmployee("John Doe", "Developer", 60000.0)
-	CreateEmployee("Alice Smith", "Manager", 80000.0)
-	CreateEmployee("Bob Johnson", "Designer", 70000.0)
-	CreateEmployee("Eve Williams", "Tester", 65000.0)
+	s := NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+	s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+	s.CreateEmployee("Eve Williams", "Tester", usd("65000"))
 
 	tests := []struct {
 		name          string
@@ -175,12 +191,12 @@ func TestListEmployees(t *testing.T) {
 			perPage:       2,
 			expectedCount: 2,
 		},
-		// Valid case: List second page with 3 employees per page
+		// Valid case: List second page with 3 employees per page (only 1 left)
 		{
 			name:          "List second page with 3 employees per page",
 			page:          2,
 			perPage:       3,
-			expectedCount: 3,
+			expectedCount: 1,
 		},
 		// Valid case: List third page with 10 employees per page (only 2 employees available)
 		{
@@ -213,7 +229,7 @@ func TestListEmployees(t *testing.T) {
 			// Perform the test within a mutex lock to ensure no race conditions
 			var paginatedEmployees []models.Employee
 			mu.Lock()
-			paginatedEmployees = ListEmployees(tt.page, tt.perPage)
+			paginatedEmployees, _ = s.ListEmployees(ListOptions{Page: tt.page, PerPage: tt.perPage})
 			mu.Unlock()
 
 			if len(paginatedEmployees) != tt.expectedCount {
@@ -225,6 +241,10 @@ func TestListEmployees(t *testing.T) {
 }
 
 func TestDeleteEmployee(t *testing.T) {
+	// Initialize some employees for testing
+	s := NewMemoryStore()
+	s.CreateEmployee("John Doe", "Developer", usd("60000"))
+	s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
 
 	tests := []struct {
 		name         string
@@ -237,14 +257,14 @@ func TestDeleteEmployee(t *testing.T) {
 			name:         "Valid case: Delete existing employee",
 			id:           1,
 			expectedErr:  nil,
-			expectedSize: 7,
+			expectedSize: 1,
 		},
 		// Invalid case: Delete non-existing employee
 		{
 			name:         "Invalid case: Delete non-existing employee",
 			id:           20,
-			expectedErr:  errors.New("Employee not found"),
-			expectedSize: 7,
+			expectedErr:  &NotFoundError{ID: 20},
+			expectedSize: 1,
 		},
 	}
 
@@ -255,8 +275,8 @@ func TestDeleteEmployee(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Perform the test within a mutex lock to ensure no race conditions
 			mu.Lock()
-			err := DeleteEmployee(tt.id)
-			size := len(employees)
+			err := s.DeleteEmployee(tt.id)
+			size := len(s.employees)
 			mu.Unlock()
 
 			if (err != nil && tt.expectedErr == nil) || (err == nil && tt.expectedErr != nil) || (err != nil && tt.expectedErr != nil && err.Error() != tt.expectedErr.Error()) {
@@ -269,3 +289,207 @@ func TestDeleteEmployee(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchEmployee(t *testing.T) {
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stores := map[string]EmployeeRepository{
+		"memory":  NewMemoryStore(),
+		"sqlite":  newTestSQLiteStore(t),
+		"journal": journal,
+	}
+	raise := func(e models.Employee) (models.Employee, error) {
+		e.Salary, _ = e.Salary.Add(usd("5000"))
+		e.ID = 99 // ignored: the id cannot change
+		e.Version = 99
+		return e, nil
+	}
+	errAbort := errors.New("abort")
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+
+			got, err := s.PatchEmployee(1, raise)
+			want := models.Employee{ID: 1, Name: "John Doe", Position: "Developer", Salary: usd("65000"), Version: 2}
+			if err != nil || got != want {
+				t.Errorf("PatchEmployee() = %v, %v; want %v", got, err, want)
+			}
+
+			_, err = s.PatchEmployee(1, func(e models.Employee) (models.Employee, error) {
+				return models.Employee{}, errAbort
+			})
+			if !errors.Is(err, errAbort) {
+				t.Errorf("PatchEmployee() error = %v, want the callback's error", err)
+			}
+			if got, _ := s.GetEmployeeByID(1); got != want {
+				t.Errorf("aborted patch changed the employee to %v", got)
+			}
+
+			if _, err := s.PatchEmployee(2, raise); err == nil {
+				t.Errorf("PatchEmployee() of a missing employee succeeded")
+			}
+		})
+	}
+}
+
+func TestEmployeeVersions(t *testing.T) {
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stores := map[string]EmployeeRepository{
+		"memory":  NewMemoryStore(),
+		"sqlite":  newTestSQLiteStore(t),
+		"journal": journal,
+	}
+	errStale := errors.New("stale")
+	ifVersion := func(version int) func(models.Employee) error {
+		return func(e models.Employee) error {
+			if e.Version != version {
+				return errStale
+			}
+			return nil
+		}
+	}
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			created, _ := s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			updated, _ := s.UpdateEmployee(1, "John Doe", "Senior Developer", usd("70000"))
+			got, _ := s.GetEmployeeByID(1)
+			if created.Version != 1 || updated.Version != 2 || got.Version != 2 {
+				t.Errorf("versions after create, update, get = %d, %d, %d; want 1, 2, 2",
+					created.Version, updated.Version, got.Version)
+			}
+
+			if err := s.DeleteEmployeeIf(1, ifVersion(1)); !errors.Is(err, errStale) {
+				t.Errorf("DeleteEmployeeIf() with a stale version error = %v, want the check's error", err)
+			}
+			if _, err := s.GetEmployeeByID(1); err != nil {
+				t.Errorf("rejected DeleteEmployeeIf() removed the employee")
+			}
+			if err := s.DeleteEmployeeIf(1, ifVersion(2)); err != nil {
+				t.Errorf("DeleteEmployeeIf() with the current version error = %v", err)
+			}
+			if _, err := s.GetEmployeeByID(1); err == nil {
+				t.Errorf("DeleteEmployeeIf() did not remove the employee")
+			}
+		})
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stores := map[string]EmployeeRepository{
+		"memory":  NewMemoryStore(),
+		"sqlite":  newTestSQLiteStore(t),
+		"journal": journal,
+	}
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+
+			var notFound *NotFoundError
+			if _, err := s.GetEmployeeByID(5); !errors.As(err, &notFound) || notFound.ID != 5 {
+				t.Errorf("GetEmployeeByID() error = %v, want a NotFoundError for 5", err)
+			}
+			if err := s.DeleteEmployee(5); !errors.As(err, &notFound) {
+				t.Errorf("DeleteEmployee() error = %v, want a NotFoundError", err)
+			}
+
+			var invalid *ValidationError
+			if _, err := s.CreateEmployee("", "Developer", usd("-1")); !errors.As(err, &invalid) || len(invalid.Errors) != 2 {
+				t.Errorf("CreateEmployee() error = %v, want a ValidationError with 2 violations", err)
+			}
+			if _, err := s.UpdateEmployee(1, "John Doe", "Developer", usd("0")); !errors.As(err, &invalid) {
+				t.Errorf("UpdateEmployee() error = %v, want a ValidationError", err)
+			}
+			if got, _ := s.GetEmployeeByID(1); got.Salary != usd("60000") {
+				t.Errorf("rejected update changed the employee to %v", got)
+			}
+		})
+	}
+}
+
+func TestExportEmployees(t *testing.T) {
+	journal, err := NewJournalStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stores := map[string]EmployeeRepository{
+		"memory":  NewMemoryStore(),
+		"sqlite":  newTestSQLiteStore(t),
+		"journal": journal,
+	}
+	where, err := filter.Parse(`salary ge 65000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for storeName, s := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			s.CreateEmployee("John Doe", "Developer", usd("60000"))
+			s.CreateEmployee("Alice Smith", "Manager", usd("80000"))
+			s.CreateEmployee("Bob Johnson", "Designer", usd("70000"))
+
+			var got []string
+			err := s.ExportEmployees(nil, func(e models.Employee) error {
+				// Writes made while the export runs must not show up in it.
+				if e.ID == 1 {
+					if _, err := s.CreateEmployee("Eve Williams", "Tester", usd("65000")); err != nil {
+						return err
+					}
+					if _, err := s.UpdateEmployee(2, "Alice Smith", "Director", usd("90000")); err != nil {
+						return err
+					}
+					if err := s.DeleteEmployee(3); err != nil {
+						return err
+					}
+				}
+				got = append(got, e.Name+" "+e.Position)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ExportEmployees() unexpected error: %v", err)
+			}
+			want := []string{"John Doe Developer", "Alice Smith Manager", "Bob Johnson Designer"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ExportEmployees() = %v, want %v", got, want)
+			}
+
+			got = nil
+			s.ExportEmployees(where, func(e models.Employee) error {
+				got = append(got, e.Name)
+				return nil
+			})
+			if want := []string{"Alice Smith", "Eve Williams"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("ExportEmployees(%v) = %v, want %v", where, got, want)
+			}
+
+			errStop := errors.New("stop")
+			calls := 0
+			err = s.ExportEmployees(nil, func(models.Employee) error {
+				calls++
+				return errStop
+			})
+			if !errors.Is(err, errStop) || calls != 1 {
+				t.Errorf("ExportEmployees() with a failing fn = %v after %d calls, want the fn's error after 1", err, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package validation
+
+import (
+	"ems/models"
+	"strconv"
+	"time"
+)
+
+// IsCurrency reports whether code is an active ISO 4217 currency code, such
+// as USD. Codes must be upper case.
+func IsCurrency(code string) bool {
+	_, ok := models.CurrencyDigits(code)
+	return ok
+}
+
+// IsDate reports whether s is a calendar date in models.DateLayout.
+func IsDate(s string) bool {
+	_, err := time.Parse(models.DateLayout, s)
+	return err == nil
+}
+
+// MinorUnits rejects amounts with more decimal places than the minor unit
+// of their currency, such as cents for USD. Amounts in unknown currencies
+// pass; the currency check reports those.
+func MinorUnits() Rule[models.Money] {
+	return func(field string, value models.Money) *Violation {
+		if digits, ok := models.CurrencyDigits(value.Currency()); ok && value.Places() > digits {
+			return violation(field, CodeInvalidFormat, "%s must have at most %d decimal places in %s", field, digits, value.Currency())
+		}
+		return nil
+	}
+}
+
+// Positive rejects amounts of zero or less.
+func Positive() Rule[models.Money] {
+	return func(field string, value models.Money) *Violation {
+		if value.Units() <= 0 {
+			return violation(field, CodeTooSmall, "%s must be greater than 0", field)
+		}
+		return nil
+	}
+}
+
+// AtMostAmount rejects amounts greater than max whole units of their
+// currency, comparing the exact decimal amounts.
+func AtMostAmount(max int64) Rule[models.Money] {
+	limit := models.MustParseMoney(strconv.FormatInt(max, 10), "")
+	return func(field string, value models.Money) *Violation {
+		if value.Cmp(limit) > 0 {
+			return violation(field, CodeTooLarge, "%s must be at most %d", field, max)
+		}
+		return nil
+	}
+}
+
+// checkMoney records violations of the salary rules by m: an amount within
+// the salary limits and the currency's minor unit, in a known currency.
+func checkMoney(v *Validator, field string, m models.Money) {
+	checkAmount(v, field, m)
+	checkCurrency(v, field+".currency", m.Currency())
+}
+
+// checkAmount records violations of the salary rules by m's amount.
+func checkAmount(v *Validator, field string, m models.Money) {
+	n := len(v.errs)
+	Check(v, field, m, Positive(), AtMostAmount(MaxSalary))
+	if len(v.errs) == n {
+		Check(v, field, m, MinorUnits())
+	}
+}
+
+// checkCurrency records a violation if code is not a known currency.
+func checkCurrency(v *Validator, field, code string) {
+	Check(v, field, code,
+		Required(),
+		Format("an ISO 4217 currency code such as USD", IsCurrency))
+}
+
+// Compensation checks c against the compensation rules and returns Errors
+// listing every violation, or nil. Salaries follow the employee salary
+// rules and reasons allow the same characters as positions.
+func Compensation(c models.Compensation) error {
+	var v Validator
+	checkMoney(&v, "salary", c.Salary)
+	Check(&v, "effective_date", c.EffectiveDate,
+		Required(),
+		Format("a date such as 2024-01-31", IsDate))
+	Check(&v, "reason", c.Reason,
+		Length(0, MaxReasonLength),
+		Characters("letters, digits, spaces and ' - . , & / ( )", positionRune))
+	return v.Err()
+}
@@ -0,0 +1,15 @@
+package validation
+
+import "ems/models"
+
+// Department checks d against the department rules and returns Errors
+// listing every violation, or nil. Names allow the same characters as
+// positions.
+func Department(d models.Department) error {
+	var v Validator
+	Check(&v, "name", d.Name,
+		Required(),
+		Length(1, MaxDepartmentName),
+		Characters("letters, digits, spaces and ' - . , & / ( )", positionRune))
+	return v.Err()
+}
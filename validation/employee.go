@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"bytes"
+	"ems/models"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Limits on employee fields.
+const (
+	MaxNameLength     = 100
+	MaxPositionLength = 100
+	MaxDepartmentName = 100
+	MaxReasonLength   = 200
+
+	// MaxSalary bounds a salary in whole units of its currency, whichever
+	// that is. It only guards against nonsense: a hundred billion covers
+	// salaries in currencies with the smallest units, such as VND and IRR,
+	// and keeps totals of many salaries well inside models.Money.
+	MaxSalary = 100_000_000_000
+)
+
+func nameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || r == ' ' || r == '\'' || r == '-' || r == '.'
+}
+
+func positionRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == ' ' ||
+		r == '\'' || r == '-' || r == '.' || r == ',' || r == '&' || r == '/' || r == '(' || r == ')'
+}
+
+// checkEmployee records violations of the employee rules, skipping fields
+// listed in skip because they already have one or, for salary.currency,
+// because the store fills it in.
+func checkEmployee(v *Validator, e models.Employee, skip map[string]bool) {
+	if !skip["name"] {
+		Check(v, "name", e.Name,
+			Required(),
+			Length(1, MaxNameLength),
+			Characters("letters, spaces, apostrophes, hyphens and periods", nameRune))
+	}
+	if !skip["position"] {
+		Check(v, "position", e.Position,
+			Required(),
+			Length(1, MaxPositionLength),
+			Characters("letters, digits, spaces and ' - . , & / ( )", positionRune))
+	}
+	if !skip["salary"] {
+		checkAmount(v, "salary", e.Salary)
+		if !skip["salary.currency"] {
+			checkCurrency(v, "salary.currency", e.Salary.Currency())
+		}
+	}
+	if !skip["department_id"] && e.DepartmentID < 0 {
+		v.Add("department_id", CodeTooSmall, "department_id must be a department id")
+	}
+	if !skip["manager_id"] && e.ManagerID < 0 {
+		v.Add("manager_id", CodeTooSmall, "manager_id must be an employee id")
+	}
+}
+
+// Employee checks e against the employee rules and returns Errors listing
+// every violation, or nil.
+func Employee(e models.Employee) error {
+	var v Validator
+	checkEmployee(&v, e, nil)
+	return v.Err()
+}
+
+// EmployeeInput checks e as a client sent it, before the store fills in
+// anything. It is Employee, except that a salary without a currency passes.
+func EmployeeInput(e models.Employee) error {
+	var v Validator
+	checkEmployee(&v, e, map[string]bool{"salary.currency": e.Salary.Currency() == ""})
+	return v.Err()
+}
+
+// employeeFields are the members an employee document may contain, in the
+// order violations are reported. id is accepted so a fetched employee can be
+// sent back as is, but callers decide what it means.
+var employeeFields = []string{"id", "name", "position", "salary", "department_id", "manager_id"}
+
+// DecodeEmployee decodes an employee JSON object and validates it. Unknown
+// members, members of the wrong type and rule violations are all returned
+// together as Errors. Any other error means data is not valid JSON. A salary
+// without a currency, such as a plain number, is left in no currency for the
+// store to put in the employee's own.
+func DecodeEmployee(data []byte) (models.Employee, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return models.Employee{}, Errors{{Field: "", Code: CodeInvalidType, Message: "employee must be a JSON object"}}
+		}
+		return models.Employee{}, err
+	}
+
+	var (
+		e       models.Employee
+		v       Validator
+		invalid = make(map[string]bool)
+	)
+	targets := map[string]any{"id": &e.ID, "name": &e.Name, "position": &e.Position, "salary": &e.Salary, "department_id": &e.DepartmentID, "manager_id": &e.ManagerID}
+	kinds := map[string]string{"id": "an integer", "name": "a string", "position": "a string", "salary": "a number or an object with an amount and a currency", "department_id": "an integer", "manager_id": "an integer"}
+	for _, field := range employeeFields {
+		raw, ok := members[field]
+		// null is treated like a missing member, as encoding/json does.
+		if !ok || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+		if err := json.Unmarshal(raw, targets[field]); err != nil {
+			v.Add(field, CodeInvalidType, field+" must be "+kinds[field])
+			invalid[field] = true
+		}
+	}
+
+	var unknown []string
+	for field := range members {
+		if !slices.Contains(employeeFields, field) {
+			unknown = append(unknown, field)
+		}
+	}
+	slices.Sort(unknown)
+	for _, field := range unknown {
+		v.Add(field, CodeUnknownField, "unknown field "+field)
+	}
+
+	invalid["salary.currency"] = e.Salary.Currency() == ""
+	checkEmployee(&v, e, invalid)
+	return e, v.Err()
+}
+
+// ParseEmployee builds an employee from text fields, such as the cells of a
+// spreadsheet row, and validates it. The salary must be a plain decimal
+// number; anything else is reported as invalid_type. An empty currency
+// means models.DefaultCurrency.
+func ParseEmployee(name, position, salary, currency string) (models.Employee, error) {
+	var (
+		v       Validator
+		invalid = make(map[string]bool)
+	)
+	e := models.Employee{Name: strings.TrimSpace(name), Position: strings.TrimSpace(position)}
+	salary = strings.TrimSpace(salary)
+	if strings.TrimSpace(currency) == "" {
+		currency = models.DefaultCurrency
+	}
+	if salary == "" {
+		v.Add("salary", CodeRequired, "salary is required")
+		invalid["salary"] = true
+	} else if parsed, err := models.ParseMoney(salary, currency); err != nil {
+		v.Add("salary", CodeInvalidType, "salary must be a number")
+		invalid["salary"] = true
+	} else {
+		e.Salary = parsed
+	}
+
+	checkEmployee(&v, e, invalid)
+	return e, v.Err()
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"ems/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxRateLength caps how long a rate may be, so a rate with thousands of
+// digits cannot make every conversion through it slow.
+const MaxRateLength = 32
+
+// ratePattern is a plain decimal number, without sign or exponent.
+var ratePattern = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// IsRate reports whether s is a positive decimal such as 1.0843. Signs,
+// exponents, NaN and infinities are not decimals, so every rate is finite
+// as well as positive.
+func IsRate(s string) bool {
+	return ratePattern.MatchString(s) && strings.Trim(s, "0.") != ""
+}
+
+// ExchangeRates checks every rate and returns Errors listing each
+// violation, or nil. Fields are keyed by the rate's position, as in
+// rates[2].date.
+func ExchangeRates(rates []models.ExchangeRate) error {
+	var v Validator
+	for i, r := range rates {
+		prefix := fmt.Sprintf("rates[%d].", i)
+		Check(&v, prefix+"date", r.Date,
+			Required(),
+			Format("a date such as 2024-01-31", IsDate))
+		Check(&v, prefix+"from", r.From,
+			Required(),
+			Format("an ISO 4217 currency code such as USD", IsCurrency))
+		Check(&v, prefix+"to", r.To,
+			Required(),
+			Format("an ISO 4217 currency code such as USD", IsCurrency))
+		if r.From != "" && r.From == r.To {
+			v.Add(prefix+"to", CodeInvalidFormat, prefix+"to must differ from "+prefix+"from")
+		}
+		Check(&v, prefix+"rate", r.Rate,
+			Required(),
+			Length(1, MaxRateLength),
+			Format("a positive decimal such as 1.0843", IsRate))
+	}
+	return v.Err()
+}
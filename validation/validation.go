@@ -0,0 +1,149 @@
+// Package validation checks values against field rules and reports every
+// violation at once, keyed by the JSON path of the offending field, so
+// clients can point at each wrong field instead of showing one message.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is one failed rule. Code is stable and meant for programs;
+// Message is meant for people.
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Violation codes.
+const (
+	CodeRequired          = "required"
+	CodeTooShort          = "too_short"
+	CodeTooLong           = "too_long"
+	CodeInvalidCharacters = "invalid_characters"
+	CodeTooSmall          = "too_small"
+	CodeTooLarge          = "too_large"
+	CodeInvalidType       = "invalid_type"
+	CodeUnknownField      = "unknown_field"
+	CodeReadOnly          = "read_only"
+	CodeNotFound          = "not_found"
+	CodeInvalidFormat     = "invalid_format"
+)
+
+// Errors is every violation found in one value.
+type Errors []Violation
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, v := range e {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Rule checks a single value. It returns nil when the value passes. The
+// field name is passed in only to build the message.
+type Rule[T any] func(field string, value T) *Violation
+
+// Validator accumulates violations across fields.
+type Validator struct {
+	errs Errors
+}
+
+// Add records a violation.
+func (v *Validator) Add(field, code, message string) {
+	v.errs = append(v.errs, Violation{Field: field, Code: code, Message: message})
+}
+
+// Err returns the violations recorded so far as Errors, or nil if there are
+// none.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Check runs rules against value in order and records the first one that
+// fails; later rules usually make no sense once an earlier one has failed.
+func Check[T any](v *Validator, field string, value T, rules ...Rule[T]) {
+	for _, rule := range rules {
+		if violation := rule(field, value); violation != nil {
+			v.errs = append(v.errs, *violation)
+			return
+		}
+	}
+}
+
+func violation(field, code, format string, args ...any) *Violation {
+	return &Violation{Field: field, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Required rejects strings that are empty or only whitespace.
+func Required() Rule[string] {
+	return func(field, value string) *Violation {
+		if strings.TrimSpace(value) == "" {
+			return violation(field, CodeRequired, "%s is required", field)
+		}
+		return nil
+	}
+}
+
+// Length bounds the number of characters (not bytes) in a string.
+func Length(min, max int) Rule[string] {
+	return func(field, value string) *Violation {
+		switch n := utf8.RuneCountInString(value); {
+		case n < min:
+			return violation(field, CodeTooShort, "%s must be at least %d characters", field, min)
+		case n > max:
+			return violation(field, CodeTooLong, "%s must be at most %d characters", field, max)
+		}
+		return nil
+	}
+}
+
+// Characters rejects strings containing a rune for which allowed returns
+// false. description completes "may only contain ...".
+func Characters(description string, allowed func(rune) bool) Rule[string] {
+	return func(field, value string) *Violation {
+		for _, r := range value {
+			if !allowed(r) {
+				return violation(field, CodeInvalidCharacters, "%s may only contain %s", field, description)
+			}
+		}
+		return nil
+	}
+}
+
+// Format rejects strings for which valid returns false. description
+// completes "must be ...".
+func Format(description string, valid func(string) bool) Rule[string] {
+	return func(field, value string) *Violation {
+		if !valid(value) {
+			return violation(field, CodeInvalidFormat, "%s must be %s", field, description)
+		}
+		return nil
+	}
+}
+
+// Above rejects numbers that are not strictly greater than min.
+func Above(min float64) Rule[float64] {
+	return func(field string, value float64) *Violation {
+		if !(value > min) {
+			return violation(field, CodeTooSmall, "%s must be greater than %v", field, min)
+		}
+		return nil
+	}
+}
+
+// AtMost rejects numbers greater than max.
+func AtMost(max float64) Rule[float64] {
+	return func(field string, value float64) *Violation {
+		if !(value <= max) {
+			return violation(field, CodeTooLarge, "%s must be at most %v", field, max)
+		}
+		return nil
+	}
+}
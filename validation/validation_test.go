@@ -0,0 +1,295 @@
+package validation
+
+import (
+	"ems/models"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// usd is amount in US dollars.
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+func fieldsAndCodes(err error) [][2]string {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	var got [][2]string
+	for _, v := range errs {
+		got = append(got, [2]string{v.Field, v.Code})
+	}
+	return got
+}
+
+func TestEmployee(t *testing.T) {
+	tests := []struct {
+		name     string
+		employee models.Employee
+		want     [][2]string
+	}{
+		{
+			name:     "Valid",
+			employee: models.Employee{Name: "José O'Neil-Smith Jr.", Position: "R&D Engineer (Level 2)", Salary: usd("60000")},
+		},
+		{
+			name:     "Everything missing",
+			employee: models.Employee{},
+			want:     [][2]string{{"name", CodeRequired}, {"position", CodeRequired}, {"salary", CodeTooSmall}, {"salary.currency", CodeRequired}},
+		},
+		{
+			name:     "Blank name",
+			employee: models.Employee{Name: "   ", Position: "Developer", Salary: usd("1")},
+			want:     [][2]string{{"name", CodeRequired}},
+		},
+		{
+			name:     "Too long",
+			employee: models.Employee{Name: strings.Repeat("a", MaxNameLength+1), Position: strings.Repeat("é", MaxPositionLength), Salary: usd("1")},
+			want:     [][2]string{{"name", CodeTooLong}},
+		},
+		{
+			name:     "Bad characters",
+			employee: models.Employee{Name: "John <script>", Position: "Developer; DROP TABLE", Salary: usd("1")},
+			want:     [][2]string{{"name", CodeInvalidCharacters}, {"position", CodeInvalidCharacters}},
+		},
+		{
+			name:     "Salary in a currency with small units",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: models.MustParseMoney("15000000", "JPY")},
+		},
+		{
+			name:     "Largest salary",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: models.MustParseMoney("100000000000", "VND")},
+		},
+		{
+			name:     "Salary too large",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("100000000000.01")},
+			want:     [][2]string{{"salary", CodeTooLarge}},
+		},
+		{
+			name:     "Salary too small",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("0.0001")},
+			want:     [][2]string{{"salary", CodeInvalidFormat}},
+		},
+		{
+			name:     "Fractions of a cent",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("60000.005")},
+			want:     [][2]string{{"salary", CodeInvalidFormat}},
+		},
+		{
+			name:     "Yen have no minor unit",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: models.MustParseMoney("6000000.5", "JPY")},
+			want:     [][2]string{{"salary", CodeInvalidFormat}},
+		},
+		{
+			name:     "Unknown currency",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: models.MustParseMoney("60000", "XYZ")},
+			want:     [][2]string{{"salary.currency", CodeInvalidFormat}},
+		},
+		{
+			name:     "Negative department",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("1"), DepartmentID: -1},
+			want:     [][2]string{{"department_id", CodeTooSmall}},
+		},
+		{
+			name:     "Negative manager",
+			employee: models.Employee{Name: "John Doe", Position: "Developer", Salary: usd("1"), ManagerID: -1},
+			want:     [][2]string{{"manager_id", CodeTooSmall}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Employee(tt.employee)
+			if got := fieldsAndCodes(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Employee() violations = %v, want %v (error %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDecodeEmployee(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    [][2]string
+		wantErr bool
+	}{
+		{
+			name:    "Valid with id",
+			payload: `{"id":0,"name":"John Doe","position":"Developer","salary":60000}`,
+		},
+		{
+			name:    "Salary with a currency",
+			payload: `{"name":"John Doe","position":"Developer","salary":{"amount":"5000000","currency":"INR"}}`,
+		},
+		{
+			name:    "Salary with too many decimal places",
+			payload: `{"name":"John Doe","position":"Developer","salary":{"amount":"1.00001","currency":"USD"}}`,
+			want:    [][2]string{{"salary", CodeInvalidType}},
+		},
+		{
+			name:    "Salary without a currency",
+			payload: `{"name":"John Doe","position":"Developer","salary":{"amount":"1"}}`,
+		},
+		{
+			name:    "Salary without a currency out of range",
+			payload: `{"name":"John Doe","position":"Developer","salary":-1}`,
+			want:    [][2]string{{"salary", CodeTooSmall}},
+		},
+		{
+			name:    "Every problem at once",
+			payload: `{"name":42,"position":"","salary":"lots","nickname":"JD","age":30}`,
+			want: [][2]string{
+				{"name", CodeInvalidType}, {"salary", CodeInvalidType},
+				{"age", CodeUnknownField}, {"nickname", CodeUnknownField},
+				{"position", CodeRequired},
+			},
+		},
+		{
+			name:    "Department that is not an id",
+			payload: `{"name":"John Doe","position":"Developer","salary":1,"department_id":"Sales"}`,
+			want:    [][2]string{{"department_id", CodeInvalidType}},
+		},
+		{
+			name:    "Null counts as missing",
+			payload: `{"name":null,"position":"Developer","salary":1}`,
+			want:    [][2]string{{"name", CodeRequired}},
+		},
+		{
+			name:    "Not an object",
+			payload: `["John Doe"]`,
+			want:    [][2]string{{"", CodeInvalidType}},
+		},
+		{
+			name:    "Malformed JSON",
+			payload: `{"name":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeEmployee([]byte(tt.payload))
+			var errs Errors
+			if tt.wantErr {
+				if err == nil || errors.As(err, &errs) {
+					t.Errorf("DecodeEmployee() error = %v, want a syntax error", err)
+				}
+				return
+			}
+			if got := fieldsAndCodes(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeEmployee() violations = %v, want %v (error %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDepartment(t *testing.T) {
+	tests := []struct {
+		name       string
+		department models.Department
+		want       [][2]string
+	}{
+		{name: "Valid", department: models.Department{Name: "R&D (Europe)"}},
+		{name: "Missing name", department: models.Department{Name: " "}, want: [][2]string{{"name", CodeRequired}}},
+		{name: "Bad characters", department: models.Department{Name: "Sales; DROP TABLE"}, want: [][2]string{{"name", CodeInvalidCharacters}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Department(tt.department)
+			if got := fieldsAndCodes(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Department() violations = %v, want %v (error %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCompensation(t *testing.T) {
+	valid := models.Compensation{Salary: usd("60000"), EffectiveDate: "2024-02-29", Reason: "Promotion to Lead"}
+	tests := []struct {
+		name string
+		edit func(c *models.Compensation)
+		want [][2]string
+	}{
+		{name: "Valid", edit: func(c *models.Compensation) {}},
+		{name: "Unknown currency", edit: func(c *models.Compensation) { c.Salary = models.MustParseMoney("60000", "ABC") }, want: [][2]string{{"salary.currency", CodeInvalidFormat}}},
+		{name: "Impossible date", edit: func(c *models.Compensation) { c.EffectiveDate = "2023-02-29" }, want: [][2]string{{"effective_date", CodeInvalidFormat}}},
+		{name: "Reason too long", edit: func(c *models.Compensation) { c.Reason = strings.Repeat("a", MaxReasonLength+1) }, want: [][2]string{{"reason", CodeTooLong}}},
+		{name: "Everything missing", edit: func(c *models.Compensation) { *c = models.Compensation{} }, want: [][2]string{{"salary", CodeTooSmall}, {"salary.currency", CodeRequired}, {"effective_date", CodeRequired}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.edit(&c)
+			err := Compensation(c)
+			if got := fieldsAndCodes(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compensation() violations = %v, want %v (error %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExchangeRates(t *testing.T) {
+	tests := []struct {
+		rate string
+		want [][2]string
+	}{
+		{rate: "1.0843"},
+		{rate: "150"},
+		{rate: "0", want: [][2]string{{"rates[0].rate", CodeInvalidFormat}}},
+		{rate: "0.000", want: [][2]string{{"rates[0].rate", CodeInvalidFormat}}},
+		{rate: "-1.1", want: [][2]string{{"rates[0].rate", CodeInvalidFormat}}},
+		{rate: "+1.1", want: [][2]string{{"rates[0].rate", CodeInvalidFormat}}},
+		{rate: "1e3", want: [][2]string{{"rates[0].rate", CodeInvalidFormat}}},
+		{rate: "NaN", want: [][2]string{{"rates[0].rate", CodeInvalidFormat}}},
+		{rate: "Inf", want: [][2]string{{"rates[0].rate", CodeInvalidFormat}}},
+		{rate: "0." + strings.Repeat("0", MaxRateLength) + "1", want: [][2]string{{"rates[0].rate", CodeTooLong}}},
+		{rate: "", want: [][2]string{{"rates[0].rate", CodeRequired}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			err := ExchangeRates([]models.ExchangeRate{{Date: "2026-01-02", From: "EUR", To: "USD", Rate: tt.rate}})
+			if got := fieldsAndCodes(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExchangeRates() violations = %v, want %v (error %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseEmployee(t *testing.T) {
+	tests := []struct {
+		name                   string
+		employeeName, position string
+		salary, currency       string
+		want                   [][2]string
+	}{
+		{name: "Valid", employeeName: " John Doe ", position: "Developer", salary: " 60000.50 "},
+		{name: "Valid in euros", employeeName: "John Doe", position: "Developer", salary: "60000.5", currency: " eur "},
+		{name: "Unknown currency", employeeName: "John Doe", position: "Developer", salary: "60000", currency: "Euro", want: [][2]string{{"salary.currency", CodeInvalidFormat}}},
+		{name: "Missing salary", employeeName: "John Doe", position: "Developer", want: [][2]string{{"salary", CodeRequired}}},
+		{name: "Salary with separators", employeeName: "John Doe", position: "Developer", salary: "60,000", want: [][2]string{{"salary", CodeInvalidType}}},
+		{name: "Infinite salary", employeeName: "John Doe", position: "Developer", salary: "Inf", want: [][2]string{{"salary", CodeInvalidType}}},
+		{name: "Everything wrong", salary: "-1", want: [][2]string{{"name", CodeRequired}, {"position", CodeRequired}, {"salary", CodeTooSmall}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := ParseEmployee(tt.employeeName, tt.position, tt.salary, tt.currency)
+			if got := fieldsAndCodes(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEmployee() violations = %v, want %v (error %v)", got, tt.want, err)
+			}
+			currency := strings.ToUpper(strings.TrimSpace(tt.currency))
+			if currency == "" {
+				currency = "USD"
+			}
+			if tt.want == nil && (e.Name != "John Doe" || e.Salary != models.MustParseMoney("60000.5", currency)) {
+				t.Errorf("ParseEmployee() = %+v, want trimmed name and salary 60000.5 %s", e, currency)
+			}
+		})
+	}
+}